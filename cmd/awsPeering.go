@@ -6,6 +6,7 @@ import (
 	"github.com/hazelcast/hazelcast-cloud-cli/internal"
 	"github.com/hazelcast/hazelcast-cloud-cli/service"
 	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
 	"github.com/jedib0t/go-pretty/v6/table"
 
@@ -16,6 +17,14 @@ var awsPeeringId string
 var awsRegion string
 var awsVpcId string
 var awsSubnetIds []string
+var awsNoRollback bool
+var awsReplaceRoutes bool
+var awsProfile string
+var awsAssumeRoleArn string
+var awsIngressSecurityGroupIds []string
+var awsEgressSecurityGroupIds []string
+var awsHazelcastOnly bool
+var awsPeeringConnectionId string
 
 var awsPeeringCmd = &cobra.Command{
 	Use:     "aws-peering",
@@ -26,33 +35,155 @@ var awsPeeringCmd = &cobra.Command{
 var awsPeeringCreateCmd = &cobra.Command{
 	Use:     "create",
 	Short:   "This command creates AWS VPC Peering between your own vpc and your Enterprise Hazelcast cluster.",
-	Example: "hzcloud aws-peering create --cluster-id=1 --vpc-id=2 --subnet-ids=a,b,c",
+	Example: "hzcloud aws-peering create --cluster-id=1 --vpc-id=2 --subnet-ids=a,b,c --egress-security-group-ids=sg-1",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
+		if hasPeeringTargets() {
+			createAwsPeerings(client)
+			return
+		}
+		if awsVpcId == "" || len(awsSubnetIds) == 0 {
+			color.Red("An error occurred. --vpc-id and --subnet-ids are required unless --target or --targets-file is set.")
+			return
+		}
+		if emitPeeringTemplate(func(format string) (string, error) {
+			awsPeeringService := service.NewAwsPeeringService(client, newAwsCustomerPeeringProperties(awsVpcId, awsSubnetIds, awsRegion))
+			return awsPeeringService.Emit(format)
+		}) {
+			return
+		}
 		indicator := util.NewLoadingIndicator("AWS Peering starting...", 100)
 		indicator.Start()
-		awsPeeringService := service.NewAwsPeeringService(client, &service.AwsCustomerPeeringProperties{
-			ClusterId: enterpriseClusterId,
-			Region:    awsRegion,
-			VpcId:     awsVpcId,
-			SubnetIds: awsSubnetIds,
-		})
+		awsPeeringService := service.NewAwsPeeringService(client, newAwsCustomerPeeringProperties(awsVpcId, awsSubnetIds, awsRegion))
 		peeringCreateErr := awsPeeringService.Create(indicator)
 		indicator.Stop()
 		if peeringCreateErr != nil {
 			color.Red("An error occurred. %s", peeringCreateErr)
 		} else {
 			color.Green("Peering successfully established.")
+			printAwsRouteChanges(awsPeeringService.RouteChanges())
+		}
+	},
+}
+
+func createAwsPeerings(client *hazelcastcloud.Client) {
+	targets, targetsErr := readPeeringTargets([]string{"vpc-id", "subnet-ids"}, []string{"region"})
+	if targetsErr != nil {
+		color.Red("An error occurred. %s", targetsErr)
+		return
+	}
+	labels := make([]string, len(targets))
+	for k, target := range targets {
+		labels[k] = target.value("vpc-id", "")
+	}
+	runPeeringTargets("AWS Peerings starting...", labels, func(index int, indicator *util.LoadingIndicator) error {
+		target := targets[index]
+		awsPeeringService := service.NewAwsPeeringService(client, newAwsCustomerPeeringProperties(target.value("vpc-id", ""),
+			target.values("subnet-ids", nil), target.value("region", awsRegion)))
+		return awsPeeringService.Create(indicator)
+	})
+}
+
+func newAwsCustomerPeeringProperties(vpcId string, subnetIds []string, region string) *service.AwsCustomerPeeringProperties {
+	return &service.AwsCustomerPeeringProperties{
+		ClusterId:               enterpriseClusterId,
+		Region:                  region,
+		VpcId:                   vpcId,
+		SubnetIds:               subnetIds,
+		NoRollback:              awsNoRollback,
+		ReplaceRoutes:           awsReplaceRoutes,
+		Profile:                 awsProfile,
+		AssumeRoleArn:           awsAssumeRoleArn,
+		IngressSecurityGroupIds: awsIngressSecurityGroupIds,
+		EgressSecurityGroupIds:  awsEgressSecurityGroupIds,
+	}
+}
+
+var awsPeeringDiagnoseCmd = &cobra.Command{
+	Use:     "diagnose",
+	Short:   "This command checks AWS VPC peerings of your Enterprise Hazelcast cluster against the vpc peering connections, routes and security groups in your account.",
+	Example: "hzcloud aws-peering diagnose --cluster-id=1 --egress-security-group-ids=sg-1",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newClient()
+		indicator := util.NewLoadingIndicator("AWS Peering diagnosing...", 100)
+		indicator.Start()
+		awsPeeringService := service.NewAwsPeeringService(client, &service.AwsCustomerPeeringProperties{
+			ClusterId:              enterpriseClusterId,
+			Region:                 awsRegion,
+			Profile:                awsProfile,
+			AssumeRoleArn:          awsAssumeRoleArn,
+			EgressSecurityGroupIds: awsEgressSecurityGroupIds,
+		})
+		checks, diagnoseErr := awsPeeringService.Diagnose(indicator)
+		indicator.Stop()
+		if diagnoseErr != nil {
+			color.Red("An error occurred. %s", diagnoseErr)
+			return
+		}
+		printPeeringChecks(checks)
+	},
+}
+
+func printAwsRouteChanges(routeChanges []service.AwsRouteChange) {
+	header := table.Row{"#", "Route Table Id", "Destination", "Previous Target", "Target", "Action"}
+	rows := []table.Row{}
+	for k, routeChange := range routeChanges {
+		rows = append(rows, table.Row{k + 1, routeChange.RouteTableId, routeChange.DestinationCidrBlock,
+			routeChange.PreviousTarget, routeChange.Target, routeChange.Action})
+	}
+	util.Print(util.PrintRequest{
+		Data:       routeChanges,
+		Header:     header,
+		Rows:       rows,
+		PrintStyle: util.PrintStyle(outputStyle),
+	})
+}
+
+var awsPeeringAcceptCmd = &cobra.Command{
+	Use:     "accept",
+	Short:   "This command completes the Hazelcast side of an AWS VPC Peering whose side in your account was created from the output of `create --emit`.",
+	Example: "hzcloud aws-peering accept --cluster-id=1 --vpc-id=2 --subnet-ids=a,b,c",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newClient()
+		indicator := util.NewLoadingIndicator("AWS Peering accepting...", 100)
+		indicator.Start()
+		awsPeeringService := service.NewAwsPeeringService(client, newAwsCustomerPeeringProperties(awsVpcId, awsSubnetIds, awsRegion))
+		peeringConnectionId, peeringAcceptErr := awsPeeringService.Accept(awsPeeringConnectionId, indicator)
+		indicator.Stop()
+		if peeringAcceptErr != nil {
+			color.Red("An error occurred. %s", peeringAcceptErr)
+		} else {
+			color.Green("Peering successfully established with vpc peering connection %s.", peeringConnectionId)
 		}
 	},
 }
 
+var awsPeeringPropertiesCmd = &cobra.Command{
+	Use:     "properties",
+	Short:   "This command shows the AWS VPC properties of your Enterprise Hazelcast cluster to create the peering manually.",
+	Example: "hzcloud aws-peering properties --cluster-id=1",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newClient()
+		properties := internal.Validate(client.AwsPeering.GetProperties(context.Background(), &models.GetAwsPeeringPropertiesInput{
+			ClusterId: enterpriseClusterId,
+		})).(*models.AwsPeeringProperties)
+		header := table.Row{"Owner Id", "Vpc Id", "Vpc Cidr", "Region"}
+		rows := []table.Row{{properties.OwnerId, properties.VpcId, properties.VpcCidr, properties.Region}}
+		util.Print(util.PrintRequest{
+			Data:       properties,
+			Header:     header,
+			Rows:       rows,
+			PrintStyle: util.PrintStyle(outputStyle),
+		})
+	},
+}
+
 var awsPeeringListCmd = &cobra.Command{
 	Use:     "list",
 	Short:   "This command lists AWS VPC peerings on your Enterprise Hazelcast cluster.",
 	Example: "hzcloud aws-peering list --cluster-id=1",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		peerings := internal.Validate(client.AwsPeering.List(context.Background(), &models.ListAwsPeeringsInput{
 			ClusterId: enterpriseClusterId,
 		})).(*[]models.AwsPeering)
@@ -72,39 +203,102 @@ var awsPeeringListCmd = &cobra.Command{
 
 var awsPeeringDeleteCmd = &cobra.Command{
 	Use:     "delete",
-	Short:   "This command delete GCP peering from your Enterprise Hazelcast cluster.",
-	Example: "hzcloud aws-peering delete --peering-id=1",
+	Short:   "This command deletes AWS VPC peering from your Enterprise Hazelcast cluster together with its routes and vpc peering connection in your account.",
+	Example: "hzcloud aws-peering delete --cluster-id=1 --peering-id=1",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
-		_ = internal.Validate(client.AwsPeering.Delete(context.Background(), &models.DeleteAwsPeeringInput{
-			Id: awsPeeringId,
-		})).(*models.Result)
+		client := newClient()
+		yes, _ := cmd.Flags().GetBool("yes")
+		confirmPeeringDeletion(client, awsPeeringId, yes)
+		if awsHazelcastOnly {
+			_ = internal.Validate(client.AwsPeering.Delete(context.Background(), &models.DeleteAwsPeeringInput{
+				Id: awsPeeringId,
+			})).(*models.Result)
+			color.Blue("Peering %s deleted.", awsPeeringId)
+			return
+		}
+		if enterpriseClusterId == "" {
+			color.Red("An error occurred. --cluster-id is required unless --hazelcast-only is set.")
+			return
+		}
+		indicator := util.NewLoadingIndicator("AWS Peering deleting...", 100)
+		indicator.Start()
+		awsPeeringService := service.NewAwsPeeringService(client, &service.AwsCustomerPeeringProperties{
+			ClusterId:     enterpriseClusterId,
+			Region:        awsRegion,
+			Profile:       awsProfile,
+			AssumeRoleArn: awsAssumeRoleArn,
+		})
+		peeringDeleteErr := awsPeeringService.Delete(awsPeeringId, indicator)
+		indicator.Stop()
+		if peeringDeleteErr != nil {
+			color.Red("An error occurred. %s", peeringDeleteErr)
+			return
+		}
+		for _, peeringConnectionId := range awsPeeringService.DeletedPeeringConnectionIds() {
+			color.Blue("Vpc peering connection %s deleted.", peeringConnectionId)
+		}
 		color.Blue("Peering %s deleted.", awsPeeringId)
+		printAwsRouteChanges(awsPeeringService.RouteChanges())
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(awsPeeringCmd)
 	awsPeeringCmd.AddCommand(awsPeeringCreateCmd)
+	awsPeeringCmd.AddCommand(awsPeeringAcceptCmd)
 	awsPeeringCmd.AddCommand(awsPeeringListCmd)
+	awsPeeringCmd.AddCommand(awsPeeringPropertiesCmd)
 	awsPeeringCmd.AddCommand(awsPeeringDeleteCmd)
+	awsPeeringCmd.AddCommand(awsPeeringDiagnoseCmd)
 
 	awsPeeringListCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
 	_ = awsPeeringListCmd.MarkFlagRequired("cluster-id")
 
+	awsPeeringPropertiesCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	_ = awsPeeringPropertiesCmd.MarkFlagRequired("cluster-id")
+
 	awsPeeringDeleteCmd.Flags().StringVar(&awsPeeringId, "peering-id", "", "id of the peering")
 	_ = awsPeeringDeleteCmd.MarkFlagRequired("peering-id")
+	awsPeeringDeleteCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	awsPeeringDeleteCmd.Flags().Bool("yes", false, "do not ask for confirmation")
+	awsPeeringDeleteCmd.Flags().BoolVar(&awsHazelcastOnly, "hazelcast-only", false, "only delete the peering from the cluster, keeping routes and vpc peering connection in your account")
+	awsPeeringDeleteCmd.Flags().StringVar(&awsRegion, "region", "", "region of your vpc, defaults to the region of your aws profile")
+	awsPeeringDeleteCmd.Flags().StringVar(&awsProfile, "aws-profile", "", "aws profile of the account of your vpc")
+	awsPeeringDeleteCmd.Flags().StringVar(&awsAssumeRoleArn, "assume-role-arn", "", "arn of the role to assume in the account of your vpc")
 
 	awsPeeringCreateCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
 	_ = awsPeeringCreateCmd.MarkFlagRequired("cluster-id")
 
-	awsPeeringCreateCmd.Flags().StringVar(&awsRegion, "region", "", "id of the cluster")
-	_ = awsPeeringCreateCmd.MarkFlagRequired("region")
+	awsPeeringCreateCmd.Flags().StringVar(&awsRegion, "region", "", "region of your vpc, defaults to the region of your aws profile")
 
 	awsPeeringCreateCmd.Flags().StringVar(&awsVpcId, "vpc-id", "", "id of the cluster")
-	_ = awsPeeringCreateCmd.MarkFlagRequired("vpc-id")
 
 	awsPeeringCreateCmd.Flags().StringSliceVar(&awsSubnetIds, "subnet-ids", []string{}, "id of the cluster")
-	_ = awsPeeringCreateCmd.MarkFlagRequired("subnet-ids")
+	addPeeringTargetFlags(awsPeeringCreateCmd, "vpc-id=vpc-1,subnet-ids=subnet-1 subnet-2,region=eu-west-1")
+
+	awsPeeringCreateCmd.Flags().BoolVar(&awsNoRollback, "no-rollback", false, "keep the created resources when peering fails, for debugging")
+	awsPeeringCreateCmd.Flags().BoolVar(&awsReplaceRoutes, "replace-routes", false, "replace existing routes to the cluster cidr in your route tables")
+	awsPeeringCreateCmd.Flags().StringVar(&awsProfile, "aws-profile", "", "aws profile of the account of your vpc")
+	awsPeeringCreateCmd.Flags().StringVar(&awsAssumeRoleArn, "assume-role-arn", "", "arn of the role to assume in the account of your vpc")
+	awsPeeringCreateCmd.Flags().StringSliceVar(&awsIngressSecurityGroupIds, "ingress-security-group-ids", []string{}, "security groups to allow inbound traffic from the cluster on the cluster port")
+	awsPeeringCreateCmd.Flags().StringSliceVar(&awsEgressSecurityGroupIds, "egress-security-group-ids", []string{}, "security groups to allow outbound traffic to the cluster on the cluster port")
+	addPeeringEmitFlag(awsPeeringCreateCmd, service.EmitTerraform, service.EmitCloudFormation)
+
+	awsPeeringAcceptCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	_ = awsPeeringAcceptCmd.MarkFlagRequired("cluster-id")
+	awsPeeringAcceptCmd.Flags().StringVar(&awsVpcId, "vpc-id", "", "id of your vpc")
+	_ = awsPeeringAcceptCmd.MarkFlagRequired("vpc-id")
+	awsPeeringAcceptCmd.Flags().StringSliceVar(&awsSubnetIds, "subnet-ids", []string{}, "ids of the subnets of your vpc routed to the cluster")
+	_ = awsPeeringAcceptCmd.MarkFlagRequired("subnet-ids")
+	awsPeeringAcceptCmd.Flags().StringVar(&awsPeeringConnectionId, "peering-connection-id", "", "id of the vpc peering connection, looked up from the vpcs when empty")
+	awsPeeringAcceptCmd.Flags().StringVar(&awsRegion, "region", "", "region of your vpc, defaults to the region of your aws profile")
+	awsPeeringAcceptCmd.Flags().StringVar(&awsProfile, "aws-profile", "", "aws profile of the account of your vpc")
+	awsPeeringAcceptCmd.Flags().StringVar(&awsAssumeRoleArn, "assume-role-arn", "", "arn of the role to assume in the account of your vpc")
 
+	awsPeeringDiagnoseCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	_ = awsPeeringDiagnoseCmd.MarkFlagRequired("cluster-id")
+	awsPeeringDiagnoseCmd.Flags().StringVar(&awsRegion, "region", "", "region of your vpc, defaults to the region of your aws profile")
+	awsPeeringDiagnoseCmd.Flags().StringVar(&awsProfile, "aws-profile", "", "aws profile of the account of your vpc")
+	awsPeeringDiagnoseCmd.Flags().StringVar(&awsAssumeRoleArn, "assume-role-arn", "", "arn of the role to assume in the account of your vpc")
+	awsPeeringDiagnoseCmd.Flags().StringSliceVar(&awsEgressSecurityGroupIds, "egress-security-group-ids", []string{}, "security groups to check for outbound traffic to the cluster on the cluster port")
 }
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+// addEnterpriseCluster adds a running enterprise cluster to the fake api and returns its id.
+func addEnterpriseCluster(server *fakeapi.Server, cloudProvider string, region string) string {
+	cluster := fakeapi.Cluster{
+		Name:             "mycluster",
+		Port:             31000,
+		HazelcastVersion: "5.0",
+		ProductType:      fakeapi.ProductType{Name: "Enterprise"},
+		State:            fakeapi.StateRunning,
+		CloudProvider:    fakeapi.CloudProvider{Name: cloudProvider, Region: region},
+	}
+	cluster.Networking.CidrBlock = "10.80.0.0/16"
+	return server.AddCluster(cluster)
+}
+
+func TestAwsPeering(t *testing.T) {
+	server := newFakeApi(t)
+	clusterId := addEnterpriseCluster(server, "aws", "eu-west-2")
+	peeringId := server.AddAwsPeering(fakeapi.AwsPeering{ClusterId: clusterId, VpcId: "vpc-1", VpcCidr: "10.0.0.0/16",
+		SubnetId: "subnet-1", SubnetCidr: "10.0.1.0/24"})
+
+	var properties models.AwsPeeringProperties
+	executeJsonCommand(t, &properties, "aws-peering", "properties", "--cluster-id="+clusterId)
+	if properties.VpcCidr != "10.80.0.0/16" || properties.Region != "eu-west-2" {
+		t.Errorf("properties printed %+v", properties)
+	}
+	var peerings []models.AwsPeering
+	executeJsonCommand(t, &peerings, "aws-peering", "list", "--cluster-id="+clusterId)
+	if len(peerings) != 1 || peerings[0].Id != peeringId || peerings[0].SubnetId != "subnet-1" {
+		t.Errorf("list printed %+v", peerings)
+	}
+	executeCommand(t, "aws-peering", "delete", "--cluster-id="+clusterId, "--peering-id="+peeringId, "--hazelcast-only", "--yes")
+	if peerings := server.AwsPeerings(clusterId); len(peerings) != 0 {
+		t.Errorf("deleted peering is still there %+v", peerings)
+	}
+}
+
+func TestAwsPeeringCreateTargetsFailed(t *testing.T) {
+	server := newFakeApi(t)
+	clusterId := addEnterpriseCluster(server, "aws", "eu-west-2")
+	server.Handle("awsPeeringProperties", func(args fakeapi.Args) (interface{}, error) {
+		return nil, errors.New("peering properties are not available")
+	})
+	targetsFile := filepath.Join(t.TempDir(), "targets.json")
+	if err := ioutil.WriteFile(targetsFile, []byte(`[{"vpc-id": "vpc-1", "subnet-ids": ["subnet-1"]},
+		{"vpc-id": "vpc-2", "subnet-ids": ["subnet-2"]}]`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		peeringTargetsFile = ""
+		peeringContinueOnError = false
+	})
+
+	out, exitCode := executeExitingCommand(t, "aws-peering", "create", "--cluster-id="+clusterId, "--targets-file="+targetsFile,
+		"--continue-on-error")
+	if exitCode != 1 {
+		t.Errorf("create exited with %d", exitCode)
+	}
+	if strings.Count(out, "FAILED") != 2 || !strings.Contains(out, "2 of 2 peerings failed.") {
+		t.Errorf("create printed\n%s", out)
+	}
+}
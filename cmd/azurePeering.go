@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"github.com/fatih/color"
 	"github.com/hazelcast/hazelcast-cloud-cli/internal"
 	"github.com/hazelcast/hazelcast-cloud-cli/service"
 	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
 	"github.com/jedib0t/go-pretty/v6/table"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -17,6 +20,15 @@ var azureTenantId string
 var azureSubscriptionId string
 var azureResourceGroupName string
 var azureVnetName string
+var azureEnvironment string
+var azureAuthMethod string
+var azureClientId string
+var azureClientSecret string
+var azureClientCertificatePath string
+var azureClientCertificatePassword string
+var azureResume bool
+var azureHazelcastOnly bool
+var azureRemoveServicePrincipal bool
 
 var azurePeeringCmd = &cobra.Command{
 	Use:     "azure-peering",
@@ -29,16 +41,24 @@ var azurePeeringCreateCmd = &cobra.Command{
 	Short:   "This command creates Azure vNet Peering between your own vNet and your Enterprise Hazelcast cluster vNet.",
 	Example: "hzcloud azure-peering create --cluster-id=1 --tenant-id=foo --subscription-id=bar --resource-group=baz --vnet=qux",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
+		if hasPeeringTargets() {
+			createAzurePeerings(client)
+			return
+		}
+		if azureSubscriptionId == "" || azureResourceGroupName == "" || azureVnetName == "" {
+			color.Red("An error occurred. --subscription-id, --resource-group and --vnet are required unless --target or --targets-file is set.")
+			return
+		}
+		if emitPeeringTemplate(func(format string) (string, error) {
+			azurePeeringService := service.NewAzurePeeringService(client, newAzureCustomerPeeringProperties())
+			return azurePeeringService.Emit(format)
+		}) {
+			return
+		}
 		indicator := util.NewLoadingIndicator("Azure Peering starting...", 100)
 		indicator.Start()
-		azurePeeringService := service.NewAzurePeeringService(client,&service.AzureCustomerPeeringProperties{
-			ClusterId:         enterpriseClusterId,
-			TenantId:          azureTenantId,
-			SubscriptionId:    azureSubscriptionId,
-			ResourceGroupName: azureResourceGroupName,
-			VnetName:          azureVnetName,
-		})
+		azurePeeringService := service.NewAzurePeeringService(client, newAzureCustomerPeeringProperties())
 		peeringCreateErr := azurePeeringService.Create(indicator)
 		indicator.Stop()
 		if peeringCreateErr != nil {
@@ -49,12 +69,55 @@ var azurePeeringCreateCmd = &cobra.Command{
 	},
 }
 
+var azurePeeringAcceptCmd = &cobra.Command{
+	Use:     "accept",
+	Short:   "This command completes the Hazelcast side of an Azure vNet Peering whose vNet peering in your subscription was created from the output of `create --emit`.",
+	Example: "hzcloud azure-peering accept --cluster-id=1 --tenant-id=foo --subscription-id=bar --resource-group=baz --vnet=qux",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newClient()
+		indicator := util.NewLoadingIndicator("Azure Peering accepting...", 100)
+		indicator.Start()
+		azurePeeringService := service.NewAzurePeeringService(client, newAzureCustomerPeeringProperties())
+		peeringAcceptErr := azurePeeringService.Accept(indicator)
+		indicator.Stop()
+		if peeringAcceptErr != nil {
+			color.Red("An error occurred. %s", peeringAcceptErr)
+		} else {
+			color.Green("Peering successfully established.")
+		}
+	},
+}
+
+var azurePeeringPropertiesCmd = &cobra.Command{
+	Use:     "properties",
+	Short:   "This command shows the Azure vNet properties of your Enterprise Hazelcast cluster to create the peering manually.",
+	Example: "hzcloud azure-peering properties --cluster-id=1",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newClient()
+		properties := *internal.Validate(client.AzurePeering.GetProperties(context.Background(), &models.GetAzurePeeringPropertiesInput{
+			ClusterId: enterpriseClusterId,
+		})).(*models.AzurePeeringProperties)
+		if properties.AppRegistrationKey != "" {
+			properties.AppRegistrationKey = "<redacted>"
+		}
+		header := table.Row{"Tenant Id", "App Registration Id", "App Registration Key", "Subscription Id", "Resource Group", "vNet Name"}
+		rows := []table.Row{{properties.TenantId, properties.AppRegistrationId, properties.AppRegistrationKey, properties.SubscriptionId,
+			properties.ResourceGroupName, properties.VnetName}}
+		util.Print(util.PrintRequest{
+			Data:       properties,
+			Header:     header,
+			Rows:       rows,
+			PrintStyle: util.PrintStyle(outputStyle),
+		})
+	},
+}
+
 var azurePeeringListCmd = &cobra.Command{
 	Use:     "list",
 	Short:   "This command lists Azure vNet peerings on your Enterprise Hazelcast cluster.",
 	Example: "hzcloud azure-peering list --cluster-id=1",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		peerings := internal.Validate(client.AzurePeering.List(context.Background(), &models.ListAzurePeeringsInput{
 			ClusterId: enterpriseClusterId,
 		})).(*[]models.AzurePeering)
@@ -74,38 +137,173 @@ var azurePeeringListCmd = &cobra.Command{
 
 var azurePeeringDeleteCmd = &cobra.Command{
 	Use:     "delete",
-	Short:   "This command deletes Azure vNet peering from your Enterprise Hazelcast cluster.",
-	Example: "hzcloud azure-peering delete --peering-id=1",
+	Short:   "This command deletes Azure vNet peering from your Enterprise Hazelcast cluster together with the vNet peering of your vNet.",
+	Example: "hzcloud azure-peering delete --cluster-id=1 --peering-id=1 --tenant-id=foo --subscription-id=bar --resource-group=baz",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
-		_ = internal.Validate(client.AzurePeering.Delete(context.Background(), &models.DeleteAzurePeeringInput{
-			Id: azurePeeringId,
-		})).(*models.Result)
+		client := newClient()
+		yes, _ := cmd.Flags().GetBool("yes")
+		confirmPeeringDeletion(client, azurePeeringId, yes)
+		if azureHazelcastOnly {
+			_ = internal.Validate(client.AzurePeering.Delete(context.Background(), &models.DeleteAzurePeeringInput{
+				Id: azurePeeringId,
+			})).(*models.Result)
+			color.Blue("Peering %s deleted.", azurePeeringId)
+			return
+		}
+		if enterpriseClusterId == "" || azureTenantId == "" || azureSubscriptionId == "" || azureResourceGroupName == "" {
+			color.Red("An error occurred. --cluster-id, --tenant-id, --subscription-id and --resource-group are required unless --hazelcast-only is set.")
+			return
+		}
+		indicator := util.NewLoadingIndicator("Azure Peering deleting...", 100)
+		indicator.Start()
+		azurePeeringService := service.NewAzurePeeringService(client, newAzureCustomerPeeringProperties())
+		peeringDeleteErr := azurePeeringService.Delete(azurePeeringId, indicator)
+		indicator.Stop()
+		if peeringDeleteErr != nil {
+			color.Red("An error occurred. %s", peeringDeleteErr)
+			return
+		}
+		for _, resource := range azurePeeringService.RemovedResources() {
+			color.Blue("%s deleted.", resource)
+		}
+		if servicePrincipalId := azurePeeringService.KeptServicePrincipalId(); servicePrincipalId != "" {
+			color.Yellow("Service principal %s is kept since it has role assignments for other vNets.", servicePrincipalId)
+		}
 		color.Blue("Peering %s deleted.", azurePeeringId)
 	},
 }
 
+var azurePeeringDiagnoseCmd = &cobra.Command{
+	Use:     "diagnose",
+	Short:   "This command checks Azure vNet peerings of your Enterprise Hazelcast cluster against the state of the vNet peerings on both sides.",
+	Example: "hzcloud azure-peering diagnose --cluster-id=1 --tenant-id=foo --subscription-id=bar --resource-group=baz --vnet=qux",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newClient()
+		indicator := util.NewLoadingIndicator("Azure Peering diagnosing...", 100)
+		indicator.Start()
+		azurePeeringService := service.NewAzurePeeringService(client, newAzureCustomerPeeringProperties())
+		checks, diagnoseErr := azurePeeringService.Diagnose(indicator)
+		indicator.Stop()
+		if diagnoseErr != nil {
+			color.Red("An error occurred. %s", diagnoseErr)
+			return
+		}
+		printPeeringChecks(checks)
+	},
+}
+
+func createAzurePeerings(client *hazelcastcloud.Client) {
+	targets, targetsErr := readPeeringTargets([]string{"resource-group", "vnet"}, []string{"subscription-id"})
+	if targetsErr != nil {
+		color.Red("An error occurred. %s", targetsErr)
+		return
+	}
+	labels := make([]string, len(targets))
+	for k, target := range targets {
+		labels[k] = fmt.Sprintf("%s/%s", target.value("resource-group", ""), target.value("vnet", ""))
+	}
+	runPeeringTargets("Azure Peerings starting...", labels, func(index int, indicator *util.LoadingIndicator) error {
+		target := targets[index]
+		customerProperties := newAzureCustomerPeeringProperties()
+		customerProperties.SubscriptionId = target.value("subscription-id", azureSubscriptionId)
+		customerProperties.ResourceGroupName = target.value("resource-group", "")
+		customerProperties.VnetName = target.value("vnet", "")
+		if customerProperties.SubscriptionId == "" {
+			return fmt.Errorf("subscription-id of the target or --subscription-id is required")
+		}
+		azurePeeringService := service.NewAzurePeeringService(client, customerProperties)
+		return azurePeeringService.Create(indicator)
+	})
+}
+
+func newAzureCustomerPeeringProperties() *service.AzureCustomerPeeringProperties {
+	return &service.AzureCustomerPeeringProperties{
+		ClusterId:                 enterpriseClusterId,
+		TenantId:                  azureTenantId,
+		SubscriptionId:            azureSubscriptionId,
+		ResourceGroupName:         azureResourceGroupName,
+		VnetName:                  azureVnetName,
+		Environment:               azureEnvironment,
+		AuthMethod:                azureAuthMethod,
+		ClientId:                  azureClientId,
+		ClientSecret:              azureClientSecret,
+		ClientCertificatePath:     azureClientCertificatePath,
+		ClientCertificatePassword: azureClientCertificatePassword,
+		Resume:                    azureResume,
+		RemoveServicePrincipal:    azureRemoveServicePrincipal,
+	}
+}
+
+func addAzureAuthFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&azureEnvironment, "azure-environment", "AzurePublicCloud", "name of the azure cloud, one of AzurePublicCloud, AzureUSGovernmentCloud, AzureChinaCloud, AzureGermanCloud")
+	cmd.Flags().StringVar(&azureAuthMethod, "auth-method", service.AzureAuthMethodCli, fmt.Sprintf("how your azure credentials are obtained, one of %s", strings.Join(service.AzureAuthMethods, ", ")))
+	cmd.Flags().StringVar(&azureClientId, "client-id", "", "application id of the service principal or the user assigned managed identity")
+	cmd.Flags().StringVar(&azureClientSecret, "client-secret", "", "secret of the service principal, defaults to AZURE_CLIENT_SECRET environment variable")
+	cmd.Flags().StringVar(&azureClientCertificatePath, "client-certificate", "", "path of the pfx certificate of the service principal")
+	cmd.Flags().StringVar(&azureClientCertificatePassword, "client-certificate-password", "", "password of the pfx certificate of the service principal")
+}
+
 func init() {
 	rootCmd.AddCommand(azurePeeringCmd)
 	azurePeeringCmd.AddCommand(azurePeeringCreateCmd)
+	azurePeeringCmd.AddCommand(azurePeeringAcceptCmd)
 	azurePeeringCmd.AddCommand(azurePeeringListCmd)
+	azurePeeringCmd.AddCommand(azurePeeringPropertiesCmd)
 	azurePeeringCmd.AddCommand(azurePeeringDeleteCmd)
+	azurePeeringCmd.AddCommand(azurePeeringDiagnoseCmd)
 
 	azurePeeringListCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
 	_ = azurePeeringListCmd.MarkFlagRequired("cluster-id")
 
+	azurePeeringPropertiesCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	_ = azurePeeringPropertiesCmd.MarkFlagRequired("cluster-id")
+
 	azurePeeringDeleteCmd.Flags().StringVar(&azurePeeringId, "peering-id", "", "id of the peering")
 	_ = azurePeeringDeleteCmd.MarkFlagRequired("peering-id")
+	azurePeeringDeleteCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	azurePeeringDeleteCmd.Flags().Bool("yes", false, "do not ask for confirmation")
+	azurePeeringDeleteCmd.Flags().BoolVar(&azureHazelcastOnly, "hazelcast-only", false, "only delete the peering from the cluster, keeping the vnet peering of your vnet")
+	azurePeeringDeleteCmd.Flags().StringVar(&azureTenantId, "tenant-id", "", "id of the azure tenant")
+	azurePeeringDeleteCmd.Flags().StringVar(&azureResourceGroupName, "resource-group", "", "name of the azure resource group")
+	azurePeeringDeleteCmd.Flags().StringVar(&azureSubscriptionId, "subscription-id", "", "id of the azure subscription")
+	azurePeeringDeleteCmd.Flags().StringVar(&azureVnetName, "vnet", "", "name of the azure vnet, defaults to the vnet of the peering")
+	azurePeeringDeleteCmd.Flags().BoolVar(&azureRemoveServicePrincipal, "remove-service-principal", false, "also remove the role assignment on your vnet and the hazelcast service principal, unless it is used for other vnets")
+	addAzureAuthFlags(azurePeeringDeleteCmd)
 
 	azurePeeringCreateCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
 	_ = azurePeeringCreateCmd.MarkFlagRequired("cluster-id")
 	azurePeeringCreateCmd.Flags().StringVar(&azureTenantId, "tenant-id", "", "id of the azure tenant")
 	_ = azurePeeringCreateCmd.MarkFlagRequired("tenant-id")
 	azurePeeringCreateCmd.Flags().StringVar(&azureResourceGroupName, "resource-group", "", "name of the azure resource group")
-	_ = azurePeeringCreateCmd.MarkFlagRequired("resource-group")
 	azurePeeringCreateCmd.Flags().StringVar(&azureSubscriptionId, "subscription-id", "", "id of the azure subscription")
-	_ = azurePeeringCreateCmd.MarkFlagRequired("subscription-id")
 	azurePeeringCreateCmd.Flags().StringVar(&azureVnetName, "vnet", "", "name of the azure vnet")
-	_ = azurePeeringCreateCmd.MarkFlagRequired("vnet")
+	addAzureAuthFlags(azurePeeringCreateCmd)
+	addPeeringTargetFlags(azurePeeringCreateCmd, "subscription-id=bar,resource-group=baz,vnet=qux")
+	addPeeringEmitFlag(azurePeeringCreateCmd, service.EmitTerraform, service.EmitBicep)
+	azurePeeringCreateCmd.Flags().BoolVar(&azureResume, "resume", false, "reuse the vnet peerings left by a failed attempt instead of deleting them")
+
+	azurePeeringAcceptCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	_ = azurePeeringAcceptCmd.MarkFlagRequired("cluster-id")
+	azurePeeringAcceptCmd.Flags().StringVar(&azureTenantId, "tenant-id", "", "id of the azure tenant")
+	_ = azurePeeringAcceptCmd.MarkFlagRequired("tenant-id")
+	azurePeeringAcceptCmd.Flags().StringVar(&azureResourceGroupName, "resource-group", "", "name of the azure resource group")
+	_ = azurePeeringAcceptCmd.MarkFlagRequired("resource-group")
+	azurePeeringAcceptCmd.Flags().StringVar(&azureSubscriptionId, "subscription-id", "", "id of the azure subscription")
+	_ = azurePeeringAcceptCmd.MarkFlagRequired("subscription-id")
+	azurePeeringAcceptCmd.Flags().StringVar(&azureVnetName, "vnet", "", "name of the azure vnet")
+	_ = azurePeeringAcceptCmd.MarkFlagRequired("vnet")
+	azurePeeringAcceptCmd.Flags().StringVar(&azureEnvironment, "azure-environment", "AzurePublicCloud", "name of the azure cloud, one of AzurePublicCloud, AzureUSGovernmentCloud, AzureChinaCloud, AzureGermanCloud")
+
+	azurePeeringDiagnoseCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	_ = azurePeeringDiagnoseCmd.MarkFlagRequired("cluster-id")
+	azurePeeringDiagnoseCmd.Flags().StringVar(&azureTenantId, "tenant-id", "", "id of the azure tenant")
+	_ = azurePeeringDiagnoseCmd.MarkFlagRequired("tenant-id")
+	azurePeeringDiagnoseCmd.Flags().StringVar(&azureResourceGroupName, "resource-group", "", "name of the azure resource group")
+	_ = azurePeeringDiagnoseCmd.MarkFlagRequired("resource-group")
+	azurePeeringDiagnoseCmd.Flags().StringVar(&azureSubscriptionId, "subscription-id", "", "id of the azure subscription")
+	_ = azurePeeringDiagnoseCmd.MarkFlagRequired("subscription-id")
+	azurePeeringDiagnoseCmd.Flags().StringVar(&azureVnetName, "vnet", "", "name of the azure vnet")
+	_ = azurePeeringDiagnoseCmd.MarkFlagRequired("vnet")
+	addAzureAuthFlags(azurePeeringDiagnoseCmd)
 
 }
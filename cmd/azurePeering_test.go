@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+func TestAzurePeering(t *testing.T) {
+	server := newFakeApi(t)
+	clusterId := addEnterpriseCluster(server, "azure", "westeurope")
+	peeringId := server.AddAzurePeering(fakeapi.AzurePeering{ClusterId: clusterId, VpcId: "my-vnet", VpcCidr: "10.0.0.0/16"})
+
+	var properties models.AzurePeeringProperties
+	executeJsonCommand(t, &properties, "azure-peering", "properties", "--cluster-id="+clusterId)
+	if properties.VnetName != "hazelcast-vnet-"+clusterId {
+		t.Errorf("properties printed %+v", properties)
+	}
+	var peerings []models.AzurePeering
+	executeJsonCommand(t, &peerings, "azure-peering", "list", "--cluster-id="+clusterId)
+	if len(peerings) != 1 || peerings[0].Id != peeringId || peerings[0].VpcId != "my-vnet" {
+		t.Errorf("list printed %+v", peerings)
+	}
+	executeCommand(t, "azure-peering", "delete", "--cluster-id="+clusterId, "--peering-id="+peeringId, "--hazelcast-only", "--yes")
+	if peerings := server.AzurePeerings(clusterId); len(peerings) != 0 {
+		t.Errorf("deleted peering is still there %+v", peerings)
+	}
+}
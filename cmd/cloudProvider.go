@@ -20,7 +20,7 @@ var cloudProviderListCmd = &cobra.Command{
 	Short:   "This command lists a available cloud provider list that Hazelcast Cloud supports.",
 	Example: "hzcloud cloud-provider list",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		cloudProvider := internal.Validate(client.CloudProvider.List(context.Background())).(*[]models.CloudProvider)
 		header := table.Row{"#", "Name", "Available in Starter", "Available in Enterprise"}
 		rows := []table.Row{}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+func TestCloudProviderList(t *testing.T) {
+	newFakeApi(t)
+
+	var cloudProviders []models.CloudProvider
+	executeJsonCommand(t, &cloudProviders, "cloud-provider", "list")
+
+	if len(cloudProviders) != 3 || cloudProviders[0].Name != "aws" || !cloudProviders[0].IsEnabledForStarter {
+		t.Errorf("list printed %+v", cloudProviders)
+	}
+}
+
+func TestRegionList(t *testing.T) {
+	newFakeApi(t)
+
+	var regions []models.Region
+	executeJsonCommand(t, &regions, "region", "list", "--cloud-provider=aws")
+
+	if len(regions) != 2 || regions[1].Name != "eu-west-2" || regions[1].IsEnabledForStarter {
+		t.Errorf("list printed %+v", regions)
+	}
+}
+
+func TestInstanceTypeList(t *testing.T) {
+	newFakeApi(t)
+
+	var instanceTypes []models.InstanceType
+	executeJsonCommand(t, &instanceTypes, "instance-type", "list", "--cloud-provider=gcp")
+
+	if len(instanceTypes) != 1 || instanceTypes[0].Name != "n1-standard-2" || instanceTypes[0].TotalMemory != 7.5 {
+		t.Errorf("list printed %+v", instanceTypes)
+	}
+}
+
+func TestHazelcastVersionList(t *testing.T) {
+	newFakeApi(t)
+
+	var versions []models.EnterpriseHazelcastVersion
+	executeJsonCommand(t, &versions, "hazelcast-version", "list")
+
+	if len(versions) != 2 || versions[0].Version != "4.2" || len(versions[0].UpgradeableVersions) != 1 {
+		t.Errorf("list printed %+v", versions)
+	}
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal"
+	"github.com/hazelcast/hazelcast-cloud-cli/service"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// clusterProduct is a kind of cluster the bulk cluster commands work on. stop is nil for the products whose clusters
+// can not be stopped.
+type clusterProduct struct {
+	name   string
+	get    func(client *hazelcastcloud.Client, clusterId string) (*models.Cluster, *hazelcastcloud.Response, error)
+	list   func(client *hazelcastcloud.Client) (*[]models.Cluster, *hazelcastcloud.Response, error)
+	delete func(client *hazelcastcloud.Client, clusterId string) error
+	stop   func(client *hazelcastcloud.Client, clusterId string) error
+}
+
+var clusterProducts = []clusterProduct{
+	{
+		name: "starter",
+		get: func(client *hazelcastcloud.Client, clusterId string) (*models.Cluster, *hazelcastcloud.Response, error) {
+			return client.StarterCluster.Get(context.Background(), &models.GetStarterClusterInput{ClusterId: clusterId})
+		},
+		list: func(client *hazelcastcloud.Client) (*[]models.Cluster, *hazelcastcloud.Response, error) {
+			return client.StarterCluster.List(context.Background())
+		},
+		delete: func(client *hazelcastcloud.Client, clusterId string) error {
+			_, _, err := client.StarterCluster.Delete(context.Background(), &models.ClusterDeleteInput{ClusterId: clusterId})
+			return err
+		},
+		stop: func(client *hazelcastcloud.Client, clusterId string) error {
+			_, _, err := client.StarterCluster.Stop(context.Background(), &models.ClusterStopInput{ClusterId: clusterId})
+			return err
+		},
+	},
+	{
+		name: "serverless",
+		get: func(client *hazelcastcloud.Client, clusterId string) (*models.Cluster, *hazelcastcloud.Response, error) {
+			return client.ServerlessCluster.Get(context.Background(), &models.GetServerlessClusterInput{ClusterId: clusterId})
+		},
+		list: func(client *hazelcastcloud.Client) (*[]models.Cluster, *hazelcastcloud.Response, error) {
+			return client.ServerlessCluster.List(context.Background())
+		},
+		delete: func(client *hazelcastcloud.Client, clusterId string) error {
+			_, _, err := client.ServerlessCluster.Delete(context.Background(), &models.ClusterDeleteInput{ClusterId: clusterId})
+			return err
+		},
+		stop: func(client *hazelcastcloud.Client, clusterId string) error {
+			_, _, err := client.ServerlessCluster.Stop(context.Background(), &models.ClusterStopInput{ClusterId: clusterId})
+			return err
+		},
+	},
+	{
+		name: "enterprise",
+		get: func(client *hazelcastcloud.Client, clusterId string) (*models.Cluster, *hazelcastcloud.Response, error) {
+			return client.EnterpriseCluster.Get(context.Background(), &models.GetEnterpriseClusterInput{ClusterId: clusterId})
+		},
+		list: func(client *hazelcastcloud.Client) (*[]models.Cluster, *hazelcastcloud.Response, error) {
+			return client.EnterpriseCluster.List(context.Background())
+		},
+		delete: func(client *hazelcastcloud.Client, clusterId string) error {
+			_, _, err := client.EnterpriseCluster.Delete(context.Background(), &models.ClusterDeleteInput{ClusterId: clusterId})
+			return err
+		},
+	},
+}
+
+type clusterBulkOptions struct {
+	selector       string
+	all            bool
+	products       []string
+	yes            bool
+	forceUnprotect bool
+	parallelism    int
+}
+
+type clusterBulkTarget struct {
+	product string
+	cluster models.Cluster
+}
+
+type clusterBulkResult struct {
+	Product string `json:"product"`
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	Result  string `json:"result"`
+	Error   string `json:"error,omitempty"`
+}
+
+// clusterBulkAction is what a bulk cluster command does to each selected cluster. skipStates are the states of the
+// clusters the action is already done or under way on, or can not be run on, they are not selected. Protected
+// clusters are not selected for deletes.
+type clusterBulkAction struct {
+	verb       string
+	doneVerb   string
+	progress   string
+	skipStates []models.State
+	deletes    bool
+	run        func(product clusterProduct) func(client *hazelcastcloud.Client, clusterId string) error
+}
+
+func (a clusterBulkAction) skips(state models.State) bool {
+	for _, skipState := range a.skipStates {
+		if state == skipState {
+			return true
+		}
+	}
+	return false
+}
+
+func newClusterCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "cluster",
+		Aliases: []string{"clusters"},
+		Short:   "This command allows you to delete or stop many clusters of all products at once, selected by their properties.",
+	}
+}
+
+func newClusterDeleteCmd() *cobra.Command {
+	var options clusterBulkOptions
+
+	clusterDeleteCmd := cobra.Command{
+		Use:     "delete",
+		Short:   "This command deletes the clusters matching the selector.",
+		Example: "hzcloud cluster delete --selector='name=ci-*,state=RUNNING' --product=starter,serverless",
+		Run: func(cmd *cobra.Command, args []string) {
+			runClusterBulkAction(clusterBulkAction{
+				verb:       "delete",
+				doneVerb:   "DELETED",
+				progress:   "Deleting",
+				skipStates: []models.State{models.Deleting},
+				deletes:    true,
+				run: func(product clusterProduct) func(client *hazelcastcloud.Client, clusterId string) error {
+					return product.delete
+				},
+			}, &options)
+		},
+	}
+
+	addClusterBulkFlags(&clusterDeleteCmd, &options)
+	clusterDeleteCmd.Flags().BoolVar(&options.forceUnprotect, "force-unprotect", false, "also delete the matching clusters which are protected")
+	return &clusterDeleteCmd
+}
+
+func newClusterStopCmd() *cobra.Command {
+	var options clusterBulkOptions
+
+	clusterStopCmd := cobra.Command{
+		Use:     "stop",
+		Short:   "This command stops the clusters matching the selector, enterprise clusters can not be stopped.",
+		Example: "hzcloud cluster stop --all --product=serverless",
+		Run: func(cmd *cobra.Command, args []string) {
+			runClusterBulkAction(clusterBulkAction{
+				verb:       "stop",
+				doneVerb:   "STOPPED",
+				progress:   "Stopping",
+				skipStates: []models.State{models.Stopped, models.State("STOPPING"), models.Deleting, models.Pending},
+				run: func(product clusterProduct) func(client *hazelcastcloud.Client, clusterId string) error {
+					return product.stop
+				},
+			}, &options)
+		},
+	}
+
+	addClusterBulkFlags(&clusterStopCmd, &options)
+	return &clusterStopCmd
+}
+
+func addClusterBulkFlags(cmd *cobra.Command, options *clusterBulkOptions) {
+	cmd.Flags().StringVar(&options.selector, "selector", "", fmt.Sprintf("comma separated key=pattern terms the clusters have to match, keys are %s",
+		strings.Join(service.ClusterSelectorKeys, ", ")))
+	cmd.Flags().BoolVar(&options.all, "all", false, "select every cluster of the products")
+	cmd.Flags().StringSliceVar(&options.products, "product", []string{}, "products of the clusters: starter, serverless or enterprise, defaults to all of them")
+	cmd.Flags().BoolVar(&options.yes, "yes", false, "do not ask for confirmation")
+	cmd.Flags().IntVar(&options.parallelism, "parallelism", 4, "maximum number of clusters changed at the same time")
+}
+
+// runClusterBulkAction lists the clusters of the products matching the selector, previews them in the default output
+// style, asks for confirmation and runs the action on them concurrently. Clusters failing do not stop the others.
+func runClusterBulkAction(action clusterBulkAction, options *clusterBulkOptions) {
+	if options.all == (options.selector != "") {
+		color.Red("An error occurred. One of --selector or --all is required.")
+		exit(1)
+	}
+	var selector service.ClusterSelector
+	if !options.all {
+		var selectorErr error
+		selector, selectorErr = service.ParseClusterSelector(options.selector)
+		if selectorErr != nil {
+			color.Red("An error occurred. %s", selectorErr)
+			exit(1)
+		}
+	}
+	products, productsErr := selectClusterProducts(options.products, action)
+	if productsErr != nil {
+		color.Red("An error occurred. %s", productsErr)
+		exit(1)
+	}
+
+	client := newClient()
+	protectionService := internal.NewProtectionService()
+	var targets []clusterBulkTarget
+	runs := map[string]func(client *hazelcastcloud.Client, clusterId string) error{}
+	for _, product := range products {
+		clusters, _, listErr := product.list(client)
+		if listErr != nil {
+			color.Red("An error occurred. %s", listErr)
+			exit(1)
+		}
+		for _, cluster := range *clusters {
+			if action.skips(cluster.State) || (selector != nil && !selector.Matches(cluster)) {
+				continue
+			}
+			if action.deletes && !options.forceUnprotect && protectionService.IsProtected(cluster.Id) {
+				color.Yellow("Cluster %s is protected, use --force-unprotect to delete it.", cluster.Id)
+				continue
+			}
+			targets = append(targets, clusterBulkTarget{product: product.name, cluster: cluster})
+		}
+		runs[product.name] = action.run(product)
+	}
+	if len(targets) == 0 {
+		color.Yellow("No cluster matches, nothing to %s.", action.verb)
+		return
+	}
+	// the preview is left out of the other styles, so they print a single document
+	if util.PrintStyle(outputStyle) == util.PrintStyleDefault {
+		printClusterBulkResults(targets, nil, "")
+	}
+	if confirmErr := confirm(options.yes, fmt.Sprintf("%d clusters will be %s.", len(targets), strings.ToLower(action.doneVerb)),
+		func() (string, error) { return strconv.Itoa(len(targets)), nil }); confirmErr != nil {
+		color.Red("An error occurred. %s", confirmErr)
+		exit(1)
+	}
+
+	indicator := util.NewLoadingIndicator(action.progress, len(targets))
+	indicator.Start()
+	var mutex sync.Mutex
+	doneCount := 0
+	errs := util.RunConcurrently(len(targets), options.parallelism, true, func(index int) error {
+		err := runs[targets[index].product](client, targets[index].cluster.Id)
+		mutex.Lock()
+		doneCount++
+		indicator.SetStep(fmt.Sprintf("%d of %d clusters done...", doneCount, len(targets)), doneCount)
+		mutex.Unlock()
+		return err
+	})
+	indicator.Stop()
+	if action.deletes {
+		for k, target := range targets {
+			if errs[k] == nil {
+				unprotectDeletedCluster(target.cluster.Id)
+			}
+		}
+	}
+	failedCount := printClusterBulkResults(targets, errs, action.doneVerb)
+	if failedCount != 0 {
+		color.Red("Failed to %s %d of %d clusters.", action.verb, failedCount, len(targets))
+		exit(1)
+	}
+	color.Green("All %d clusters are %s.", len(targets), strings.ToLower(action.doneVerb))
+}
+
+func selectClusterProducts(names []string, action clusterBulkAction) ([]clusterProduct, error) {
+	for _, name := range names {
+		if !containsString([]string{"starter", "serverless", "enterprise"}, name) {
+			return nil, fmt.Errorf("product %s is not one of starter, serverless or enterprise", name)
+		}
+	}
+	var products []clusterProduct
+	for _, product := range clusterProducts {
+		if len(names) != 0 && !containsString(names, product.name) {
+			continue
+		}
+		if action.run(product) == nil {
+			if len(names) != 0 {
+				return nil, fmt.Errorf("%s clusters can not %s", product.name, action.verb)
+			}
+			continue
+		}
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+// printClusterBulkResults prints the targets with the results of the action, or only the targets when errs is nil.
+// It returns the number of failed targets.
+func printClusterBulkResults(targets []clusterBulkTarget, errs []error, doneVerb string) int {
+	header := table.Row{"Product", "Id", "Name", "State"}
+	if errs != nil {
+		header = append(header, "Result", "Error")
+	}
+	rows := []table.Row{}
+	results := []clusterBulkResult{}
+	failedCount := 0
+	for k, target := range targets {
+		result := clusterBulkResult{Product: target.product, Id: target.cluster.Id, Name: target.cluster.Name,
+			State: string(target.cluster.State)}
+		row := table.Row{result.Product, result.Id, result.Name, result.State}
+		if errs != nil {
+			result.Result = doneVerb
+			if errs[k] != nil {
+				result.Result = "FAILED"
+				result.Error = errs[k].Error()
+				failedCount++
+			}
+			row = append(row, result.Result, result.Error)
+		}
+		results = append(results, result)
+		rows = append(rows, row)
+	}
+	util.Print(util.PrintRequest{
+		Header:     header,
+		Rows:       rows,
+		Data:       results,
+		PrintStyle: util.PrintStyle(outputStyle),
+	})
+	return failedCount
+}
+
+func init() {
+	clusterCmd := newClusterCmd()
+	rootCmd.AddCommand(clusterCmd)
+	clusterCmd.AddCommand(newClusterDeleteCmd())
+	clusterCmd.AddCommand(newClusterStopCmd())
+}
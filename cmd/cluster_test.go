@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+)
+
+// addBulkTestClusters adds a cluster of the product and state for every name to the fake api and returns their ids by
+// name.
+func addBulkTestClusters(server *fakeapi.Server, clusters map[string][2]string) map[string]string {
+	ids := map[string]string{}
+	for name, productAndState := range clusters {
+		ids[name] = server.AddCluster(fakeapi.Cluster{
+			Name:          name,
+			ProductType:   fakeapi.ProductType{Name: productAndState[0]},
+			State:         productAndState[1],
+			CloudProvider: fakeapi.CloudProvider{Name: "aws", Region: "eu-west-2"},
+		})
+	}
+	return ids
+}
+
+// decodeBulkResults decodes the first json document of the output after the loading indicator, which has to be the
+// only one.
+func decodeBulkResults(t *testing.T, out string) []clusterBulkResult {
+	t.Helper()
+	var results []clusterBulkResult
+	if index := strings.LastIndex(out, util.ClearLine); index != -1 {
+		out = out[index+len(util.ClearLine):]
+	}
+	decoder := json.NewDecoder(strings.NewReader(out))
+	if err := decoder.Decode(&results); err != nil {
+		t.Fatalf("no json printed. %s\n%s", err, out)
+	}
+	var next interface{}
+	if decoder.Decode(&next) == nil {
+		t.Errorf("another json document is printed after %+v: %v", results, next)
+	}
+	return results
+}
+
+func clusterState(server *fakeapi.Server, clusterId string) string {
+	cluster, ok := server.Cluster(clusterId)
+	if !ok {
+		return "NOT FOUND"
+	}
+	return cluster.State
+}
+
+func TestClusterStop(t *testing.T) {
+	server := newFakeApi(t)
+	ids := addBulkTestClusters(server, map[string][2]string{
+		"ci-starter":            {"Starter", fakeapi.StateRunning},
+		"ci-serverless":         {"Serverless", fakeapi.StateRunning},
+		"ci-serverless-stopped": {"Serverless", fakeapi.StateStopped},
+		"ci-enterprise":         {"Enterprise", fakeapi.StateRunning},
+		"prod-starter":          {"Starter", fakeapi.StateRunning},
+	})
+
+	out := executeCommand(t, "cluster", "stop", "--selector=name=ci-*", "--product=serverless", "--yes", "--output=json")
+	results := decodeBulkResults(t, out)
+	if len(results) != 1 || results[0].Name != "ci-serverless" || results[0].Product != "serverless" || results[0].Result != "STOPPED" {
+		t.Errorf("stop of the serverless clusters printed %+v", results)
+	}
+	if state := clusterState(server, ids["ci-starter"]); state != fakeapi.StateRunning {
+		t.Errorf("starter cluster is %s", state)
+	}
+
+	out = executeCommand(t, "cluster", "stop", "--selector=name=ci-*", "--yes")
+	if !strings.Contains(out, "ci-starter") || strings.Contains(out, "ci-serverless") ||
+		!strings.Contains(out, "STOPPED") || !strings.Contains(out, "All 1 clusters are stopped.") {
+		t.Errorf("stop of the clusters printed\n%s", out)
+	}
+	for name, state := range map[string]string{"ci-starter": fakeapi.StateStopped, "ci-enterprise": fakeapi.StateRunning,
+		"prod-starter": fakeapi.StateRunning} {
+		if clusterState(server, ids[name]) != state {
+			t.Errorf("%s is %s, expected %s", name, clusterState(server, ids[name]), state)
+		}
+	}
+
+	out, exitCode := executeExitingCommand(t, "cluster", "stop", "--all", "--product=enterprise", "--yes")
+	if exitCode != 1 || !strings.Contains(out, "enterprise clusters can not stop") {
+		t.Errorf("stop of the enterprise clusters exited with %d and printed\n%s", exitCode, out)
+	}
+
+	out, exitCode = executeExitingCommand(t, "cluster", "stop", "--all")
+	if exitCode != 1 || !strings.Contains(out, "confirmation is needed, use --yes to run without a terminal") {
+		t.Errorf("stop without --yes exited with %d and printed\n%s", exitCode, out)
+	}
+	if state := clusterState(server, ids["prod-starter"]); state != fakeapi.StateRunning {
+		t.Errorf("stop without confirmation stopped prod-starter, it is %s", state)
+	}
+}
+
+func TestClusterDelete(t *testing.T) {
+	server := newFakeApi(t)
+	ids := addBulkTestClusters(server, map[string][2]string{
+		"ci-starter":          {"Starter", fakeapi.StateRunning},
+		"ci-serverless":       {"Serverless", fakeapi.StateStopped},
+		"ci-enterprise":       {"Enterprise", fakeapi.StateRunning},
+		"ci-enterprise-going": {"Enterprise", fakeapi.StateDeleting},
+		"prod-starter":        {"Starter", fakeapi.StateRunning},
+	})
+
+	out := executeCommand(t, "cluster", "delete", "--selector=name=ci-*", "--yes", "--output=json")
+	results := decodeBulkResults(t, out)
+	var deleted []string
+	for _, result := range results {
+		if result.Result != "DELETED" {
+			t.Errorf("result of %s is %s", result.Name, result.Result)
+		}
+		deleted = append(deleted, result.Product+" "+result.Name)
+	}
+	if strings.Join(deleted, ",") != "starter ci-starter,serverless ci-serverless,enterprise ci-enterprise" {
+		t.Errorf("deleted clusters are %v", deleted)
+	}
+	for name, state := range map[string]string{"ci-starter": "NOT FOUND", "ci-serverless": "NOT FOUND",
+		"ci-enterprise": "NOT FOUND", "ci-enterprise-going": fakeapi.StateDeleting, "prod-starter": fakeapi.StateRunning} {
+		if clusterState(server, ids[name]) != state {
+			t.Errorf("%s is %s, expected %s", name, clusterState(server, ids[name]), state)
+		}
+	}
+
+	out, exitCode := executeExitingCommand(t, "cluster", "delete", "--selector=colour=red", "--yes")
+	if exitCode != 1 || !strings.Contains(out, "selector key colour is not one of") {
+		t.Errorf("delete with an unknown selector key exited with %d and printed\n%s", exitCode, out)
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/fatih/color"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+	"golang.org/x/term"
+	"os"
+	"strings"
+)
+
+// confirm asks the user to type the expected text to go on with a destructive operation. It is skipped with --yes
+// and refused when stdin is not a terminal, so scripts have to pass --yes. The expected text is only looked up when
+// the user is asked.
+func confirm(yes bool, prompt string, getExpected func() (string, error)) error {
+	if yes {
+		return nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("confirmation is needed, use --yes to run without a terminal")
+	}
+	expected, expectedErr := getExpected()
+	if expectedErr != nil {
+		return expectedErr
+	}
+	fmt.Printf("%s Type %s to confirm: ", prompt, expected)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != expected {
+		return fmt.Errorf("confirmation did not match %s, nothing is changed", expected)
+	}
+	return nil
+}
+
+// confirmClusterOperation asks the user to type the name of the cluster the destructive operation is run on, exiting
+// when it does not match.
+func confirmClusterOperation(yes bool, operation string, getClusterName func() (string, error)) {
+	if confirmErr := confirm(yes, operation, getClusterName); confirmErr != nil {
+		color.Red("An error occurred. %s", confirmErr)
+		exit(1)
+	}
+}
+
+// clusterName returns the name of the cluster got by get, for confirmClusterOperation.
+func clusterName(get func() (*models.Cluster, *hazelcastcloud.Response, error)) func() (string, error) {
+	return func() (string, error) {
+		cluster, _, err := get()
+		if err != nil {
+			return "", err
+		}
+		return cluster.Name, nil
+	}
+}
+
+// confirmPeeringDeletion asks the user to type the name of the cluster of the peering, or the id of the peering when
+// --cluster-id is not given, unless yes is set.
+func confirmPeeringDeletion(client *hazelcastcloud.Client, peeringId string, yes bool) {
+	getExpected := func() (string, error) {
+		return peeringId, nil
+	}
+	if enterpriseClusterId != "" {
+		getExpected = clusterName(func() (*models.Cluster, *hazelcastcloud.Response, error) {
+			return client.EnterpriseCluster.Get(context.Background(), &models.GetEnterpriseClusterInput{
+				ClusterId: enterpriseClusterId,
+			})
+		})
+	}
+	if confirmErr := confirm(yes, fmt.Sprintf("Peering %s will be deleted.", peeringId), getExpected); confirmErr != nil {
+		color.Red("An error occurred. %s", confirmErr)
+		exit(1)
+	}
+}
@@ -0,0 +1,517 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/hazelcast/hazelcast-cloud-cli/service"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type customClassesUploadOptions struct {
+	fileNames   []string
+	dirs        []string
+	fromMaven   string
+	fromGradle  string
+	wait        bool
+	waitTimeout time.Duration
+	inspect     bool
+}
+
+type customClassesInspectOptions struct {
+	fileNames        []string
+	dirs             []string
+	hazelcastVersion string
+	classes          bool
+}
+
+type customClassesUploadResult struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	Transitions []string `json:"transitions,omitempty"`
+	unchanged   bool
+}
+
+type customClassesSyncResult struct {
+	Action     string `json:"action"`
+	Name       string `json:"name"`
+	ArtifactId string `json:"artifactId"`
+	Result     string `json:"result"`
+}
+
+func addCustomClassesUploadFlags(cmd *cobra.Command, options *customClassesUploadOptions) {
+	cmd.Flags().StringSliceVar(&options.fileNames, "file-name", []string{}, "files to upload, can be repeated")
+	cmd.Flags().StringSliceVar(&options.dirs, "dir", []string{}, "directories to upload the jars of, their class files are packaged into a jar named after the directory")
+	cmd.Flags().StringVar(&options.fromMaven, "from-maven", "", "upload the jars built by maven into target of the project directory")
+	cmd.Flags().Lookup("from-maven").NoOptDefVal = "."
+	cmd.Flags().StringVar(&options.fromGradle, "from-gradle", "", "upload the jars built by gradle into build/libs of the project directory")
+	cmd.Flags().Lookup("from-gradle").NoOptDefVal = "."
+	cmd.Flags().BoolVar(&options.wait, "wait", false, "wait until the uploaded artifacts are processed, exits with an error when one fails")
+	cmd.Flags().DurationVar(&options.waitTimeout, "wait-timeout", 10*time.Minute, "maximum time to wait with --wait")
+	cmd.Flags().BoolVar(&options.inspect, "inspect", false, "inspect the files before uploading, exits with an error when the cluster can not load one")
+}
+
+// uploadCustomClasses uploads the files of the options, skipping the ones whose content is already uploaded with the
+// same name. The Hazelcast version of the cluster is only needed to inspect the files.
+func uploadCustomClasses(artifacts service.ArtifactClient, clusterId string, getHazelcastVersion func() (string, error),
+	options *customClassesUploadOptions) {
+	fileNames := options.fileNames
+	for _, build := range [][]string{{options.fromMaven, "target"}, {options.fromGradle, "build/libs"}} {
+		if build[0] == "" {
+			continue
+		}
+		buildArtifacts, buildArtifactsErr := service.FindBuildArtifacts(build[0], build[1])
+		if buildArtifactsErr != nil {
+			color.Red("An error occurred. %s", buildArtifactsErr)
+			exit(1)
+		}
+		fileNames = append(fileNames, buildArtifacts...)
+	}
+	if len(fileNames) == 0 && len(options.dirs) == 0 {
+		color.Red("An error occurred. One of --file-name, --dir, --from-maven or --from-gradle is required.")
+		exit(1)
+	}
+	files, filesErr := service.CollectCustomClassesFiles(fileNames, options.dirs)
+	if filesErr != nil {
+		color.Red("An error occurred. %s", filesErr)
+		exit(1)
+	}
+	if options.inspect {
+		hazelcastVersion, hazelcastVersionErr := getHazelcastVersion()
+		if hazelcastVersionErr != nil {
+			color.Red("An error occurred. %s", hazelcastVersionErr)
+			exit(1)
+		}
+		inspections := inspectCustomClasses(files, hazelcastVersion)
+		if printCustomClassesProblems(inspections) {
+			color.Red("Nothing is uploaded, the cluster can not load the classes of the files.")
+			exit(1)
+		}
+	}
+
+	customClassesService := service.NewCustomClassesService(artifacts, clusterId)
+	uploadedArtifacts, uploadedArtifactsErr := customClassesService.List()
+	if uploadedArtifactsErr != nil {
+		color.Red("An error occurred. %s", uploadedArtifactsErr)
+		exit(1)
+	}
+	var results []*customClassesUploadResult
+	for _, file := range files {
+		unchangedArtifact, unchangedErr := customClassesService.FindUnchanged(file, uploadedArtifacts)
+		if unchangedErr != nil {
+			printCustomClassesUploads(results, false)
+			color.Red("An error occurred. %s", unchangedErr)
+			exit(1)
+		}
+		if unchangedArtifact != nil {
+			results = append(results, &customClassesUploadResult{Id: unchangedArtifact.Id, Name: unchangedArtifact.Name,
+				Status: "UNCHANGED", unchanged: true})
+			continue
+		}
+		content, openErr := file.Open()
+		if openErr != nil {
+			printCustomClassesUploads(results, false)
+			color.Red("An error occurred. %s", openErr)
+			exit(1)
+		}
+		reader := progressbar.NewReader(content, progressbar.DefaultBytes(file.Size, "uploading "+file.Name))
+		artifact, uploadErr := customClassesService.Upload(file, &reader)
+		content.Close()
+		if uploadErr != nil {
+			printCustomClassesUploads(results, false)
+			color.Red("An error occurred. %s", uploadErr)
+			exit(1)
+		}
+		results = append(results, &customClassesUploadResult{Id: artifact.Id, Name: artifact.Name, Status: artifact.Status})
+	}
+	if !options.wait {
+		printCustomClassesUploads(results, false)
+		return
+	}
+
+	waitErr := waitForCustomClasses(customClassesService, results, options.waitTimeout)
+	printCustomClassesUploads(results, true)
+	if waitErr != nil {
+		color.Red("An error occurred. %s", waitErr)
+		exit(1)
+	}
+	failedCount := 0
+	for _, result := range results {
+		if result.Status == service.ArtifactStatusFailed {
+			failedCount++
+		}
+	}
+	if failedCount != 0 {
+		color.Red("%d of %d artifacts failed.", failedCount, len(results))
+		exit(1)
+	}
+	color.Green("All %d artifacts are ready.", len(results))
+}
+
+// waitForCustomClasses waits for the uploaded artifacts of the results, recording the statuses they go through.
+func waitForCustomClasses(customClassesService service.CustomClassesService, results []*customClassesUploadResult, timeout time.Duration) error {
+	resultsById := map[string]*customClassesUploadResult{}
+	var artifactIds []string
+	for _, result := range results {
+		if !result.unchanged {
+			resultsById[result.Id] = result
+			artifactIds = append(artifactIds, result.Id)
+		}
+	}
+	if len(artifactIds) == 0 {
+		return nil
+	}
+	indicator := util.NewLoadingIndicator("Artifacts processing...", len(artifactIds))
+	indicator.Start()
+	defer indicator.Stop()
+	doneCount := 0
+	_, waitErr := customClassesService.WaitForArtifacts(artifactIds, timeout, func(artifact models.UploadedArtifact) {
+		result := resultsById[artifact.Id]
+		if len(result.Transitions) == 0 && result.Status != artifact.Status {
+			result.Transitions = append(result.Transitions, result.Status)
+		}
+		result.Transitions = append(result.Transitions, artifact.Status)
+		result.Status = artifact.Status
+		if service.IsArtifactStatusTerminal(artifact.Status) {
+			doneCount++
+		}
+		indicator.SetStep(fmt.Sprintf("%s is %s, %d of %d artifacts processed...", artifact.Name, artifact.Status, doneCount,
+			len(artifactIds)), doneCount)
+	})
+	return waitErr
+}
+
+func printCustomClassesUploads(results []*customClassesUploadResult, withTransitions bool) {
+	if len(results) == 0 {
+		return
+	}
+	header := table.Row{"Id", "File Name", "Status"}
+	if withTransitions {
+		header = append(header, "Transitions")
+	}
+	rows := []table.Row{}
+	for _, result := range results {
+		row := table.Row{result.Id, result.Name, result.Status}
+		if withTransitions {
+			row = append(row, strings.Join(result.Transitions, " -> "))
+		}
+		rows = append(rows, row)
+	}
+	util.Print(util.PrintRequest{
+		Header:     header,
+		Rows:       rows,
+		Data:       results,
+		PrintStyle: util.PrintStyle(outputStyle),
+	})
+}
+
+// syncCustomClasses makes the uploaded artifacts of the cluster match the files of the directories. Deleting and
+// replacing artifacts is confirmed with the name of the cluster unless yes or dryRun is set.
+func syncCustomClasses(artifacts service.ArtifactClient, clusterId string, dirs []string, prune bool, dryRun bool, yes bool,
+	getClusterName func() (string, error)) {
+	files, filesErr := service.CollectCustomClassesFiles(nil, dirs)
+	if filesErr != nil {
+		color.Red("An error occurred. %s", filesErr)
+		exit(1)
+	}
+	customClassesService := service.NewCustomClassesService(artifacts, clusterId)
+	uploadedArtifacts, uploadedArtifactsErr := customClassesService.List()
+	if uploadedArtifactsErr != nil {
+		color.Red("An error occurred. %s", uploadedArtifactsErr)
+		exit(1)
+	}
+	actions, planErr := customClassesService.PlanSync(files, uploadedArtifacts, prune)
+	if planErr != nil {
+		color.Red("An error occurred. %s", planErr)
+		exit(1)
+	}
+	deletedCount := 0
+	for _, action := range actions {
+		if action.Action == service.SyncActionReplace || action.Action == service.SyncActionDelete {
+			deletedCount += len(action.ArtifactIds)
+		}
+	}
+	if deletedCount != 0 && !dryRun {
+		confirmClusterOperation(yes, fmt.Sprintf("%d artifacts will be deleted or replaced.", deletedCount), getClusterName)
+	}
+	var results []customClassesSyncResult
+	for k, action := range actions {
+		if dryRun {
+			results = append(results, customClassesSyncResult{Action: action.Action, Name: action.Name,
+				ArtifactId: strings.Join(action.ArtifactIds, ", "), Result: "DRY RUN"})
+			continue
+		}
+		artifactId, syncErr := applyCustomClassesSyncAction(customClassesService, action)
+		if syncErr != nil {
+			results = append(results, customClassesSyncResult{Action: action.Action, Name: action.Name, ArtifactId: artifactId, Result: "FAILED"})
+			printCustomClassesSync(results)
+			color.Red("An error occurred. %s", syncErr)
+			if k+1 < len(actions) {
+				color.Red("%d remaining actions skipped.", len(actions)-k-1)
+			}
+			exit(1)
+		}
+		results = append(results, customClassesSyncResult{Action: action.Action, Name: action.Name, ArtifactId: artifactId, Result: "DONE"})
+	}
+	printCustomClassesSync(results)
+	if dryRun {
+		color.Blue("Dry run, nothing was changed.")
+	}
+}
+
+// applyCustomClassesSyncAction uploads the file of an UPLOAD or REPLACE action before deleting the artifacts it
+// replaces, so the cluster always has the classes. It returns the id of the uploaded or kept artifact.
+func applyCustomClassesSyncAction(customClassesService service.CustomClassesService, action service.CustomClassesSyncAction) (string, error) {
+	artifactId := strings.Join(action.ArtifactIds, ", ")
+	if action.File != nil {
+		content, openErr := action.File.Open()
+		if openErr != nil {
+			return artifactId, openErr
+		}
+		defer content.Close()
+		reader := progressbar.NewReader(content, progressbar.DefaultBytes(action.File.Size, "uploading "+action.File.Name))
+		artifact, uploadErr := customClassesService.Upload(*action.File, &reader)
+		if uploadErr != nil {
+			return artifactId, uploadErr
+		}
+		artifactId = artifact.Id
+	}
+	if action.Action == service.SyncActionReplace || action.Action == service.SyncActionDelete {
+		for _, deletedArtifactId := range action.ArtifactIds {
+			if deleteErr := customClassesService.Delete(deletedArtifactId); deleteErr != nil {
+				return artifactId, deleteErr
+			}
+		}
+	}
+	return artifactId, nil
+}
+
+func printCustomClassesSync(results []customClassesSyncResult) {
+	header := table.Row{"Action", "File Name", "Artifact Id", "Result"}
+	rows := []table.Row{}
+	for _, result := range results {
+		rows = append(rows, table.Row{result.Action, result.Name, result.ArtifactId, result.Result})
+	}
+	util.Print(util.PrintRequest{
+		Header:     header,
+		Rows:       rows,
+		Data:       results,
+		PrintStyle: util.PrintStyle(outputStyle),
+	})
+}
+
+type customClassesDownloadOptions struct {
+	artifactId string
+	output     string
+	outputDir  string
+	force      bool
+	sha256     string
+	all        bool
+}
+
+type customClassesDownloadResult struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Sha256 string `json:"sha256"`
+}
+
+func addCustomClassesDownloadFlags(cmd *cobra.Command, options *customClassesDownloadOptions) {
+	cmd.Flags().StringVar(&options.artifactId, "file-id", "", "id of the Uploaded Artifact")
+	cmd.Flags().StringVar(&options.output, "output", "", "path of the downloaded file, defaults to the artifact name in --output-dir")
+	cmd.Flags().StringVar(&options.outputDir, "output-dir", ".", "directory of the downloaded files")
+	cmd.Flags().BoolVar(&options.force, "force", false, "overwrite existing files")
+	cmd.Flags().StringVar(&options.sha256, "sha256", "", "expected SHA-256 of the downloaded file")
+	cmd.Flags().BoolVar(&options.all, "all", false, "download every artifact of the cluster")
+}
+
+// downloadCustomClasses downloads one or all artifacts of the cluster, resuming interrupted downloads.
+func downloadCustomClasses(artifacts service.ArtifactClient, clusterId string, options *customClassesDownloadOptions) {
+	if options.all == (options.artifactId != "") {
+		color.Red("An error occurred. One of --file-id or --all is required.")
+		exit(1)
+	}
+	if options.all && (options.output != "" || options.sha256 != "") {
+		color.Red("An error occurred. --output and --sha256 can not be used with --all.")
+		exit(1)
+	}
+	customClassesService := service.NewCustomClassesService(artifacts, clusterId)
+	artifactIds := []string{options.artifactId}
+	if options.all {
+		uploadedArtifacts, uploadedArtifactsErr := customClassesService.List()
+		if uploadedArtifactsErr != nil {
+			color.Red("An error occurred. %s", uploadedArtifactsErr)
+			exit(1)
+		}
+		artifactIds = nil
+		for _, uploadedArtifact := range uploadedArtifacts {
+			artifactIds = append(artifactIds, uploadedArtifact.Id)
+		}
+	}
+
+	var links []*models.UploadedArtifactLink
+	nameCounts := map[string]int{}
+	for _, artifactId := range artifactIds {
+		link, linkErr := customClassesService.Link(artifactId)
+		if linkErr != nil {
+			color.Red("An error occurred. %s", linkErr)
+			exit(1)
+		}
+		links = append(links, link)
+		nameCounts[link.Name]++
+	}
+	paths := make([]string, len(links))
+	for k, link := range links {
+		paths[k] = options.output
+		if paths[k] == "" {
+			name := link.Name
+			if nameCounts[name] > 1 {
+				name = link.Id + "-" + name
+			}
+			paths[k] = filepath.Join(options.outputDir, name)
+		}
+		if _, statErr := os.Stat(paths[k]); statErr == nil && !options.force {
+			color.Red("An error occurred. %s already exists, use --force to overwrite it.", paths[k])
+			exit(1)
+		}
+	}
+	if options.output == "" {
+		if mkdirErr := os.MkdirAll(options.outputDir, 0755); mkdirErr != nil {
+			color.Red("An error occurred. %s", mkdirErr)
+			exit(1)
+		}
+	}
+
+	var results []customClassesDownloadResult
+	for k, link := range links {
+		sha256Hash, downloadErr := customClassesService.Download(link, paths[k], options.sha256,
+			func(size int64, offset int64, description string) io.Writer {
+				bar := progressbar.DefaultBytes(size, description)
+				_ = bar.Set64(offset)
+				return bar
+			})
+		if downloadErr != nil {
+			printCustomClassesDownloads(results)
+			color.Red("An error occurred. %s", downloadErr)
+			exit(1)
+		}
+		results = append(results, customClassesDownloadResult{Id: link.Id, Name: link.Name, Path: paths[k], Sha256: sha256Hash})
+	}
+	printCustomClassesDownloads(results)
+}
+
+func printCustomClassesDownloads(results []customClassesDownloadResult) {
+	header := table.Row{"Id", "File Name", "Path", "SHA-256"}
+	rows := []table.Row{}
+	for _, result := range results {
+		rows = append(rows, table.Row{result.Id, result.Name, result.Path, result.Sha256})
+	}
+	util.Print(util.PrintRequest{
+		Header:     header,
+		Rows:       rows,
+		Data:       results,
+		PrintStyle: util.PrintStyle(outputStyle),
+	})
+}
+
+func addCustomClassesInspectFlags(cmd *cobra.Command, options *customClassesInspectOptions) {
+	cmd.Flags().StringSliceVar(&options.fileNames, "file-name", []string{}, "files to inspect, can be repeated")
+	cmd.Flags().StringSliceVar(&options.dirs, "dir", []string{}, "directories to inspect the jars of, their class files are packaged into a jar named after the directory")
+	cmd.Flags().StringVar(&options.hazelcastVersion, "hazelcast-version", "", "Hazelcast version to check the Java versions of the classes against, defaults to the version of the cluster when there is one")
+	cmd.Flags().BoolVar(&options.classes, "classes", false, "list the classes instead of the packages")
+}
+
+// inspectCustomClassesFiles prints the packages or classes of the files of the options and the problems found in them.
+// The Hazelcast version of the cluster is only fetched when no version is given, getHazelcastVersion is nil when the
+// command has no cluster.
+func inspectCustomClassesFiles(clusterId string, getHazelcastVersion func() (string, error), options *customClassesInspectOptions) {
+	if len(options.fileNames) == 0 && len(options.dirs) == 0 {
+		color.Red("An error occurred. One of --file-name or --dir is required.")
+		exit(1)
+	}
+	files, filesErr := service.CollectCustomClassesFiles(options.fileNames, options.dirs)
+	if filesErr != nil {
+		color.Red("An error occurred. %s", filesErr)
+		exit(1)
+	}
+	hazelcastVersion := options.hazelcastVersion
+	if hazelcastVersion == "" && clusterId != "" {
+		var hazelcastVersionErr error
+		hazelcastVersion, hazelcastVersionErr = getHazelcastVersion()
+		if hazelcastVersionErr != nil {
+			color.Red("An error occurred. %s", hazelcastVersionErr)
+			exit(1)
+		}
+	}
+
+	inspections := inspectCustomClasses(files, hazelcastVersion)
+	var header table.Row
+	rows := []table.Row{}
+	if options.classes {
+		header = table.Row{"File Name", "Class", "Java Version"}
+		for _, inspection := range inspections {
+			for _, class := range inspection.Classes {
+				rows = append(rows, table.Row{inspection.Name, class.Name, class.JavaVersion})
+			}
+		}
+	} else {
+		header = table.Row{"File Name", "Package", "Classes", "Java Version"}
+		for _, inspection := range inspections {
+			for _, classPackage := range inspection.Packages {
+				rows = append(rows, table.Row{inspection.Name, classPackage.Name, classPackage.ClassCount, classPackage.MaxJavaVersion})
+			}
+		}
+	}
+	util.Print(util.PrintRequest{
+		Header:     header,
+		Rows:       rows,
+		Data:       inspections,
+		PrintStyle: util.PrintStyle(outputStyle),
+	})
+	if printCustomClassesProblems(inspections) {
+		exit(1)
+	}
+	if hazelcastVersion == "" && getHazelcastVersion == nil {
+		color.Yellow("Java versions of the classes are not checked, use --hazelcast-version to check them.")
+	} else if hazelcastVersion == "" {
+		color.Yellow("Java versions of the classes are not checked, use --cluster-id or --hazelcast-version to check them.")
+	}
+}
+
+func inspectCustomClasses(files []service.CustomClassesFile, hazelcastVersion string) []service.CustomClassesInspection {
+	var inspections []service.CustomClassesInspection
+	for _, file := range files {
+		inspection, inspectionErr := service.InspectCustomClassesFile(file, hazelcastVersion)
+		if inspectionErr != nil {
+			color.Red("An error occurred. %s", inspectionErr)
+			exit(1)
+		}
+		inspections = append(inspections, *inspection)
+	}
+	return inspections
+}
+
+// printCustomClassesProblems prints the problems of the inspections and returns whether one of them is an error.
+func printCustomClassesProblems(inspections []service.CustomClassesInspection) bool {
+	hasErrors := false
+	for _, inspection := range inspections {
+		for _, problem := range inspection.Problems {
+			if problem.Severity == service.InspectionSeverityError {
+				color.Red("%s: %s", problem.Severity, problem.Message)
+			} else {
+				color.Yellow("%s: %s", problem.Severity, problem.Message)
+			}
+		}
+		hasErrors = hasErrors || inspection.HasErrors()
+	}
+	return hasErrors
+}
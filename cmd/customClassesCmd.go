@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal"
+	"github.com/hazelcast/hazelcast-cloud-cli/service"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// customClassesProduct is a kind of cluster with custom classes, so the custom-classes commands are built once for
+// every product whose API has artifacts.
+type customClassesProduct struct {
+	// clusterCmd is the command of the product, used in the examples.
+	clusterCmd string
+	// name is the name of the product, used in the help texts.
+	name       string
+	artifacts  func(client *hazelcastcloud.Client) service.ArtifactClient
+	getCluster func(client *hazelcastcloud.Client, clusterId string) (*models.Cluster, *hazelcastcloud.Response, error)
+}
+
+var enterpriseCustomClassesProduct = customClassesProduct{
+	clusterCmd: "enterprise-cluster",
+	name:       "enterprise",
+	artifacts: func(client *hazelcastcloud.Client) service.ArtifactClient {
+		return client.EnterpriseCluster
+	},
+	getCluster: func(client *hazelcastcloud.Client, clusterId string) (*models.Cluster, *hazelcastcloud.Response, error) {
+		return client.EnterpriseCluster.Get(context.Background(), &models.GetEnterpriseClusterInput{
+			ClusterId: clusterId,
+		})
+	},
+}
+
+var serverlessCustomClassesProduct = customClassesProduct{
+	clusterCmd: "serverless-cluster",
+	name:       "serverless",
+	artifacts: func(client *hazelcastcloud.Client) service.ArtifactClient {
+		return client.ServerlessCluster
+	},
+	getCluster: func(client *hazelcastcloud.Client, clusterId string) (*models.Cluster, *hazelcastcloud.Response, error) {
+		return client.ServerlessCluster.Get(context.Background(), &models.GetServerlessClusterInput{
+			ClusterId: clusterId,
+		})
+	},
+}
+
+func newCustomClassesCmd(product customClassesProduct) *cobra.Command {
+	customClassesCmd := &cobra.Command{
+		Use:     "custom-classes",
+		Aliases: []string{"classes"},
+		Short: fmt.Sprintf("This command allows you to manage custom classes on your %s cluster like: list, upload, sync, "+
+			"delete, download or inspect.", product.name),
+	}
+	customClassesCmd.AddCommand(newCustomClassesListCmd(product))
+	customClassesCmd.AddCommand(newCustomClassesUploadCmd(product))
+	customClassesCmd.AddCommand(newCustomClassesSyncCmd(product))
+	customClassesCmd.AddCommand(newCustomClassesDeleteCmd(product))
+	customClassesCmd.AddCommand(newCustomClassesDownloadCmd(product))
+	customClassesCmd.AddCommand(newCustomClassesInspectCmd(product))
+	return customClassesCmd
+}
+
+func newCustomClassesListCmd(product customClassesProduct) *cobra.Command {
+	var clusterId string
+
+	customClassesListCmd := cobra.Command{
+		Use:     "list",
+		Short:   "This command lists Artifacts that contains Custom Classes uploaded to Hazelcast Instance.",
+		Example: fmt.Sprintf("hzcloud %s custom-classes list --cluster-id=1", product.clusterCmd),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := newClient()
+			artifacts := internal.Validate(product.artifacts(client).ListUploadedArtifacts(context.Background(),
+				&models.ListUploadedArtifactsInput{
+					ClusterId: clusterId,
+				})).(*[]models.UploadedArtifact)
+			header := table.Row{"Id", "File Name", "Status"}
+			rows := []table.Row{}
+			for _, artifact := range *artifacts {
+				rows = append(rows, table.Row{artifact.Id, artifact.Name, artifact.Status})
+			}
+			util.Print(util.PrintRequest{
+				Header:     header,
+				Rows:       rows,
+				Data:       artifacts,
+				PrintStyle: util.PrintStyle(outputStyle),
+			})
+		},
+	}
+
+	customClassesListCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
+	_ = customClassesListCmd.MarkFlagRequired("cluster-id")
+
+	return &customClassesListCmd
+}
+
+func newCustomClassesUploadCmd(product customClassesProduct) *cobra.Command {
+	var clusterId string
+	var uploadOptions customClassesUploadOptions
+
+	customClassesUploadCmd := cobra.Command{
+		Use:     "upload",
+		Short:   "This command uploads Artifacts with custom classes to Hazelcast Instance, skipping the ones already uploaded.",
+		Example: fmt.Sprintf("hzcloud %s custom-classes upload --cluster-id=1 --file-name=a.jar,b.jar --dir=target/classes", product.clusterCmd),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := newClient()
+			uploadCustomClasses(product.artifacts(client), clusterId, func() (string, error) {
+				return getHazelcastVersion(product, client, clusterId)
+			}, &uploadOptions)
+		},
+	}
+
+	customClassesUploadCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
+	addCustomClassesUploadFlags(&customClassesUploadCmd, &uploadOptions)
+	_ = customClassesUploadCmd.MarkFlagRequired("cluster-id")
+
+	return &customClassesUploadCmd
+}
+
+func newCustomClassesSyncCmd(product customClassesProduct) *cobra.Command {
+	var clusterId string
+	var dirs []string
+	var prune bool
+	var dryRun bool
+	var yes bool
+
+	customClassesSyncCmd := cobra.Command{
+		Use:     "sync",
+		Short:   "This command uploads the new and changed Artifacts of directories to Hazelcast Instance, deleting the ones not in the directories with --prune.",
+		Example: fmt.Sprintf("hzcloud %s custom-classes sync --cluster-id=1 --dir=build/libs --prune", product.clusterCmd),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := newClient()
+			syncCustomClasses(product.artifacts(client), clusterId, dirs, prune, dryRun, yes,
+				clusterName(func() (*models.Cluster, *hazelcastcloud.Response, error) {
+					return product.getCluster(client, clusterId)
+				}))
+		},
+	}
+
+	customClassesSyncCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
+	customClassesSyncCmd.Flags().StringSliceVar(&dirs, "dir", []string{}, "directories to mirror, their class files are packaged into a jar named after the directory")
+	customClassesSyncCmd.Flags().BoolVar(&prune, "prune", false, "delete the uploaded artifacts which are not in the directories")
+	customClassesSyncCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print what would be uploaded and deleted")
+	customClassesSyncCmd.Flags().BoolVar(&yes, "yes", false, "do not ask for confirmation before deleting or replacing artifacts")
+	_ = customClassesSyncCmd.MarkFlagRequired("cluster-id")
+	_ = customClassesSyncCmd.MarkFlagRequired("dir")
+
+	return &customClassesSyncCmd
+}
+
+func newCustomClassesDeleteCmd(product customClassesProduct) *cobra.Command {
+	var clusterId string
+	var customClassesId string
+	var yes bool
+
+	customClassesDeleteCmd := cobra.Command{
+		Use:     "delete",
+		Short:   "This command deletes Artifact with custom classes that was uploaded to Hazelcast Instance.",
+		Example: fmt.Sprintf("hzcloud %s custom-classes delete --cluster-id=1 --file-id=2", product.clusterCmd),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := newClient()
+			confirmClusterOperation(yes, fmt.Sprintf("Artifact %s will be deleted.", customClassesId),
+				clusterName(func() (*models.Cluster, *hazelcastcloud.Response, error) {
+					return product.getCluster(client, clusterId)
+				}))
+			artifact := internal.Validate(product.artifacts(client).DeleteArtifact(context.Background(),
+				&models.DeleteArtifactInput{
+					ClusterId:       clusterId,
+					CustomClassesId: customClassesId,
+				})).(*models.UploadedArtifact)
+
+			header := table.Row{"Id", "File Name", "Status"}
+			rows := []table.Row{{artifact.Id, artifact.Name, artifact.Status}}
+			util.Print(util.PrintRequest{
+				Header:     header,
+				Rows:       rows,
+				Data:       artifact,
+				PrintStyle: util.PrintStyle(outputStyle),
+			})
+		},
+	}
+
+	customClassesDeleteCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
+	customClassesDeleteCmd.Flags().StringVar(&customClassesId, "file-id", "", "id of the Uploaded Artifact")
+	customClassesDeleteCmd.Flags().BoolVar(&yes, "yes", false, "do not ask for confirmation")
+	_ = customClassesDeleteCmd.MarkFlagRequired("cluster-id")
+	_ = customClassesDeleteCmd.MarkFlagRequired("file-id")
+
+	return &customClassesDeleteCmd
+}
+
+func newCustomClassesDownloadCmd(product customClassesProduct) *cobra.Command {
+	var clusterId string
+	var downloadOptions customClassesDownloadOptions
+
+	customClassesDownloadCmd := cobra.Command{
+		Use:     "download",
+		Short:   "This command downloads artifacts with custom classes that were uploaded to Hazelcast Instance.",
+		Example: fmt.Sprintf("hzcloud %s custom-classes download --cluster-id=1 --file-id=2 --output-dir=classes", product.clusterCmd),
+		Run: func(cmd *cobra.Command, args []string) {
+			client := newClient()
+			downloadCustomClasses(product.artifacts(client), clusterId, &downloadOptions)
+		},
+	}
+
+	customClassesDownloadCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
+	addCustomClassesDownloadFlags(&customClassesDownloadCmd, &downloadOptions)
+	_ = customClassesDownloadCmd.MarkFlagRequired("cluster-id")
+
+	return &customClassesDownloadCmd
+}
+
+func newCustomClassesInspectCmd(product customClassesProduct) *cobra.Command {
+	var clusterId string
+	var inspectOptions customClassesInspectOptions
+
+	customClassesInspectCmd := cobra.Command{
+		Use:     "inspect",
+		Short:   "This command lists the packages and classes of jars and checks that the cluster can load them before uploading.",
+		Example: fmt.Sprintf("hzcloud %s custom-classes inspect --cluster-id=1 --file-name=a.jar", product.clusterCmd),
+		Run: func(cmd *cobra.Command, args []string) {
+			inspectCustomClassesFiles(clusterId, func() (string, error) {
+				return getHazelcastVersion(product, newClient(), clusterId)
+			}, &inspectOptions)
+		},
+	}
+
+	customClassesInspectCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster to check the classes against")
+	addCustomClassesInspectFlags(&customClassesInspectCmd, &inspectOptions)
+
+	return &customClassesInspectCmd
+}
+
+// newStandaloneCustomClassesCmd builds the custom-classes command of the root, which inspects jars without a cluster.
+func newStandaloneCustomClassesCmd() *cobra.Command {
+	var inspectOptions customClassesInspectOptions
+
+	customClassesInspectCmd := cobra.Command{
+		Use:     "inspect",
+		Short:   "This command lists the packages and classes of jars and checks that a cluster of a Hazelcast version can load them, without a cluster.",
+		Example: "hzcloud custom-classes inspect --hazelcast-version=5.4.0 --file-name=a.jar",
+		Run: func(cmd *cobra.Command, args []string) {
+			inspectCustomClassesFiles("", nil, &inspectOptions)
+		},
+	}
+	addCustomClassesInspectFlags(&customClassesInspectCmd, &inspectOptions)
+
+	customClassesCmd := &cobra.Command{
+		Use:     "custom-classes",
+		Aliases: []string{"classes"},
+		Short:   "This command allows you to inspect custom classes before creating a cluster, use the custom-classes command of a cluster to manage its custom classes.",
+	}
+	customClassesCmd.AddCommand(&customClassesInspectCmd)
+	return customClassesCmd
+}
+
+func init() {
+	rootCmd.AddCommand(newStandaloneCustomClassesCmd())
+}
+
+func getHazelcastVersion(product customClassesProduct, client *hazelcastcloud.Client, clusterId string) (string, error) {
+	cluster, _, err := product.getCluster(client, clusterId)
+	if err != nil {
+		return "", err
+	}
+	return cluster.HazelcastVersion, nil
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+func TestCustomClasses(t *testing.T) {
+	server := newFakeApi(t)
+	clusterId := addEnterpriseCluster(server, "aws", "eu-west-2")
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "classes.jar")
+	if err := ioutil.WriteFile(fileName, []byte("classes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var uploads []customClassesUploadResult
+	executeJsonCommand(t, &uploads, "enterprise-cluster", "custom-classes", "upload", "--cluster-id="+clusterId,
+		"--file-name="+fileName)
+	if len(uploads) != 1 || uploads[0].Name != "classes.jar" {
+		t.Fatalf("upload printed %+v", uploads)
+	}
+	artifactId := uploads[0].Id
+	executeJsonCommand(t, &uploads, "enterprise-cluster", "custom-classes", "upload", "--cluster-id="+clusterId,
+		"--file-name="+fileName)
+	if len(uploads) != 1 || uploads[0].Id != artifactId || uploads[0].Status != "UNCHANGED" {
+		t.Fatalf("upload of the same file printed %+v", uploads)
+	}
+
+	var artifacts []models.UploadedArtifact
+	executeJsonCommand(t, &artifacts, "enterprise-cluster", "custom-classes", "list", "--cluster-id="+clusterId)
+	if len(artifacts) != 1 || artifacts[0].Id != artifactId || artifacts[0].Name != "classes.jar" {
+		t.Fatalf("list printed %+v", artifacts)
+	}
+
+	outputDir := filepath.Join(dir, "downloads")
+	executeCommand(t, "enterprise-cluster", "custom-classes", "download", "--cluster-id="+clusterId,
+		"--file-id="+artifactId, "--output-dir="+outputDir)
+	content, readErr := ioutil.ReadFile(filepath.Join(outputDir, "classes.jar"))
+	if readErr != nil || string(content) != "classes" {
+		t.Fatalf("download wrote %q. %v", content, readErr)
+	}
+
+	executeCommand(t, "enterprise-cluster", "custom-classes", "delete", "--cluster-id="+clusterId,
+		"--file-id="+artifactId, "--yes")
+	if artifacts := server.Artifacts(clusterId); len(artifacts) != 0 {
+		t.Fatalf("deleted artifact is still there %+v", artifacts)
+	}
+}
+
+func TestCustomClassesInspect(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "classes.jar")
+	file, createErr := os.Create(fileName)
+	if createErr != nil {
+		t.Fatal(createErr)
+	}
+	writer := zip.NewWriter(file)
+	entry, entryErr := writer.Create("com/example/Entity.class")
+	if entryErr != nil {
+		t.Fatal(entryErr)
+	}
+	// class file header of Java 21
+	_, _ = entry.Write([]byte{0xCA, 0xFE, 0xBA, 0xBE, 0x00, 0x00, 0x00, 0x41})
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = file.Close()
+
+	out := executeCommand(t, "custom-classes", "inspect", "--hazelcast-version=4.2.0", "--file-name="+fileName,
+		"--classes=false", "--output=csv")
+	if !strings.Contains(out, "classes.jar,com.example,1,21") {
+		t.Errorf("inspect printed no package row:\n%s", out)
+	}
+	if !strings.Contains(out, "WARNING: classes of package com.example are compiled for Java 21, the cluster runs Hazelcast 4.2.0 which is assumed to run on Java 11") {
+		t.Errorf("inspect printed no Java version warning:\n%s", out)
+	}
+}
+
+func TestCustomClassesUploadWait(t *testing.T) {
+	server := newFakeApi(t)
+	server.TransitionSteps = 1
+	server.FailingArtifactNames["broken.jar"] = true
+	clusterId := addEnterpriseCluster(server, "aws", "eu-west-2")
+	dir := t.TempDir()
+	for name, content := range map[string]string{"classes.jar": "classes", "broken.jar": "broken", "slow.jar": "slow"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := executeCommand(t, "enterprise-cluster", "custom-classes", "upload", "--cluster-id="+clusterId,
+		"--file-name="+filepath.Join(dir, "classes.jar"), "--wait")
+	if !strings.Contains(out, "PENDING -> READY") || !strings.Contains(out, "All 1 artifacts are ready.") {
+		t.Errorf("upload printed\n%s", out)
+	}
+
+	out, exitCode := executeExitingCommand(t, "enterprise-cluster", "custom-classes", "upload", "--cluster-id="+clusterId,
+		"--file-name="+filepath.Join(dir, "broken.jar"), "--wait")
+	if exitCode != 1 || !strings.Contains(out, "PENDING -> FAILED") || !strings.Contains(out, "1 of 1 artifacts failed.") {
+		t.Errorf("upload of a failing artifact exited with %d and printed\n%s", exitCode, out)
+	}
+
+	server.TransitionSteps = 1000
+	out, exitCode = executeExitingCommand(t, "enterprise-cluster", "custom-classes", "upload", "--cluster-id="+clusterId,
+		"--file-name="+filepath.Join(dir, "slow.jar"), "--wait", "--wait-timeout=0s")
+	if exitCode != 1 || !strings.Contains(out, "PENDING -> PROCESSING") ||
+		!strings.Contains(out, "timed out waiting for 1 artifacts to be processed") {
+		t.Errorf("upload of a slow artifact exited with %d and printed\n%s", exitCode, out)
+	}
+}
+
+func TestCustomClassesSync(t *testing.T) {
+	server := newFakeApi(t)
+	clusterId := addEnterpriseCluster(server, "aws", "eu-west-2")
+	server.AddArtifact(fakeapi.Artifact{ClusterId: clusterId, Name: "same.jar", Content: []byte("same")})
+	oldId := server.AddArtifact(fakeapi.Artifact{ClusterId: clusterId, Name: "old.jar", Content: []byte("old")})
+	dir := t.TempDir()
+	for name, content := range map[string]string{"same.jar": "same", "new.jar": "new"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	artifactNames := func() string {
+		var names []string
+		for _, artifact := range server.Artifacts(clusterId) {
+			names = append(names, artifact.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	out := executeCommand(t, "enterprise-cluster", "custom-classes", "sync", "--cluster-id="+clusterId, "--dir="+dir,
+		"--prune", "--dry-run")
+	for _, row := range []string{"UPLOAD", "UNCHANGED", "DELETE", "Dry run, nothing was changed."} {
+		if !strings.Contains(out, row) {
+			t.Errorf("sync with --dry-run printed no %s\n%s", row, out)
+		}
+	}
+	if names := artifactNames(); names != "same.jar,old.jar" {
+		t.Errorf("sync with --dry-run changed the artifacts to %s", names)
+	}
+
+	var results []customClassesSyncResult
+	executeJsonCommand(t, &results, "enterprise-cluster", "custom-classes", "sync", "--cluster-id="+clusterId, "--dir="+dir)
+	if len(results) != 3 || results[0].Action != "UPLOAD" || results[0].Name != "new.jar" || results[0].Result != "DONE" ||
+		results[2].Action != "KEEP" || results[2].ArtifactId != oldId {
+		t.Errorf("sync printed %+v", results)
+	}
+	if names := artifactNames(); names != "same.jar,old.jar,new.jar" {
+		t.Errorf("artifacts after sync are %s", names)
+	}
+	out, exitCode := executeExitingCommand(t, "enterprise-cluster", "custom-classes", "sync", "--cluster-id="+clusterId,
+		"--dir="+dir, "--prune")
+	if exitCode != 1 || !strings.Contains(out, "confirmation is needed, use --yes to run without a terminal") {
+		t.Errorf("sync with --prune without --yes exited with %d and printed\n%s", exitCode, out)
+	}
+	if names := artifactNames(); names != "same.jar,old.jar,new.jar" {
+		t.Errorf("sync without confirmation changed the artifacts to %s", names)
+	}
+	executeCommand(t, "enterprise-cluster", "custom-classes", "sync", "--cluster-id="+clusterId, "--dir="+dir, "--prune", "--yes")
+	if names := artifactNames(); names != "same.jar,new.jar" {
+		t.Errorf("artifacts after sync with --prune are %s", names)
+	}
+}
+
+func TestCustomClassesDownload(t *testing.T) {
+	server := newFakeApi(t)
+	clusterId := addEnterpriseCluster(server, "aws", "eu-west-2")
+	firstId := server.AddArtifact(fakeapi.Artifact{ClusterId: clusterId, Name: "classes.jar", Content: []byte("first")})
+	secondId := server.AddArtifact(fakeapi.Artifact{ClusterId: clusterId, Name: "classes.jar", Content: []byte("second")})
+	otherId := server.AddArtifact(fakeapi.Artifact{ClusterId: clusterId, Name: "other.jar", Content: []byte("other")})
+	outputDir := t.TempDir()
+
+	executeCommand(t, "enterprise-cluster", "custom-classes", "download", "--cluster-id="+clusterId, "--all",
+		"--output-dir="+outputDir)
+	for name, content := range map[string]string{firstId + "-classes.jar": "first", secondId + "-classes.jar": "second",
+		"other.jar": "other"} {
+		if downloaded, readErr := ioutil.ReadFile(filepath.Join(outputDir, name)); readErr != nil || string(downloaded) != content {
+			t.Errorf("download of %s wrote %q. %v", name, downloaded, readErr)
+		}
+	}
+
+	path := filepath.Join(outputDir, "other.jar")
+	if err := ioutil.WriteFile(path, []byte("local"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, exitCode := executeExitingCommand(t, "enterprise-cluster", "custom-classes", "download", "--cluster-id="+clusterId,
+		"--file-id="+otherId, "--output-dir="+outputDir)
+	if exitCode != 1 || !strings.Contains(out, path+" already exists, use --force to overwrite it.") {
+		t.Errorf("download over an existing file exited with %d and printed\n%s", exitCode, out)
+	}
+	if content, _ := ioutil.ReadFile(path); string(content) != "local" {
+		t.Errorf("existing file is overwritten with %q", content)
+	}
+	executeCommand(t, "enterprise-cluster", "custom-classes", "download", "--cluster-id="+clusterId, "--file-id="+otherId,
+		"--output-dir="+outputDir, "--force")
+	if content, _ := ioutil.ReadFile(path); string(content) != "other" {
+		t.Errorf("download with --force wrote %q", content)
+	}
+}
@@ -2,17 +2,13 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"github.com/fatih/color"
 	"github.com/hazelcast/hazelcast-cloud-cli/internal"
 	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
 	"github.com/jedib0t/go-pretty/v6/table"
-	"github.com/schollz/progressbar/v3"
-	"io"
-	"io/ioutil"
-	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -39,7 +35,7 @@ var enterpriseClusterCreateCmd = &cobra.Command{
 			return err
 		}
 		enterpriseClusterCreateInput.ZoneType = zoneType
-		client := internal.NewClient()
+		client := newClient()
 		cluster := internal.Validate(client.EnterpriseCluster.Create(context.Background(),
 			&enterpriseClusterCreateInput)).(*models.Cluster)
 		color.Green("Cluster creation started.")
@@ -72,7 +68,7 @@ var enterpriseClusterGetCmd = &cobra.Command{
 	Short:   "This command get detailed configuration of starter Hazelcast instance.",
 	Example: "hzcloud enterprise-cluster get --cluster-id=3",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		cluster := internal.Validate(client.EnterpriseCluster.Get(context.Background(),
 			&models.GetEnterpriseClusterInput{
 				ClusterId: enterpriseClusterId,
@@ -89,7 +85,7 @@ var enterpriseClusterListCmd = &cobra.Command{
 	Short:   "This command lists Hazelcast Instances.",
 	Example: "hzcloud enterprise-cluster list",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		clusters := internal.Validate(client.EnterpriseCluster.List(context.Background())).(*[]models.Cluster)
 		header := table.Row{
 			"Id", "Name", "State", "Version", "Memory(GiB)", "Network", "Instance", "Per Zone", "Cloud Provider",
@@ -119,207 +115,25 @@ var enterpriseClusterDeleteCmd = &cobra.Command{
 	Short:   "This command deletes Hazelcast Instance according to its id",
 	Example: "hzcloud enterprise-cluster delete --cluster-id=3",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		forceUnprotect, _ := cmd.Flags().GetBool("force-unprotect")
+		checkClusterProtection(enterpriseClusterId, forceUnprotect)
+		client := newClient()
+		yes, _ := cmd.Flags().GetBool("yes")
+		confirmClusterOperation(yes, fmt.Sprintf("Cluster %s will be deleted.", enterpriseClusterId),
+			clusterName(func() (*models.Cluster, *hazelcastcloud.Response, error) {
+				return client.EnterpriseCluster.Get(context.Background(), &models.GetEnterpriseClusterInput{
+					ClusterId: enterpriseClusterId,
+				})
+			}))
 		clusterResponse := internal.Validate(client.EnterpriseCluster.Delete(context.Background(),
 			&models.ClusterDeleteInput{
 				ClusterId: enterpriseClusterId,
 			})).(*models.ClusterId)
+		unprotectDeletedCluster(enterpriseClusterId)
 		color.Blue("Cluster %d deleted.", clusterResponse.ClusterId)
 	},
 }
 
-func newEnterpriseCustomClassesCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:     "custom-classes",
-		Aliases: []string{"classes"},
-		Short:   "This command allows you to manage custom classes on your enterprise cluster like: list, upload, delete.",
-	}
-}
-
-func newEnterpriseCustomClassesListCmd() *cobra.Command {
-	var clusterId string
-
-	enterpriseCustomClassesListCmd := cobra.Command{
-		Use:     "list",
-		Short:   "This command lists Artifacts that contains Custom Classes uploaded to Hazelcast Instance.",
-		Example: "hzcloud enterprise-cluster custom-classes list",
-		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
-			artifacts := internal.Validate(client.EnterpriseCluster.ListUploadedArtifacts(context.Background(),
-				&models.ListUploadedArtifactsInput{
-					ClusterId: clusterId,
-				})).(*[]models.UploadedArtifact)
-			header := table.Row{"Id", "File Name", "Status"}
-			rows := []table.Row{}
-			for _, artifact := range *artifacts {
-				rows = append(rows, table.Row{artifact.Id, artifact.Name, artifact.Status})
-			}
-			util.Print(util.PrintRequest{
-				Header:     header,
-				Rows:       rows,
-				Data:       artifacts,
-				PrintStyle: util.PrintStyle(outputStyle),
-			})
-		},
-	}
-
-	enterpriseCustomClassesListCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
-	_ = enterpriseCustomClassesListCmd.MarkFlagRequired("cluster-id")
-
-	return &enterpriseCustomClassesListCmd
-}
-
-func newEnterpriseClusterCustomClassesUploadCmd() *cobra.Command {
-	var clusterId string
-	var customClassesFileName string
-
-	enterpriseClusterCustomClassesUploadCmd := cobra.Command{
-		Use:     "upload",
-		Short:   "This command uploads Artifact with custom classes to Hazelcast Instance.",
-		Example: "hzcloud enterprise-cluster custom-classes upload",
-		Run: func(cmd *cobra.Command, args []string) {
-			file, err := os.Open(customClassesFileName)
-			if err != nil {
-				color.Red(err.Error())
-				os.Exit(1)
-			}
-			defer file.Close()
-			stat, err := file.Stat()
-			if err != nil {
-				color.Red(err.Error())
-				os.Exit(1)
-			}
-			reader := progressbar.NewReader(file, progressbar.DefaultBytes(stat.Size(), "uploading "+file.Name()))
-			client := internal.NewClient()
-			artifact := internal.Validate(client.EnterpriseCluster.UploadArtifact(context.Background(),
-				&models.UploadArtifactInput{
-					ClusterId: clusterId,
-					FileName:  filepath.Base(file.Name()),
-					Content:   &reader,
-				})).(*models.UploadedArtifact)
-
-			header := table.Row{"Id", "File Name", "Status"}
-			rows := []table.Row{{artifact.Id, artifact.Name, artifact.Status}}
-			util.Print(util.PrintRequest{
-				Header:     header,
-				Rows:       rows,
-				Data:       artifact,
-				PrintStyle: util.PrintStyle(outputStyle),
-			})
-		},
-	}
-
-	enterpriseClusterCustomClassesUploadCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
-	enterpriseClusterCustomClassesUploadCmd.Flags().StringVar(&customClassesFileName, "file-name", "", "File to upload")
-	_ = enterpriseClusterCustomClassesUploadCmd.MarkFlagRequired("cluster-id")
-	_ = enterpriseClusterCustomClassesUploadCmd.MarkFlagRequired("file-name")
-
-	return &enterpriseClusterCustomClassesUploadCmd
-}
-
-func newEnterpriseClusterCustomClassesDeleteCmd() *cobra.Command {
-	var clusterId string
-	var customClassesId string
-
-	enterpriseClusterCustomClassesDeleteCmd := cobra.Command{
-		Use:     "delete",
-		Short:   "This command deletes Artifact with custom classes that was uploaded to Hazelcast Instance.",
-		Example: "hzcloud enterprise-cluster custom-classes delete",
-		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
-			artifact := internal.Validate(client.EnterpriseCluster.DeleteArtifact(context.Background(),
-				&models.DeleteArtifactInput{
-					ClusterId:       clusterId,
-					CustomClassesId: customClassesId,
-				})).(*models.UploadedArtifact)
-
-			header := table.Row{"Id", "File Name", "Status"}
-			rows := []table.Row{{artifact.Id, artifact.Name, artifact.Status}}
-			util.Print(util.PrintRequest{
-				Header:     header,
-				Rows:       rows,
-				Data:       artifact,
-				PrintStyle: util.PrintStyle(outputStyle),
-			})
-		},
-	}
-
-	enterpriseClusterCustomClassesDeleteCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
-	enterpriseClusterCustomClassesDeleteCmd.Flags().StringVar(&customClassesId, "file-id", "",
-		"id of the Uploaded Artifact")
-	_ = enterpriseClusterCustomClassesDeleteCmd.MarkFlagRequired("cluster-id")
-	_ = enterpriseClusterCustomClassesDeleteCmd.MarkFlagRequired("file-id")
-
-	return &enterpriseClusterCustomClassesDeleteCmd
-}
-
-func newEnterpriseClusterCustomClassesDownloadCmd() *cobra.Command {
-	var clusterId string
-	var customClassesId string
-
-	enterpriseClusterCustomClassesDownloadCmd := cobra.Command{
-		Use:     "download",
-		Short:   "This command downloads an artifact with custom classes that was uploaded to Hazelcast Instance.",
-		Example: "hzcloud enterprise-cluster custom-classes download",
-		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
-			artifact := internal.Validate(client.EnterpriseCluster.DownloadArtifact(context.Background(),
-				&models.DownloadArtifactInput{
-					ClusterId:       clusterId,
-					CustomClassesId: customClassesId,
-				})).(*models.UploadedArtifactLink)
-
-			header := table.Row{"Id", "File Name"}
-			rows := []table.Row{{artifact.Id, artifact.Name}}
-			util.Print(util.PrintRequest{
-				Header:     header,
-				Rows:       rows,
-				Data:       artifact,
-				PrintStyle: util.PrintStyle(outputStyle),
-			})
-			httpClient := http.Client{}
-			request, requestErr := http.NewRequest("GET", artifact.Url, nil)
-			if requestErr != nil {
-				color.Red(requestErr.Error())
-				os.Exit(1)
-			}
-			response, responseErr := httpClient.Do(request)
-			if responseErr != nil {
-				color.Red(responseErr.Error())
-				os.Exit(1)
-			}
-			tmpFile, tmpFileErr := ioutil.TempFile("", "*-"+artifact.Name)
-			if tmpFileErr != nil {
-				color.Red(tmpFileErr.Error())
-				os.Exit(1)
-			}
-			defer tmpFile.Close()
-			bar := progressbar.DefaultBytes(
-				response.ContentLength,
-				"downloading "+artifact.Name,
-			)
-			_, writeErr := io.Copy(io.MultiWriter(tmpFile, bar), response.Body)
-			if writeErr != nil {
-				color.Red(writeErr.Error())
-				os.Exit(1)
-			}
-			renameErr := os.Rename(tmpFile.Name(), artifact.Name)
-			if renameErr != nil {
-				color.Red(renameErr.Error())
-				os.Exit(1)
-			}
-		},
-	}
-
-	enterpriseClusterCustomClassesDownloadCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
-	enterpriseClusterCustomClassesDownloadCmd.Flags().StringVar(&customClassesId, "file-id", "",
-		"id of the Uploaded Artifact")
-	_ = enterpriseClusterCustomClassesDownloadCmd.MarkFlagRequired("cluster-id")
-	_ = enterpriseClusterCustomClassesDownloadCmd.MarkFlagRequired("file-id")
-
-	return &enterpriseClusterCustomClassesDownloadCmd
-}
-
 func init() {
 	rootCmd.AddCommand(enterpriseClusterCmd)
 	enterpriseClusterCmd.AddCommand(enterpriseClusterCreateCmd)
@@ -390,6 +204,8 @@ func init() {
 	enterpriseClusterCreateCmd.Flags().Bool("wait", false, "wait until resource creation finished")
 
 	enterpriseClusterDeleteCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	enterpriseClusterDeleteCmd.Flags().Bool("yes", false, "do not ask for confirmation")
+	enterpriseClusterDeleteCmd.Flags().Bool("force-unprotect", false, "delete the cluster even if it is protected")
 	err = enterpriseClusterDeleteCmd.MarkFlagRequired("cluster-id")
 	if err != nil {
 		panic(err)
@@ -401,10 +217,5 @@ func init() {
 		panic(err)
 	}
 
-	enterpriseCustomClassesCmd := newEnterpriseCustomClassesCmd()
-	enterpriseClusterCmd.AddCommand(enterpriseCustomClassesCmd)
-	enterpriseCustomClassesCmd.AddCommand(newEnterpriseCustomClassesListCmd())
-	enterpriseCustomClassesCmd.AddCommand(newEnterpriseClusterCustomClassesUploadCmd())
-	enterpriseCustomClassesCmd.AddCommand(newEnterpriseClusterCustomClassesDeleteCmd())
-	enterpriseCustomClassesCmd.AddCommand(newEnterpriseClusterCustomClassesDownloadCmd())
+	enterpriseClusterCmd.AddCommand(newCustomClassesCmd(enterpriseCustomClassesProduct))
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+func TestEnterpriseCluster(t *testing.T) {
+	server := newFakeApi(t)
+	server.TransitionSteps = 1
+
+	executeCommand(t, "enterprise-cluster", "create", "--name=mycluster", "--cloud-provider=aws", "--region=eu-west-2",
+		"--zone-type=MULTI", "--hazelcast-version=5.0", "--instance-type=m5.large", "--cidr-block=10.80.0.0/16",
+		"--native-memory=4", "--wait")
+	var clusters []models.Cluster
+	executeJsonCommand(t, &clusters, "enterprise-cluster", "list")
+	if len(clusters) != 1 || clusters[0].Name != "mycluster" || clusters[0].Networking.CidrBlock != "10.80.0.0/16" ||
+		len(clusters[0].CloudProvider.AvailabilityZones) != 3 || clusters[0].State != models.Running {
+		t.Fatalf("list printed %+v", clusters)
+	}
+	clusterId := clusters[0].Id
+
+	var cluster models.Cluster
+	executeJsonCommand(t, &cluster, "enterprise-cluster", "get", "--cluster-id="+clusterId)
+	if cluster.Id != clusterId || cluster.Specs.InstanceType != "m5.large" {
+		t.Fatalf("get printed %+v", cluster)
+	}
+	executeCommand(t, "enterprise-cluster", "delete", "--cluster-id="+clusterId, "--yes")
+	if _, ok := server.Cluster(clusterId); ok {
+		t.Fatal("deleted cluster is still there")
+	}
+}
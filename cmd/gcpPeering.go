@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"github.com/fatih/color"
 	"github.com/hazelcast/hazelcast-cloud-cli/internal"
 	"github.com/hazelcast/hazelcast-cloud-cli/service"
 	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
 	"github.com/jedib0t/go-pretty/v6/table"
 
@@ -15,6 +17,12 @@ import (
 var gcpPeeringId string
 var gcpNetworkName string
 var gcpProjectId string
+var gcpHostProjectId string
+var gcpCredentialsFile string
+var gcpImpersonateServiceAccount string
+var gcpExportCustomRoutes bool
+var gcpImportCustomRoutes bool
+var gcpHazelcastOnly bool
 
 var gcpPeeringCmd = &cobra.Command{
 	Use:     "gcp-peering",
@@ -27,12 +35,24 @@ var gcpPeeringCreateCmd = &cobra.Command{
 	Short:   "This command creates GCP VPC Peering between your own VPC and your Enterprise Hazelcast cluster vNet.",
 	Example: "hzcloud gcp-peering create --cluster-id=1 --project-id=2 --network-name=3",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
-		peeringCreateErr := service.NewGcpPeeringService(client).Create(&service.GcpCustomerPeeringProperties{
-			ClusterId:   enterpriseClusterId,
-			ProjectId:   gcpProjectId,
-			NetworkName: gcpNetworkName,
-		})
+		client := newClient()
+		if hasPeeringTargets() {
+			createGcpPeerings(client)
+			return
+		}
+		if gcpProjectId == "" || gcpNetworkName == "" {
+			color.Red("An error occurred. --project-id and --network-name are required unless --target or --targets-file is set.")
+			return
+		}
+		if emitPeeringTemplate(func(format string) (string, error) {
+			return newGcpPeeringService(client).Emit(newGcpCustomerPeeringProperties(gcpProjectId, gcpHostProjectId, gcpNetworkName), format)
+		}) {
+			return
+		}
+		indicator := util.NewLoadingIndicator("GCP Peering starting...", 100)
+		indicator.Start()
+		peeringCreateErr := newGcpPeeringService(client).Create(newGcpCustomerPeeringProperties(gcpProjectId, gcpHostProjectId, gcpNetworkName), indicator)
+		indicator.Stop()
 		if peeringCreateErr != nil {
 			color.Red("An error occurred. %s", peeringCreateErr)
 		} else {
@@ -41,12 +61,50 @@ var gcpPeeringCreateCmd = &cobra.Command{
 	},
 }
 
+var gcpPeeringAcceptCmd = &cobra.Command{
+	Use:     "accept",
+	Short:   "This command completes the Hazelcast side of a GCP VPC Peering whose network peering in your project was created from the output of `create --emit`.",
+	Example: "hzcloud gcp-peering accept --cluster-id=1 --project-id=2 --network-name=3",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newClient()
+		indicator := util.NewLoadingIndicator("GCP Peering accepting...", 100)
+		indicator.Start()
+		peeringAcceptErr := service.NewGcpPeeringService(client).Accept(newGcpCustomerPeeringProperties(gcpProjectId, gcpHostProjectId, gcpNetworkName), indicator)
+		indicator.Stop()
+		if peeringAcceptErr != nil {
+			color.Red("An error occurred. %s", peeringAcceptErr)
+		} else {
+			color.Green("Peering successfully established.")
+		}
+	},
+}
+
+var gcpPeeringPropertiesCmd = &cobra.Command{
+	Use:     "properties",
+	Short:   "This command shows the GCP network properties of your Enterprise Hazelcast cluster to create the peering manually.",
+	Example: "hzcloud gcp-peering properties --cluster-id=1",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newClient()
+		properties := internal.Validate(client.GcpPeering.GetProperties(context.Background(), &models.GetGcpPeeringPropertiesInput{
+			ClusterId: enterpriseClusterId,
+		})).(*models.GcpPeeringProperties)
+		header := table.Row{"Project Id", "Network Name"}
+		rows := []table.Row{{properties.ProjectId, properties.NetworkName}}
+		util.Print(util.PrintRequest{
+			Data:       properties,
+			Header:     header,
+			Rows:       rows,
+			PrintStyle: util.PrintStyle(outputStyle),
+		})
+	},
+}
+
 var gcpPeeringListCmd = &cobra.Command{
 	Use:     "list",
 	Short:   "This command lists GCP VPC peerings on your Enterprise Hazelcast cluster.",
 	Example: "hzcloud gcp-peering list --cluster-id=1",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		peerings := internal.Validate(client.GcpPeering.List(context.Background(), &models.ListGcpPeeringsInput{
 			ClusterId: enterpriseClusterId,
 		})).(*[]models.GcpPeering)
@@ -66,36 +124,140 @@ var gcpPeeringListCmd = &cobra.Command{
 
 var gcpPeeringDeleteCmd = &cobra.Command{
 	Use:     "delete",
-	Short:   "This command deletes GCP VPC peering from your Enterprise Hazelcast cluster.",
-	Example: "hzcloud gcp-peering delete --peering-id=1",
+	Short:   "This command deletes GCP VPC peering from your Enterprise Hazelcast cluster together with the network peering in your project.",
+	Example: "hzcloud gcp-peering delete --cluster-id=1 --peering-id=1",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
-		_ = internal.Validate(client.GcpPeering.Delete(context.Background(), &models.DeleteGcpPeeringInput{
-			Id: gcpPeeringId,
-		})).(*models.Result)
+		client := newClient()
+		yes, _ := cmd.Flags().GetBool("yes")
+		confirmPeeringDeletion(client, gcpPeeringId, yes)
+		if gcpHazelcastOnly {
+			_ = internal.Validate(client.GcpPeering.Delete(context.Background(), &models.DeleteGcpPeeringInput{
+				Id: gcpPeeringId,
+			})).(*models.Result)
+			color.Blue("Peering %s deleted.", gcpPeeringId)
+			return
+		}
+		if enterpriseClusterId == "" {
+			color.Red("An error occurred. --cluster-id is required unless --hazelcast-only is set.")
+			return
+		}
+		indicator := util.NewLoadingIndicator("GCP Peering deleting...", 100)
+		indicator.Start()
+		removedPeeringName, peeringDeleteErr := newGcpPeeringService(client).Delete(enterpriseClusterId, gcpPeeringId, indicator)
+		indicator.Stop()
+		if peeringDeleteErr != nil {
+			color.Red("An error occurred. %s", peeringDeleteErr)
+			return
+		}
+		if removedPeeringName != "" {
+			color.Blue("Network peering %s removed.", removedPeeringName)
+		}
 		color.Blue("Peering %s deleted.", gcpPeeringId)
 	},
 }
 
+var gcpPeeringDiagnoseCmd = &cobra.Command{
+	Use:     "diagnose",
+	Short:   "This command checks GCP VPC peerings of your Enterprise Hazelcast cluster against the network peerings and subnetworks of your networks.",
+	Example: "hzcloud gcp-peering diagnose --cluster-id=1",
+	Run: func(cmd *cobra.Command, args []string) {
+		client := newClient()
+		checks, diagnoseErr := newGcpPeeringService(client).Diagnose(enterpriseClusterId)
+		if diagnoseErr != nil {
+			color.Red("An error occurred. %s", diagnoseErr)
+			return
+		}
+		printPeeringChecks(checks)
+	},
+}
+
+func createGcpPeerings(client *hazelcastcloud.Client) {
+	targets, targetsErr := readPeeringTargets([]string{"project-id", "network-name"}, []string{"host-project-id"})
+	if targetsErr != nil {
+		color.Red("An error occurred. %s", targetsErr)
+		return
+	}
+	labels := make([]string, len(targets))
+	for k, target := range targets {
+		labels[k] = fmt.Sprintf("%s/%s", target.value("project-id", ""), target.value("network-name", ""))
+	}
+	runPeeringTargets("GCP Peerings starting...", labels, func(index int, indicator *util.LoadingIndicator) error {
+		target := targets[index]
+		return newGcpPeeringService(client).Create(newGcpCustomerPeeringProperties(target.value("project-id", ""),
+			target.value("host-project-id", gcpHostProjectId), target.value("network-name", "")), indicator)
+	})
+}
+
+func newGcpCustomerPeeringProperties(projectId string, hostProjectId string, networkName string) *service.GcpCustomerPeeringProperties {
+	return &service.GcpCustomerPeeringProperties{
+		ClusterId:          enterpriseClusterId,
+		ProjectId:          projectId,
+		HostProjectId:      hostProjectId,
+		NetworkName:        networkName,
+		ExportCustomRoutes: gcpExportCustomRoutes,
+		ImportCustomRoutes: gcpImportCustomRoutes,
+	}
+}
+
+func newGcpPeeringService(client *hazelcastcloud.Client) service.GcpPeeringService {
+	gcpPeeringService := service.NewGcpPeeringService(client)
+	gcpPeeringService.CredentialsFile = gcpCredentialsFile
+	gcpPeeringService.ImpersonateServiceAccount = gcpImpersonateServiceAccount
+	return gcpPeeringService
+}
+
+func addGcpCredentialFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&gcpCredentialsFile, "credentials-file", "", "path of the gcp credentials file, defaults to application default credentials")
+	cmd.Flags().StringVar(&gcpImpersonateServiceAccount, "impersonate-service-account", "", "email of the gcp service account to impersonate")
+}
+
 func init() {
 	rootCmd.AddCommand(gcpPeeringCmd)
 	gcpPeeringCmd.AddCommand(gcpPeeringCreateCmd)
+	gcpPeeringCmd.AddCommand(gcpPeeringAcceptCmd)
 	gcpPeeringCmd.AddCommand(gcpPeeringListCmd)
+	gcpPeeringCmd.AddCommand(gcpPeeringPropertiesCmd)
 	gcpPeeringCmd.AddCommand(gcpPeeringDeleteCmd)
+	gcpPeeringCmd.AddCommand(gcpPeeringDiagnoseCmd)
 
 	gcpPeeringListCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
 	_ = gcpPeeringListCmd.MarkFlagRequired("cluster-id")
 
+	gcpPeeringPropertiesCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	_ = gcpPeeringPropertiesCmd.MarkFlagRequired("cluster-id")
+
 	gcpPeeringDeleteCmd.Flags().StringVar(&gcpPeeringId, "peering-id", "", "id of the peering")
 	_ = gcpPeeringDeleteCmd.MarkFlagRequired("peering-id")
+	gcpPeeringDeleteCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	gcpPeeringDeleteCmd.Flags().Bool("yes", false, "do not ask for confirmation")
+	gcpPeeringDeleteCmd.Flags().BoolVar(&gcpHazelcastOnly, "hazelcast-only", false, "only delete the peering from the cluster, keeping the network peering in your project")
+	addGcpCredentialFlags(gcpPeeringDeleteCmd)
 
 	gcpPeeringCreateCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
 	_ = gcpPeeringCreateCmd.MarkFlagRequired("cluster-id")
 
 	gcpPeeringCreateCmd.Flags().StringVar(&gcpNetworkName, "network-name", "", "name of the gcp network")
-	_ = gcpPeeringCreateCmd.MarkFlagRequired("network-name")
 
-	gcpPeeringCreateCmd.Flags().StringVar(&gcpProjectId, "project-id", "", "id of the gcp project")
-	_ = gcpPeeringCreateCmd.MarkFlagRequired("project-id")
+	gcpPeeringCreateCmd.Flags().StringVar(&gcpProjectId, "project-id", "", "id of the gcp project, a service project of --host-project-id when using shared vpc")
+
+	gcpPeeringCreateCmd.Flags().StringVar(&gcpHostProjectId, "host-project-id", "", "id of the shared vpc host project owning the network")
+
+	gcpPeeringCreateCmd.Flags().BoolVar(&gcpExportCustomRoutes, "export-custom-routes", false, "export custom routes of your network to the hazelcast network")
+	gcpPeeringCreateCmd.Flags().BoolVar(&gcpImportCustomRoutes, "import-custom-routes", false, "import custom routes of the hazelcast network to your network")
+	addGcpCredentialFlags(gcpPeeringCreateCmd)
+	addPeeringTargetFlags(gcpPeeringCreateCmd, "project-id=2,network-name=3,host-project-id=4")
+	addPeeringEmitFlag(gcpPeeringCreateCmd, service.EmitTerraform, service.EmitGcloudScript)
+
+	gcpPeeringAcceptCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	_ = gcpPeeringAcceptCmd.MarkFlagRequired("cluster-id")
+	gcpPeeringAcceptCmd.Flags().StringVar(&gcpProjectId, "project-id", "", "id of the gcp project, a service project of --host-project-id when using shared vpc")
+	_ = gcpPeeringAcceptCmd.MarkFlagRequired("project-id")
+	gcpPeeringAcceptCmd.Flags().StringVar(&gcpHostProjectId, "host-project-id", "", "id of the shared vpc host project owning the network")
+	gcpPeeringAcceptCmd.Flags().StringVar(&gcpNetworkName, "network-name", "", "name of the gcp network")
+	_ = gcpPeeringAcceptCmd.MarkFlagRequired("network-name")
+
+	gcpPeeringDiagnoseCmd.Flags().StringVar(&enterpriseClusterId, "cluster-id", "", "id of the cluster")
+	_ = gcpPeeringDiagnoseCmd.MarkFlagRequired("cluster-id")
+	addGcpCredentialFlags(gcpPeeringDiagnoseCmd)
 
 }
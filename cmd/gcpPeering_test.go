@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+func TestGcpPeering(t *testing.T) {
+	server := newFakeApi(t)
+	clusterId := addEnterpriseCluster(server, "gcp", "us-central1")
+	peeringId := server.AddGcpPeering(fakeapi.GcpPeering{ClusterId: clusterId, ProjectId: "my-project", NetworkName: "my-network"})
+
+	var properties models.GcpPeeringProperties
+	executeJsonCommand(t, &properties, "gcp-peering", "properties", "--cluster-id="+clusterId)
+	if properties.NetworkName != "hazelcast-network-"+clusterId {
+		t.Errorf("properties printed %+v", properties)
+	}
+	var peerings []models.GcpPeering
+	executeJsonCommand(t, &peerings, "gcp-peering", "list", "--cluster-id="+clusterId)
+	if len(peerings) != 1 || peerings[0].Id != peeringId || peerings[0].NetworkName != "my-network" {
+		t.Errorf("list printed %+v", peerings)
+	}
+	executeCommand(t, "gcp-peering", "delete", "--cluster-id="+clusterId, "--peering-id="+peeringId, "--hazelcast-only", "--yes")
+	if peerings := server.GcpPeerings(clusterId); len(peerings) != 0 {
+		t.Errorf("deleted peering is still there %+v", peerings)
+	}
+	executeCommand(t, "gcp-peering", "accept", "--cluster-id="+clusterId, "--project-id=my-service-project",
+		"--host-project-id=my-project", "--network-name=my-network")
+	if peerings := server.GcpPeerings(clusterId); len(peerings) != 1 || peerings[0].ProjectId != "my-project" {
+		t.Errorf("accepted peerings are %+v", peerings)
+	}
+}
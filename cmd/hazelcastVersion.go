@@ -23,7 +23,7 @@ var hazelcastVersionListCmd = &cobra.Command{
 	Example: "hzcloud hazelcast-version list",
 	Args:    cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		versions := internal.Validate(client.HazelcastVersion.List(context.Background())).(*[]models.EnterpriseHazelcastVersion)
 		header := table.Row{"#", "Version", "Upgradeable Versions"}
 		rows := []table.Row{}
@@ -23,7 +23,7 @@ var instanceTypeListCmd = &cobra.Command{
 	Short:   "This command lists instance types that Hazelcast Enterprise supports.",
 	Example: "hzcloud instance-type list",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		instanceTypes := internal.Validate(client.InstanceType.List(context.Background(), &models.InstanceTypeInput{
 			CloudProvider: instanceTypeCloudProvider,
 		})).(*[]models.InstanceType)
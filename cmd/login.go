@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/hazelcast/hazelcast-cloud-cli/internal"
@@ -16,14 +17,11 @@ var loginCmd = &cobra.Command{
 	Aliases: []string{"login"},
 	Short:   "This command logins you to Hazelcast Cloud with api-key and api-secret.",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		stdin := bufio.NewReader(os.Stdin)
 		fmt.Print("API Key: ")
-		apiKey, _ := term.ReadPassword(int(os.Stdin.Fd()))
-		fmt.Printf("\r\033[K")
+		apiKeyString := readSecret(stdin)
 		fmt.Print("API Secret: ")
-		apiSecret, _ := term.ReadPassword(int(os.Stdin.Fd()))
-		fmt.Printf("\r\033[K")
-		apiKeyString := strings.TrimSpace(string(apiKey))
-		apiSecretString := strings.TrimSpace(string(apiSecret))
+		apiSecretString := readSecret(stdin)
 
 		loginResult, response, loginErr := internal.Login(apiKeyString, apiSecretString)
 		internal.Validate(loginResult, response, loginErr)
@@ -37,6 +35,19 @@ var loginCmd = &cobra.Command{
 	},
 }
 
+// readSecret reads a line without echoing it, or reads it as is when stdin is not a terminal so the credentials can be
+// piped.
+func readSecret(stdin *bufio.Reader) string {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, _ := stdin.ReadString('\n')
+		fmt.Println()
+		return strings.TrimSpace(line)
+	}
+	secret, _ := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Printf("\r\033[K")
+	return strings.TrimSpace(string(secret))
+}
+
 func init() {
 	rootCmd.AddCommand(loginCmd)
 }
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+)
+
+func TestLogin(t *testing.T) {
+	server := newFakeApi(t)
+	apiUrl := os.Getenv("HZ_CLOUD_API_URL")
+	_ = os.Setenv("HZ_CLOUD_API_URL", server.URL)
+	defer func() { _ = os.Setenv("HZ_CLOUD_API_URL", apiUrl) }()
+
+	reader, writer, pipeErr := os.Pipe()
+	if pipeErr != nil {
+		t.Fatal(pipeErr)
+	}
+	_, _ = writer.WriteString(fakeapi.ApiKey + "\n" + fakeapi.ApiSecret + "\n")
+	_ = writer.Close()
+	stdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = stdin }()
+
+	out := executeCommand(t, "login")
+
+	if !strings.Contains(out, "You have successfully logged into Hazelcast Cloud.") {
+		t.Errorf("login printed %q", out)
+	}
+	configService := internal.NewConfigService()
+	if configService.Get(internal.ApiKey) != fakeapi.ApiKey || configService.Get(internal.ApiSecret) != fakeapi.ApiSecret {
+		t.Errorf("login saved %q and %q", configService.Get(internal.ApiKey), configService.Get(internal.ApiSecret))
+	}
+}
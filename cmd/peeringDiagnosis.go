@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/fatih/color"
+	"github.com/hazelcast/hazelcast-cloud-cli/service"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+func printPeeringChecks(checks []service.PeeringCheck) {
+	header := table.Row{"#", "Check", "Status", "Details", "Remediation"}
+	rows := []table.Row{}
+	failedCount := 0
+	for k, check := range checks {
+		status := "PASS"
+		if !check.IsPassed {
+			status = "FAIL"
+			failedCount++
+		}
+		rows = append(rows, table.Row{k + 1, check.Name, status, check.Details, check.Remediation})
+	}
+	util.Print(util.PrintRequest{
+		Data:       checks,
+		Header:     header,
+		Rows:       rows,
+		PrintStyle: util.PrintStyle(outputStyle),
+	})
+	if failedCount != 0 {
+		color.Red("%d of %d checks failed.", failedCount, len(checks))
+	} else {
+		color.Green("All %d checks passed.", len(checks))
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/hazelcast/hazelcast-cloud-cli/service"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var peeringEmitFormat string
+
+func addPeeringEmitFlag(cmd *cobra.Command, formats ...string) {
+	cmd.Flags().StringVar(&peeringEmitFormat, "emit", "", fmt.Sprintf("print the %s of your side of the peering instead of creating it", strings.Join(formats, " or ")))
+}
+
+// emitPeeringTemplate prints the template of your side of the peering returned by emit. It returns false when
+// --emit is not set, so the peering is created instead.
+func emitPeeringTemplate(emit func(format string) (string, error)) bool {
+	if peeringEmitFormat == "" {
+		return false
+	}
+	if !containsString(service.EmitFormats, peeringEmitFormat) {
+		color.Red("An error occurred. --emit must be one of %s.", strings.Join(service.EmitFormats, ", "))
+		return true
+	}
+	peeringTemplate, emitErr := emit(peeringEmitFormat)
+	if emitErr != nil {
+		color.Red("An error occurred. %s", emitErr)
+		return true
+	}
+	fmt.Print(peeringTemplate)
+	return true
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+var peeringTargets []string
+var peeringTargetsFile string
+var peeringParallelism int
+var peeringContinueOnError bool
+
+// peeringTarget holds the values of one target network of a peering create command, keyed by the flag names of the
+// command, e.g. "vpc-id".
+type peeringTarget map[string][]string
+
+type peeringTargetResult struct {
+	Target string `json:"target"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (t peeringTarget) value(key string, defaultValue string) string {
+	if values, ok := t[key]; ok && len(values) != 0 {
+		return values[0]
+	}
+	return defaultValue
+}
+
+func (t peeringTarget) values(key string, defaultValues []string) []string {
+	if values, ok := t[key]; ok {
+		return values
+	}
+	return defaultValues
+}
+
+func addPeeringTargetFlags(cmd *cobra.Command, example string) {
+	cmd.Flags().StringArrayVar(&peeringTargets, "target", []string{}, fmt.Sprintf("target network as comma separated key=value pairs with space separated lists, e.g. \"%s\", can be repeated", example))
+	cmd.Flags().StringVar(&peeringTargetsFile, "targets-file", "", "json file with an array of target networks, e.g. [{\"key\": \"value\", \"list-key\": [\"value\"]}]")
+	cmd.Flags().IntVar(&peeringParallelism, "parallelism", 4, "maximum number of peerings created at the same time with --target or --targets-file")
+	cmd.Flags().BoolVar(&peeringContinueOnError, "continue-on-error", false, "keep creating the peerings of the remaining targets after one fails")
+}
+
+func hasPeeringTargets() bool {
+	return len(peeringTargets) != 0 || peeringTargetsFile != ""
+}
+
+// readPeeringTargets parses the --target flags and the --targets-file. Every target must have the required keys and
+// only the allowed ones.
+func readPeeringTargets(requiredKeys []string, optionalKeys []string) ([]peeringTarget, error) {
+	if peeringEmitFormat != "" {
+		return nil, fmt.Errorf("--emit can not be used with --target or --targets-file")
+	}
+	var targets []peeringTarget
+	for _, flag := range peeringTargets {
+		target := peeringTarget{}
+		for _, pair := range strings.Split(flag, ",") {
+			keyValue := strings.SplitN(pair, "=", 2)
+			if len(keyValue) != 2 {
+				return nil, fmt.Errorf("target %s must be comma separated key=value pairs", flag)
+			}
+			target[strings.TrimSpace(keyValue[0])] = strings.Fields(keyValue[1])
+		}
+		targets = append(targets, target)
+	}
+	if peeringTargetsFile != "" {
+		content, readErr := ioutil.ReadFile(peeringTargetsFile)
+		if readErr != nil {
+			return nil, readErr
+		}
+		var fileTargets []map[string]interface{}
+		if unmarshalErr := json.Unmarshal(content, &fileTargets); unmarshalErr != nil {
+			return nil, fmt.Errorf("%s must contain a json array of objects. %s", peeringTargetsFile, unmarshalErr)
+		}
+		for _, fileTarget := range fileTargets {
+			target := peeringTarget{}
+			for key, value := range fileTarget {
+				switch typedValue := value.(type) {
+				case string:
+					target[key] = []string{typedValue}
+				case []interface{}:
+					for _, item := range typedValue {
+						target[key] = append(target[key], fmt.Sprint(item))
+					}
+				default:
+					return nil, fmt.Errorf("value of %s in %s must be a string or an array of strings", key, peeringTargetsFile)
+				}
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	allowedKeys := append(append([]string{}, requiredKeys...), optionalKeys...)
+	for i, target := range targets {
+		for key := range target {
+			if !containsString(allowedKeys, key) {
+				return nil, fmt.Errorf("target %d has unknown key %s, allowed keys are %s", i+1, key, strings.Join(allowedKeys, ", "))
+			}
+		}
+		for _, key := range requiredKeys {
+			if len(target[key]) == 0 {
+				return nil, fmt.Errorf("target %d has no %s", i+1, key)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// runPeeringTargets creates the peerings of the targets concurrently with --parallelism and prints a result per
+// target. Each create gets its own loading indicator, the overall progress is shown instead.
+func runPeeringTargets(message string, labels []string, create func(index int, indicator *util.LoadingIndicator) error) {
+	indicator := util.NewLoadingIndicator(message, len(labels))
+	indicator.Start()
+	var mutex sync.Mutex
+	doneCount := 0
+	errs := util.RunConcurrently(len(labels), peeringParallelism, peeringContinueOnError, func(index int) error {
+		err := create(index, util.NewLoadingIndicator(message, 100))
+		mutex.Lock()
+		doneCount++
+		indicator.SetStep(fmt.Sprintf("%d of %d peerings done...", doneCount, len(labels)), doneCount)
+		mutex.Unlock()
+		return err
+	})
+	indicator.Stop()
+	printPeeringTargetResults(labels, errs)
+}
+
+func printPeeringTargetResults(labels []string, errs []error) {
+	header := table.Row{"#", "Target", "Status", "Error"}
+	rows := []table.Row{}
+	results := []peeringTargetResult{}
+	failedCount := 0
+	for k, label := range labels {
+		result := peeringTargetResult{Target: label, Status: "ESTABLISHED"}
+		if errs[k] == util.ErrSkipped {
+			result.Status = "SKIPPED"
+			result.Error = errs[k].Error()
+		} else if errs[k] != nil {
+			result.Status = "FAILED"
+			result.Error = errs[k].Error()
+			failedCount++
+		}
+		results = append(results, result)
+		rows = append(rows, table.Row{k + 1, result.Target, result.Status, result.Error})
+	}
+	util.Print(util.PrintRequest{
+		Data:       results,
+		Header:     header,
+		Rows:       rows,
+		PrintStyle: util.PrintStyle(outputStyle),
+	})
+	if failedCount != 0 {
+		color.Red("%d of %d peerings failed.", failedCount, len(labels))
+		exit(1)
+	} else {
+		color.Green("All %d peerings successfully established.", len(labels))
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
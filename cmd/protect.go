@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+var protectClusterId string
+var unprotectClusterId string
+
+var protectCmd = &cobra.Command{
+	Use:     "protect",
+	Short:   "This command protects a cluster from being deleted by this CLI, lists the protected clusters without --cluster-id.",
+	Example: "hzcloud protect --cluster-id=1",
+	Run: func(cmd *cobra.Command, args []string) {
+		protectionService := internal.NewProtectionService()
+		if protectClusterId == "" {
+			header := table.Row{"Cluster Id"}
+			rows := []table.Row{}
+			clusterIds := protectionService.List()
+			for _, clusterId := range clusterIds {
+				rows = append(rows, table.Row{clusterId})
+			}
+			util.Print(util.PrintRequest{
+				Header:     header,
+				Rows:       rows,
+				Data:       clusterIds,
+				PrintStyle: util.PrintStyle(outputStyle),
+			})
+			return
+		}
+		if _, findErr := findCluster(newClient(), protectClusterId); findErr != nil {
+			color.Red("An error occurred. %s", findErr)
+			exit(1)
+		}
+		protectionService.Protect(protectClusterId)
+		color.Green("Cluster %s is protected from deletion.", protectClusterId)
+	},
+}
+
+var unprotectCmd = &cobra.Command{
+	Use:     "unprotect",
+	Short:   "This command removes the deletion protection of a cluster.",
+	Example: "hzcloud unprotect --cluster-id=1",
+	Run: func(cmd *cobra.Command, args []string) {
+		internal.NewProtectionService().Unprotect(unprotectClusterId)
+		color.Green("Cluster %s is not protected from deletion any more.", unprotectClusterId)
+	},
+}
+
+// findCluster gets the cluster with the id from the starter, serverless and enterprise clusters in turn, so that only
+// existing clusters are protected.
+func findCluster(client *hazelcastcloud.Client, clusterId string) (*models.Cluster, error) {
+	var getErrs []string
+	for _, product := range clusterProducts {
+		cluster, _, getErr := product.get(client, clusterId)
+		if getErr == nil {
+			return cluster, nil
+		}
+		getErrs = append(getErrs, fmt.Sprintf("%s: %s", product.name, getErr))
+	}
+	return nil, fmt.Errorf("cluster %s is not found. %s", clusterId, strings.Join(getErrs, ", "))
+}
+
+// checkClusterProtection exits when the cluster is protected from deletion, unless forceUnprotect is given.
+func checkClusterProtection(clusterId string, forceUnprotect bool) {
+	if internal.NewProtectionService().IsProtected(clusterId) && !forceUnprotect {
+		color.Red("An error occurred. Cluster %s is protected from deletion, use --force-unprotect to delete it anyway.", clusterId)
+		exit(1)
+	}
+}
+
+// unprotectDeletedCluster removes the protection of a deleted cluster, so it does not protect an id which is not used
+// any more.
+func unprotectDeletedCluster(clusterId string) {
+	protectionService := internal.NewProtectionService()
+	if protectionService.IsProtected(clusterId) {
+		protectionService.Unprotect(clusterId)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(protectCmd)
+	protectCmd.Flags().StringVar(&protectClusterId, "cluster-id", "", "id of the cluster")
+
+	rootCmd.AddCommand(unprotectCmd)
+	unprotectCmd.Flags().StringVar(&unprotectClusterId, "cluster-id", "", "id of the cluster")
+	_ = unprotectCmd.MarkFlagRequired("cluster-id")
+}
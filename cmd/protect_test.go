@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+)
+
+func TestProtect(t *testing.T) {
+	server := newFakeApi(t)
+	clusterId := addEnterpriseCluster(server, "aws", "eu-west-2")
+
+	executeCommand(t, "protect", "--cluster-id="+clusterId)
+	var clusterIds []string
+	executeJsonCommand(t, &clusterIds, "protect")
+	if len(clusterIds) != 1 || clusterIds[0] != clusterId {
+		t.Errorf("protect printed %v", clusterIds)
+	}
+
+	out, exitCode := executeExitingCommand(t, "protect", "--cluster-id=missing")
+	if exitCode != 1 || !strings.Contains(out, "cluster missing is not found.") {
+		t.Errorf("protect of a missing cluster exited with %d and printed\n%s", exitCode, out)
+	}
+
+	executeCommand(t, "unprotect", "--cluster-id="+clusterId)
+	if internal.NewProtectionService().IsProtected(clusterId) {
+		t.Errorf("cluster %s is still protected", clusterId)
+	}
+}
+
+func TestProtectClusterDelete(t *testing.T) {
+	for _, product := range []string{"Starter", "Serverless", "Enterprise"} {
+		t.Run(product, func(t *testing.T) {
+			server := newFakeApi(t)
+			clusterId := server.AddCluster(fakeapi.Cluster{
+				Name:          "mycluster",
+				ProductType:   fakeapi.ProductType{Name: product},
+				State:         fakeapi.StateRunning,
+				CloudProvider: fakeapi.CloudProvider{Name: "aws", Region: "eu-west-2"},
+			})
+			command := strings.ToLower(product) + "-cluster"
+			executeCommand(t, "protect", "--cluster-id="+clusterId)
+
+			out, exitCode := executeExitingCommand(t, command, "delete", "--cluster-id="+clusterId, "--yes")
+			if exitCode != 1 || !strings.Contains(out, "Cluster "+clusterId+" is protected from deletion") {
+				t.Errorf("delete of a protected cluster exited with %d and printed\n%s", exitCode, out)
+			}
+			if _, ok := server.Cluster(clusterId); !ok {
+				t.Fatal("protected cluster is deleted")
+			}
+
+			executeCommand(t, command, "delete", "--cluster-id="+clusterId, "--yes", "--force-unprotect")
+			if _, ok := server.Cluster(clusterId); ok {
+				t.Error("delete with --force-unprotect did not delete the cluster")
+			}
+			if internal.NewProtectionService().IsProtected(clusterId) {
+				t.Error("deleted cluster is still protected")
+			}
+		})
+	}
+}
+
+func TestProtectBulkClusterDelete(t *testing.T) {
+	server := newFakeApi(t)
+	ids := addBulkTestClusters(server, map[string][2]string{
+		"ci-protected": {"Starter", fakeapi.StateRunning},
+		"ci-other":     {"Serverless", fakeapi.StateRunning},
+	})
+	executeCommand(t, "protect", "--cluster-id="+ids["ci-protected"])
+
+	out := executeCommand(t, "cluster", "delete", "--selector=name=ci-*", "--yes")
+	if !strings.Contains(out, "Cluster "+ids["ci-protected"]+" is protected, use --force-unprotect to delete it.") ||
+		!strings.Contains(out, "All 1 clusters are deleted.") {
+		t.Errorf("delete printed\n%s", out)
+	}
+	if _, ok := server.Cluster(ids["ci-protected"]); !ok {
+		t.Fatal("protected cluster is deleted")
+	}
+	if _, ok := server.Cluster(ids["ci-other"]); ok {
+		t.Error("unprotected cluster is not deleted")
+	}
+
+	executeCommand(t, "cluster", "delete", "--selector=name=ci-*", "--yes", "--force-unprotect")
+	if _, ok := server.Cluster(ids["ci-protected"]); ok {
+		t.Error("delete with --force-unprotect did not delete the protected cluster")
+	}
+	if internal.NewProtectionService().IsProtected(ids["ci-protected"]) {
+		t.Error("deleted cluster is still protected")
+	}
+}
+
+func TestConfirmWithoutTerminal(t *testing.T) {
+	expectedLookedUp := false
+	getExpected := func() (string, error) {
+		expectedLookedUp = true
+		return "mycluster", nil
+	}
+
+	err := confirm(false, "Cluster 1 will be deleted.", getExpected)
+	if err == nil || err.Error() != "confirmation is needed, use --yes to run without a terminal" {
+		t.Errorf("confirm without a terminal returned %v", err)
+	}
+	if err := confirm(true, "Cluster 1 will be deleted.", getExpected); err != nil {
+		t.Errorf("confirm with yes returned %v", err)
+	}
+	if expectedLookedUp {
+		t.Error("confirmation text is looked up without asking")
+	}
+}
@@ -23,7 +23,7 @@ var regionListCmd = &cobra.Command{
 	Short:   "This command lists available regions for Hazelcast Enterprise on selected cloud provider.",
 	Example: "hzcloud region list --cloud-provider=azure",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		regions := internal.Validate(client.Region.List(context.Background(), &models.RegionInput{
 			CloudProvider: regionCloudProvider,
 		})).(*[]models.Region)
@@ -2,12 +2,21 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
 	"github.com/spf13/cobra"
 	"os"
 )
 
 var outputStyle string
 
+type ClientFactory func() *hazelcastcloud.Client
+
+var newClient ClientFactory = internal.NewClient
+
+// exit ends a command which failed after printing its error, tests replace it.
+var exit = os.Exit
+
 var rootCmd = &cobra.Command{
 	Use:   "hzcloud",
 	Short: "hzcloud is a command line interface (CLI) for the Hazelcast Cloud API.",
@@ -20,6 +29,11 @@ func Execute() {
 	}
 }
 
+// SetClientFactory replaces the way commands obtain a Hazelcast Cloud client, e.g. to run them against a fake API.
+func SetClientFactory(clientFactory ClientFactory) {
+	newClient = clientFactory
+}
+
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&outputStyle, "output", "o", "default", "--output json")
 }
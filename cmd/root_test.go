@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// newFakeApi starts a fake api the commands are run against, with a temporary home directory for the config.
+func newFakeApi(t *testing.T) *fakeapi.Server {
+	t.Helper()
+	server := fakeapi.NewServer()
+	SetClientFactory(server.ClientFactory())
+	home := os.Getenv("HOME")
+	if err := os.Setenv("HOME", t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Setenv("HOME", home)
+		SetClientFactory(internal.NewClient)
+		server.Close()
+	})
+	return server
+}
+
+// executeCommand runs the command with the args and returns what it printed. The flags of all commands are reset to
+// their defaults before each run.
+func executeCommand(t *testing.T, args ...string) string {
+	t.Helper()
+	out, exitCode := executeExitingCommand(t, args...)
+	if exitCode != 0 {
+		t.Fatalf("%v exited with %d.\n%s", args, exitCode, out)
+	}
+	return out
+}
+
+// exitPanic stops a command where it calls exit in executeExitingCommand.
+type exitPanic int
+
+// executeExitingCommand runs the command like executeCommand, and returns the code it exited with, 0 when it did not
+// call exit.
+func executeExitingCommand(t *testing.T, args ...string) (out string, exitCode int) {
+	t.Helper()
+	reader, writer, pipeErr := os.Pipe()
+	if pipeErr != nil {
+		t.Fatal(pipeErr)
+	}
+	stdout, colorOutput := os.Stdout, color.Output
+	os.Stdout, color.Output = writer, writer
+	exit = func(code int) { panic(exitPanic(code)) }
+	output := make(chan string)
+	go func() {
+		out, _ := ioutil.ReadAll(reader)
+		output <- string(out)
+	}()
+	var executeErr error
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				code, ok := recovered.(exitPanic)
+				if !ok {
+					panic(recovered)
+				}
+				exitCode = int(code)
+			}
+		}()
+		resetFlags(rootCmd)
+		rootCmd.SetArgs(args)
+		executeErr = rootCmd.Execute()
+	}()
+	_ = writer.Close()
+	os.Stdout, color.Output = stdout, colorOutput
+	exit = os.Exit
+	out = <-output
+	if executeErr != nil {
+		t.Fatalf("%v failed. %s\n%s", args, executeErr, out)
+	}
+	return out, exitCode
+}
+
+// executeJsonCommand runs the command with json output and decodes what it printed into v.
+func executeJsonCommand(t *testing.T, v interface{}, args ...string) {
+	t.Helper()
+	out := executeCommand(t, append(args, "--output=json")...)
+	if err := json.Unmarshal([]byte(out), v); err != nil {
+		t.Fatalf("%v did not print json. %s\n%s", args, err, out)
+	}
+}
+
+// resetFlags sets the flags of the command and its subcommands back to their defaults, as cobra keeps the values of a
+// previous run and appends to the values of slice flags.
+func resetFlags(command *cobra.Command) {
+	for _, flags := range []*pflag.FlagSet{command.Flags(), command.PersistentFlags()} {
+		flags.VisitAll(func(flag *pflag.Flag) {
+			if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+				_ = sliceValue.Replace(nil)
+			} else {
+				_ = flag.Value.Set(flag.DefValue)
+			}
+			flag.Changed = false
+		})
+	}
+	for _, subcommand := range command.Commands() {
+		resetFlags(subcommand)
+	}
+}
@@ -2,18 +2,14 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"github.com/fatih/color"
 	"github.com/hazelcast/hazelcast-cloud-cli/internal"
 	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
 	"github.com/jedib0t/go-pretty/v6/table"
-	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/cobra"
-	"io"
-	"io/ioutil"
-	"net/http"
-	"os"
-	"path/filepath"
 )
 
 func newServerlessClusterCmd() *cobra.Command {
@@ -38,7 +34,7 @@ func newServerlessClusterCreateCmd() *cobra.Command {
 			} else {
 				createClusterInputParams.ClusterType = models.Serverless
 			}
-			client := internal.NewClient()
+			client := newClient()
 			cluster := internal.Validate(client.ServerlessCluster.Create(context.Background(),
 				&createClusterInputParams)).(*models.Cluster)
 			color.Green("Cluster %s is creating. You can check the status using hzcloud serverless-cluster list.",
@@ -65,7 +61,7 @@ func newServerlessClusterListCmd() *cobra.Command {
 		Short:   "This command allows you to create a serverless Hazelcast cluster.",
 		Example: "hzcloud serverless-cluster list",
 		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
+			client := newClient()
 			clusters := internal.Validate(client.ServerlessCluster.List(context.Background())).(*[]models.Cluster)
 			header := table.Row{"Id", "Name", "Type", "State", "Version", "Memory (GiB)", "Cloud Provider", "Region"}
 			var rows []table.Row
@@ -94,7 +90,7 @@ func newServerlessClusterGetCmd() *cobra.Command {
 		Short:   "This command get detailed configuration of a serverless Hazelcast cluster.",
 		Example: "hzcloud serverless-cluster get --cluster-id=100",
 		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
+			client := newClient()
 			cluster := internal.Validate(client.ServerlessCluster.Get(context.Background(),
 				&models.GetServerlessClusterInput{
 					ClusterId: serverlessClusterId,
@@ -114,22 +110,34 @@ func newServerlessClusterGetCmd() *cobra.Command {
 
 func newServerlessClusterDeleteCmd() *cobra.Command {
 	var serverlessClusterId string
+	var yes bool
+	var forceUnprotect bool
 
 	serverlessClusterDeleteCmd := &cobra.Command{
 		Use:     "delete",
 		Short:   "This command allows you to delete a serverless Hazelcast cluster.",
 		Example: "hzcloud serverless-cluster delete --cluster-id=100",
 		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
+			checkClusterProtection(serverlessClusterId, forceUnprotect)
+			client := newClient()
+			confirmClusterOperation(yes, fmt.Sprintf("Cluster %s will be deleted.", serverlessClusterId),
+				clusterName(func() (*models.Cluster, *hazelcastcloud.Response, error) {
+					return client.ServerlessCluster.Get(context.Background(), &models.GetServerlessClusterInput{
+						ClusterId: serverlessClusterId,
+					})
+				}))
 			clusterResponse := internal.Validate(client.ServerlessCluster.Delete(context.Background(),
 				&models.ClusterDeleteInput{
 					ClusterId: serverlessClusterId,
 				})).(*models.ClusterId)
+			unprotectDeletedCluster(serverlessClusterId)
 			color.Blue("Cluster %d deleted.", clusterResponse.ClusterId)
 		},
 	}
 
 	serverlessClusterDeleteCmd.Flags().StringVar(&serverlessClusterId, "cluster-id", "", "id of the cluster")
+	serverlessClusterDeleteCmd.Flags().BoolVar(&yes, "yes", false, "do not ask for confirmation")
+	serverlessClusterDeleteCmd.Flags().BoolVar(&forceUnprotect, "force-unprotect", false, "delete the cluster even if it is protected")
 	_ = serverlessClusterDeleteCmd.MarkFlagRequired("cluster-id")
 
 	return serverlessClusterDeleteCmd
@@ -143,7 +151,7 @@ func newServerlessClusterStopCmd() *cobra.Command {
 		Short:   "This command allows you to stop a serverless Hazelcast cluster.",
 		Example: "hzcloud serverless-cluster stop --cluster-id=100",
 		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
+			client := newClient()
 			clusterResponse := internal.Validate(client.ServerlessCluster.Stop(context.Background(),
 				&models.ClusterStopInput{
 					ClusterId: serverlessClusterId,
@@ -166,7 +174,7 @@ func newServerlessClusterResumeCmd() *cobra.Command {
 		Short:   "This command allows you to resume a serverless Hazelcast cluster.",
 		Example: "hzcloud serverless-cluster resume --cluster-id=100",
 		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
+			client := newClient()
 			clusterResponse := internal.Validate(client.ServerlessCluster.Resume(context.Background(),
 				&models.ClusterResumeInput{
 					ClusterId: serverlessClusterId,
@@ -181,199 +189,6 @@ func newServerlessClusterResumeCmd() *cobra.Command {
 	return serverlessClusterResumeCmd
 }
 
-func newServerlessCustomClassesCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:     "custom-classes",
-		Aliases: []string{"classes"},
-		Short:   "This command allows you to manage custom classes on your serverless cluster like: list, upload, delete.",
-	}
-}
-
-func newServerlessCustomClassesListCmd() *cobra.Command {
-	var clusterId string
-
-	serverlessCustomClassesListCmd := cobra.Command{
-		Use:     "list",
-		Short:   "This command lists Artifacts that contains Custom Classes uploaded to Hazelcast Instance.",
-		Example: "hzcloud serverless-cluster custom-classes list",
-		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
-			artifacts := internal.Validate(client.ServerlessCluster.ListUploadedArtifacts(context.Background(),
-				&models.ListUploadedArtifactsInput{
-					ClusterId: clusterId,
-				})).(*[]models.UploadedArtifact)
-			header := table.Row{"Id", "File Name", "Status"}
-			rows := []table.Row{}
-			for _, artifact := range *artifacts {
-				rows = append(rows, table.Row{artifact.Id, artifact.Name, artifact.Status})
-			}
-			util.Print(util.PrintRequest{
-				Header:     header,
-				Rows:       rows,
-				Data:       artifacts,
-				PrintStyle: util.PrintStyle(outputStyle),
-			})
-		},
-	}
-
-	serverlessCustomClassesListCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
-	_ = serverlessCustomClassesListCmd.MarkFlagRequired("cluster-id")
-
-	return &serverlessCustomClassesListCmd
-}
-
-func newServerlessClusterCustomClassesUploadCmd() *cobra.Command {
-	var clusterId string
-	var customClassesFileName string
-
-	serverlessClusterCustomClassesUploadCmd := cobra.Command{
-		Use:     "upload",
-		Short:   "This command uploads Artifact with custom classes to Hazelcast Instance.",
-		Example: "hzcloud serverless-cluster custom-classes upload",
-		Run: func(cmd *cobra.Command, args []string) {
-			file, err := os.Open(customClassesFileName)
-			if err != nil {
-				color.Red(err.Error())
-				os.Exit(1)
-			}
-			defer file.Close()
-
-			stat, err := file.Stat()
-			if err != nil {
-				color.Red(err.Error())
-				os.Exit(1)
-			}
-			reader := progressbar.NewReader(file, progressbar.DefaultBytes(stat.Size(), "uploading "+file.Name()))
-			client := internal.NewClient()
-			artifact := internal.Validate(client.ServerlessCluster.UploadArtifact(context.Background(),
-				&models.UploadArtifactInput{
-					ClusterId: clusterId,
-					FileName:  filepath.Base(file.Name()),
-					Content:   &reader,
-				})).(*models.UploadedArtifact)
-
-			header := table.Row{"Id", "File Name", "Status"}
-			rows := []table.Row{{artifact.Id, artifact.Name, artifact.Status}}
-			util.Print(util.PrintRequest{
-				Header:     header,
-				Rows:       rows,
-				Data:       artifact,
-				PrintStyle: util.PrintStyle(outputStyle),
-			})
-		},
-	}
-
-	serverlessClusterCustomClassesUploadCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
-	serverlessClusterCustomClassesUploadCmd.Flags().StringVar(&customClassesFileName, "file-name", "", "File to upload")
-	_ = serverlessClusterCustomClassesUploadCmd.MarkFlagRequired("cluster-id")
-	_ = serverlessClusterCustomClassesUploadCmd.MarkFlagRequired("file-name")
-
-	return &serverlessClusterCustomClassesUploadCmd
-}
-
-func newServerlessClusterCustomClassesDeleteCmd() *cobra.Command {
-	var clusterId string
-	var customClassesId string
-
-	serverlessClusterCustomClassesDeleteCmd := cobra.Command{
-		Use:     "delete",
-		Short:   "This command deletes Artifact with custom classes that was uploaded to Hazelcast Instance.",
-		Example: "hzcloud serverless-cluster custom-classes delete",
-		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
-			artifact := internal.Validate(client.ServerlessCluster.DeleteArtifact(context.Background(),
-				&models.DeleteArtifactInput{
-					ClusterId:       clusterId,
-					CustomClassesId: customClassesId,
-				})).(*models.UploadedArtifact)
-
-			header := table.Row{"Id", "File Name", "Status"}
-			rows := []table.Row{{artifact.Id, artifact.Name, artifact.Status}}
-			util.Print(util.PrintRequest{
-				Header:     header,
-				Rows:       rows,
-				Data:       artifact,
-				PrintStyle: util.PrintStyle(outputStyle),
-			})
-		},
-	}
-
-	serverlessClusterCustomClassesDeleteCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
-	serverlessClusterCustomClassesDeleteCmd.Flags().StringVar(&customClassesId, "file-id", "",
-		"id of the Uploaded Artifact")
-	_ = serverlessClusterCustomClassesDeleteCmd.MarkFlagRequired("cluster-id")
-	_ = serverlessClusterCustomClassesDeleteCmd.MarkFlagRequired("file-id")
-
-	return &serverlessClusterCustomClassesDeleteCmd
-}
-
-func newServerlessClusterCustomClassesDownloadCmd() *cobra.Command {
-	var clusterId string
-	var customClassesId string
-
-	serverlessClusterCustomClassesDownloadCmd := cobra.Command{
-		Use:     "download",
-		Short:   "This command downloads an artifact with custom classes that was uploaded to Hazelcast Instance.",
-		Example: "hzcloud serverless-cluster custom-classes download",
-		Run: func(cmd *cobra.Command, args []string) {
-			client := internal.NewClient()
-			artifact := internal.Validate(client.ServerlessCluster.DownloadArtifact(context.Background(),
-				&models.DownloadArtifactInput{
-					ClusterId:       clusterId,
-					CustomClassesId: customClassesId,
-				})).(*models.UploadedArtifactLink)
-
-			header := table.Row{"Id", "File Name"}
-			rows := []table.Row{{artifact.Id, artifact.Name}}
-			util.Print(util.PrintRequest{
-				Header:     header,
-				Rows:       rows,
-				Data:       artifact,
-				PrintStyle: util.PrintStyle(outputStyle),
-			})
-			httpClient := http.Client{}
-			request, requestErr := http.NewRequest("GET", artifact.Url, nil)
-			if requestErr != nil {
-				color.Red(requestErr.Error())
-				os.Exit(1)
-			}
-			response, responseErr := httpClient.Do(request)
-			if responseErr != nil {
-				color.Red(responseErr.Error())
-				os.Exit(1)
-			}
-			tmpFile, tmpFileErr := ioutil.TempFile("", "*-"+artifact.Name)
-			if tmpFileErr != nil {
-				color.Red(tmpFileErr.Error())
-				os.Exit(1)
-			}
-			defer tmpFile.Close()
-			bar := progressbar.DefaultBytes(
-				response.ContentLength,
-				"downloading "+artifact.Name,
-			)
-			_, writeErr := io.Copy(io.MultiWriter(tmpFile, bar), response.Body)
-			if writeErr != nil {
-				color.Red(writeErr.Error())
-				os.Exit(1)
-			}
-			renameErr := os.Rename(tmpFile.Name(), artifact.Name)
-			if renameErr != nil {
-				color.Red(renameErr.Error())
-				os.Exit(1)
-			}
-		},
-	}
-
-	serverlessClusterCustomClassesDownloadCmd.Flags().StringVar(&clusterId, "cluster-id", "", "id of the cluster")
-	serverlessClusterCustomClassesDownloadCmd.Flags().StringVar(&customClassesId, "file-id", "",
-		"id of the Uploaded Artifact")
-	_ = serverlessClusterCustomClassesDownloadCmd.MarkFlagRequired("cluster-id")
-	_ = serverlessClusterCustomClassesDownloadCmd.MarkFlagRequired("file-id")
-
-	return &serverlessClusterCustomClassesDownloadCmd
-}
-
 func init() {
 	serverlessClusterCmd := newServerlessClusterCmd()
 	rootCmd.AddCommand(serverlessClusterCmd)
@@ -385,10 +200,5 @@ func init() {
 	serverlessClusterCmd.AddCommand(newServerlessClusterStopCmd())
 	serverlessClusterCmd.AddCommand(newServerlessClusterResumeCmd())
 
-	serverlessCustomClassesCmd := newServerlessCustomClassesCmd()
-	serverlessClusterCmd.AddCommand(serverlessCustomClassesCmd)
-	serverlessCustomClassesCmd.AddCommand(newServerlessCustomClassesListCmd())
-	serverlessCustomClassesCmd.AddCommand(newServerlessClusterCustomClassesUploadCmd())
-	serverlessCustomClassesCmd.AddCommand(newServerlessClusterCustomClassesDeleteCmd())
-	serverlessCustomClassesCmd.AddCommand(newServerlessClusterCustomClassesDownloadCmd())
+	serverlessClusterCmd.AddCommand(newCustomClassesCmd(serverlessCustomClassesProduct))
 }
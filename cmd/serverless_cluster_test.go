@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+func TestServerlessCluster(t *testing.T) {
+	server := newFakeApi(t)
+	server.TransitionSteps = 1
+
+	executeCommand(t, "serverless-cluster", "create", "--name=mycluster", "--region=us-east-1", "--dev-mode-enabled")
+	var clusters []models.Cluster
+	executeJsonCommand(t, &clusters, "serverless-cluster", "list")
+	if len(clusters) != 1 || clusters[0].Name != "mycluster" || clusters[0].ClusterType.Name != models.Devmode {
+		t.Fatalf("list printed %+v", clusters)
+	}
+	clusterId := clusters[0].Id
+
+	var cluster models.Cluster
+	executeJsonCommand(t, &cluster, "serverless-cluster", "get", "--cluster-id="+clusterId)
+	if cluster.Id != clusterId || cluster.State != models.Running {
+		t.Fatalf("get printed %+v", cluster)
+	}
+	executeCommand(t, "serverless-cluster", "stop", "--cluster-id="+clusterId)
+	if cluster, _ := server.Cluster(clusterId); cluster.State != fakeapi.StateStopped {
+		t.Fatalf("stopped cluster is %s", cluster.State)
+	}
+	executeCommand(t, "serverless-cluster", "resume", "--cluster-id="+clusterId)
+	if cluster, _ := server.Cluster(clusterId); cluster.State != fakeapi.StatePending {
+		t.Fatalf("resumed cluster is %s", cluster.State)
+	}
+	executeCommand(t, "serverless-cluster", "delete", "--cluster-id="+clusterId, "--yes")
+	if _, ok := server.Cluster(clusterId); ok {
+		t.Fatal("deleted cluster is still there")
+	}
+}
@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"github.com/fatih/color"
 	"github.com/hazelcast/hazelcast-cloud-cli/internal"
 	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
@@ -27,7 +29,7 @@ var starterClusterCreateCmd = &cobra.Command{
 	Short:   "This command creates Hazelcast instance with provided configurations.",
 	Example: "hzcloud starter-cluster create --cloud-provider=aws --cluster-type=FREE --name=mycluster --region=us-east-1 --total-memory=0.2 --hazelcast-version=4.0",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := internal.NewClient()
+		client := newClient()
 		clusterType, err := util.AugmentStarterClusterType(starterClusterCreateClusterType)
 		if err != nil {
 			return err
@@ -45,7 +47,7 @@ var starterClusterGetCmd = &cobra.Command{
 	Short:   "This command get detailed configuration of starter Hazelcast instance.",
 	Example: "hzcloud starter-cluster get --cluster-id=100",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		cluster := internal.Validate(client.StarterCluster.Get(context.Background(), &models.GetStarterClusterInput{
 			ClusterId: starterClusterId,
 		})).(*models.Cluster)
@@ -61,7 +63,7 @@ var starterClusterListCmd = &cobra.Command{
 	Short:   "This command lists Hazelcast Instances.",
 	Example: "hzcloud starter-cluster list",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		clusters := internal.Validate(client.StarterCluster.List(context.Background())).(*[]models.Cluster)
 		header := table.Row{"Id", "Name", "State", "Version", "Memory (GiB)", "Cloud Provider", "Region", "Is Free"}
 		rows := []table.Row{}
@@ -83,10 +85,20 @@ var starterClusterDeleteCmd = &cobra.Command{
 	Short:   "This command deletes Hazelcast Instance according to its id",
 	Example: "hzcloud starter-cluster delete --cluster-id=100",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		forceUnprotect, _ := cmd.Flags().GetBool("force-unprotect")
+		checkClusterProtection(starterClusterId, forceUnprotect)
+		client := newClient()
+		yes, _ := cmd.Flags().GetBool("yes")
+		confirmClusterOperation(yes, fmt.Sprintf("Cluster %s will be deleted.", starterClusterId),
+			clusterName(func() (*models.Cluster, *hazelcastcloud.Response, error) {
+				return client.StarterCluster.Get(context.Background(), &models.GetStarterClusterInput{
+					ClusterId: starterClusterId,
+				})
+			}))
 		clusterResponse := internal.Validate(client.StarterCluster.Delete(context.Background(), &models.ClusterDeleteInput{
 			ClusterId: starterClusterId,
 		})).(*models.ClusterId)
+		unprotectDeletedCluster(starterClusterId)
 		color.Blue("Cluster %d deleted.", clusterResponse.ClusterId)
 	},
 }
@@ -96,7 +108,7 @@ var starterClusterStopCmd = &cobra.Command{
 	Short:   "This command stops Hazelcast Instance according to its id",
 	Example: "hzcloud starter-cluster stop --cluster-id=100",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		clusterResponse := internal.Validate(client.StarterCluster.Stop(context.Background(), &models.ClusterStopInput{
 			ClusterId: starterClusterId,
 		})).(*models.ClusterId)
@@ -109,7 +121,7 @@ var starterClusterResumeCmd = &cobra.Command{
 	Short:   "This command resumes Hazelcast Instance according to its id",
 	Example: "hzcloud starter-cluster resume --cluster-id=100",
 	Run: func(cmd *cobra.Command, args []string) {
-		client := internal.NewClient()
+		client := newClient()
 		clusterResponse := internal.Validate(client.StarterCluster.Resume(context.Background(), &models.ClusterResumeInput{
 			ClusterId: starterClusterId,
 		})).(*models.ClusterId)
@@ -147,6 +159,8 @@ func init() {
 	starterClusterCmd.AddCommand(starterClusterDeleteCmd)
 	starterClusterDeleteCmd.Flags().StringVar(&starterClusterId, "cluster-id", "", "id of the cluster")
 	_ = starterClusterDeleteCmd.MarkFlagRequired("cluster-id")
+	starterClusterDeleteCmd.Flags().Bool("yes", false, "do not ask for confirmation")
+	starterClusterDeleteCmd.Flags().Bool("force-unprotect", false, "delete the cluster even if it is protected")
 
 	starterClusterCmd.AddCommand(starterClusterStopCmd)
 	starterClusterStopCmd.Flags().StringVar(&starterClusterId, "cluster-id", "", "id of the cluster")
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+func TestStarterCluster(t *testing.T) {
+	server := newFakeApi(t)
+	server.TransitionSteps = 1
+
+	out := executeCommand(t, "starter-cluster", "create", "--name=mycluster", "--cloud-provider=aws",
+		"--region=us-east-1", "--cluster-type=FREE", "--total-memory=0.2", "--hazelcast-version=4.2")
+	if !strings.Contains(out, "is creating") {
+		t.Fatalf("create printed %q", out)
+	}
+	var clusters []models.Cluster
+	executeJsonCommand(t, &clusters, "starter-cluster", "list")
+	if len(clusters) != 1 || clusters[0].Name != "mycluster" || clusters[0].CloudProvider.Region != "us-east-1" ||
+		clusters[0].HazelcastVersion != "4.2" {
+		t.Fatalf("list printed %+v", clusters)
+	}
+	clusterId := clusters[0].Id
+
+	var cluster models.Cluster
+	executeJsonCommand(t, &cluster, "starter-cluster", "get", "--cluster-id="+clusterId)
+	if cluster.Id != clusterId || cluster.State != models.Running {
+		t.Fatalf("get printed %+v", cluster)
+	}
+
+	executeCommand(t, "starter-cluster", "stop", "--cluster-id="+clusterId)
+	if cluster, _ := server.Cluster(clusterId); cluster.State != fakeapi.StateStopped {
+		t.Fatalf("stopped cluster is %s", cluster.State)
+	}
+	executeCommand(t, "starter-cluster", "resume", "--cluster-id="+clusterId)
+	if cluster, _ := server.Cluster(clusterId); cluster.State != fakeapi.StatePending {
+		t.Fatalf("resumed cluster is %s", cluster.State)
+	}
+	executeCommand(t, "starter-cluster", "delete", "--cluster-id="+clusterId, "--yes")
+	if _, ok := server.Cluster(clusterId); ok {
+		t.Fatal("deleted cluster is still there")
+	}
+}
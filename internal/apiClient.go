@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ApiClient calls the endpoints of the Hazelcast Cloud API which are not covered by the SDK. Requests go to the
+// scheme and host of the SDK client base url with its token and user agent. The http client of the SDK client is not
+// accessible, so requests are sent through HttpClient. Requests failing with a network error or a 429, 502, 503 or
+// 504 status are sent again up to Retries times, waiting RetryWait doubled on each attempt. Requests and responses
+// are logged to DebugOutput when it is set, which is stderr when HZ_CLOUD_DEBUG is set.
+type ApiClient struct {
+	client      *hazelcastcloud.Client
+	HttpClient  *http.Client
+	Retries     int
+	RetryWait   time.Duration
+	DebugOutput io.Writer
+}
+
+type AzurePeeringNotification struct {
+	ClusterId           string `json:"clusterId"`
+	PeeringConnectionId string `json:"peeringConnectionId"`
+	VnetId              string `json:"vpcId"`
+	VnetCidr            string `json:"vpcCidr"`
+}
+
+func NewApiClient(client *hazelcastcloud.Client) *ApiClient {
+	apiClient := &ApiClient{
+		client:     client,
+		HttpClient: http.DefaultClient,
+		Retries:    3,
+		RetryWait:  time.Second,
+	}
+	if len(strings.TrimSpace(os.Getenv("HZ_CLOUD_DEBUG"))) != 0 {
+		apiClient.DebugOutput = os.Stderr
+	}
+	return apiClient
+}
+
+// NotifyAzurePeering registers the vnet peerings created on both sides as a peering of the cluster.
+func (c *ApiClient) NotifyAzurePeering(ctx context.Context, notification *AzurePeeringNotification) (*models.Result, *hazelcastcloud.Response, error) {
+	var result models.Result
+	response, err := c.post(ctx, "/peerings", notification, &result)
+	if err != nil {
+		return nil, response, err
+	}
+	return &result, response, nil
+}
+
+func (c *ApiClient) post(ctx context.Context, path string, body interface{}, v interface{}) (*hazelcastcloud.Response, error) {
+	requestBody, marshalErr := json.Marshal(body)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+	endpoint := url.URL{Scheme: c.client.BaseURL.Scheme, Host: c.client.BaseURL.Host, Path: path}
+	httpResponse, responseBody, doErr := c.do(ctx, http.MethodPost, endpoint.String(), requestBody)
+	if doErr != nil {
+		return nil, doErr
+	}
+	response := &hazelcastcloud.Response{Response: httpResponse}
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+		return response, newErrorResponse(httpResponse, responseBody)
+	}
+	if v != nil && len(responseBody) != 0 {
+		if unmarshalErr := json.Unmarshal(responseBody, v); unmarshalErr != nil {
+			return response, unmarshalErr
+		}
+	}
+	return response, nil
+}
+
+// do sends the request until it does not fail with a retryable error or the retries run out, and returns the response
+// with its read body.
+func (c *ApiClient) do(ctx context.Context, method string, endpoint string, requestBody []byte) (*http.Response, []byte, error) {
+	wait := c.RetryWait
+	for attempt := 0; ; attempt++ {
+		request, requestErr := http.NewRequest(method, endpoint, bytes.NewReader(requestBody))
+		if requestErr != nil {
+			return nil, nil, requestErr
+		}
+		request = request.WithContext(ctx)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Accept", "application/json")
+		request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.client.Token))
+		if c.client.UserAgent != "" {
+			request.Header.Set("User-Agent", c.client.UserAgent)
+		}
+		c.debug("%s %s %s", method, endpoint, requestBody)
+
+		httpResponse, doErr := c.HttpClient.Do(request)
+		var responseBody []byte
+		if doErr == nil {
+			var readErr error
+			responseBody, readErr = ioutil.ReadAll(httpResponse.Body)
+			httpResponse.Body.Close()
+			if readErr != nil {
+				return httpResponse, nil, readErr
+			}
+			c.debug("%s %s", httpResponse.Status, responseBody)
+		} else {
+			c.debug("%s", doErr)
+		}
+		if attempt >= c.Retries || ctx.Err() != nil || !isRetryable(httpResponse, doErr) {
+			return httpResponse, responseBody, doErr
+		}
+		select {
+		case <-ctx.Done():
+			return httpResponse, responseBody, doErr
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func isRetryable(httpResponse *http.Response, doErr error) bool {
+	if doErr != nil {
+		return true
+	}
+	switch httpResponse.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (c *ApiClient) debug(format string, a ...interface{}) {
+	if c.DebugOutput != nil {
+		fmt.Fprintf(c.DebugOutput, "[hzcloud] "+format+"\n", a...)
+	}
+}
+
+// newErrorResponse parses both a plain error body and the errors of a GraphQL response, falling back to the status
+// when the body has no message.
+func newErrorResponse(httpResponse *http.Response, responseBody []byte) *hazelcastcloud.ErrorResponse {
+	errorResponse := &hazelcastcloud.ErrorResponse{Response: httpResponse}
+	var body struct {
+		Message       string `json:"message"`
+		CorrelationId string `json:"correlationId"`
+		Errors        []struct {
+			Message       string `json:"message"`
+			CorrelationId string `json:"correlationId"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(responseBody, &body) == nil {
+		errorResponse.Message = body.Message
+		errorResponse.CorrelationId = body.CorrelationId
+		if errorResponse.Message == "" && len(body.Errors) != 0 {
+			errorResponse.Message = body.Errors[0].Message
+			errorResponse.CorrelationId = body.Errors[0].CorrelationId
+		}
+	}
+	if errorResponse.Message == "" {
+		errorResponse.Message = strings.TrimSpace(fmt.Sprintf("%s %s", httpResponse.Status, responseBody))
+	}
+	return errorResponse
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+)
+
+// newTestApiClient returns a client logged into the fake API which sends its requests to the handler over http.
+func newTestApiClient(t *testing.T, handler http.HandlerFunc) *ApiClient {
+	server := fakeapi.NewServer()
+	t.Cleanup(server.Close)
+	endpoint := httptest.NewServer(handler)
+	t.Cleanup(endpoint.Close)
+	client := server.ClientFactory()()
+	baseUrl, parseErr := url.Parse(endpoint.URL + "/api/v1")
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+	client.BaseURL = baseUrl
+	apiClient := NewApiClient(client)
+	apiClient.RetryWait = 0
+	return apiClient
+}
+
+func TestApiClientNotifyAzurePeering(t *testing.T) {
+	var requests []*http.Request
+	var notification AzurePeeringNotification
+	apiClient := newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &notification); err != nil {
+			t.Errorf("request body %s is not json: %s", body, err)
+		}
+		w.Write([]byte(`{"isSuccess": true}`))
+	})
+
+	result, _, err := apiClient.NotifyAzurePeering(context.Background(), &AzurePeeringNotification{
+		ClusterId: "1", PeeringConnectionId: "hazelcast-peering", VnetId: "my-vnet", VnetCidr: "10.1.0.0/16",
+	})
+	if err != nil {
+		t.Fatalf("NotifyAzurePeering failed: %s", err)
+	}
+	if !result.IsSuccess {
+		t.Errorf("result is %+v", result)
+	}
+	if len(requests) != 1 || requests[0].Method != http.MethodPost || requests[0].URL.Path != "/peerings" {
+		t.Fatalf("requests are %+v", requests)
+	}
+	if authorization := requests[0].Header.Get("Authorization"); !strings.HasPrefix(authorization, "Bearer ") {
+		t.Errorf("authorization header is %q", authorization)
+	}
+	if notification.ClusterId != "1" || notification.PeeringConnectionId != "hazelcast-peering" ||
+		notification.VnetId != "my-vnet" || notification.VnetCidr != "10.1.0.0/16" {
+		t.Errorf("notification is %+v", notification)
+	}
+}
+
+func TestApiClientPostError(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		message       string
+		correlationId string
+	}{
+		{"error body", http.StatusBadRequest, `{"message": "vnet is not found", "correlationId": "c-1"}`, "vnet is not found", "c-1"},
+		{"graphql errors", http.StatusUnprocessableEntity, `{"errors": [{"message": "cluster is not found", "correlationId": "c-2"}]}`, "cluster is not found", "c-2"},
+		{"plain body", http.StatusForbidden, "access denied", "403 Forbidden access denied", ""},
+		{"empty body", http.StatusNotFound, "", "404 Not Found", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apiClient := newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			})
+
+			response, err := apiClient.post(context.Background(), "/peerings", struct{}{}, nil)
+			errorResponse, ok := err.(*hazelcastcloud.ErrorResponse)
+			if !ok {
+				t.Fatalf("post returned %v", err)
+			}
+			if errorResponse.Message != test.message || errorResponse.CorrelationId != test.correlationId {
+				t.Errorf("error response is %+v", errorResponse)
+			}
+			if response == nil || response.StatusCode != test.status {
+				t.Errorf("response is %+v", response)
+			}
+		})
+	}
+}
+
+func TestApiClientPostRetry(t *testing.T) {
+	statuses := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}
+	var bodies []string
+	apiClient := newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(statuses[len(bodies)-1])
+	})
+	var debugOutput bytes.Buffer
+	apiClient.DebugOutput = &debugOutput
+
+	if _, err := apiClient.post(context.Background(), "/peerings", map[string]string{"clusterId": "1"}, nil); err != nil {
+		t.Fatalf("post failed: %s", err)
+	}
+	if len(bodies) != 3 || bodies[2] != `{"clusterId":"1"}` {
+		t.Errorf("request bodies are %q", bodies)
+	}
+	if !strings.Contains(debugOutput.String(), "503 Service Unavailable") {
+		t.Errorf("debug output is %q", debugOutput.String())
+	}
+
+	requests := 0
+	apiClient = newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	if _, err := apiClient.post(context.Background(), "/peerings", struct{}{}, nil); err == nil {
+		t.Error("post succeeded")
+	}
+	if requests != apiClient.Retries+1 {
+		t.Errorf("requests are %d", requests)
+	}
+
+	requests = 0
+	apiClient = newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	if _, err := apiClient.post(context.Background(), "/peerings", struct{}{}, nil); err == nil || requests != 1 {
+		t.Errorf("post returned %v after %d requests", err, requests)
+	}
+}
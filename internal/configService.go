@@ -13,6 +13,7 @@ const (
 	ApiKey               ConfigKey = "api-key"
 	ApiSecret            ConfigKey = "api-secret"
 	LastVersionCheckTime ConfigKey = "last-version-check-time"
+	ProtectedClusterIds  ConfigKey = "protected-cluster-ids"
 )
 
 type ConfigService interface {
@@ -0,0 +1,173 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type uploadedFile struct {
+	Name    string
+	Content []byte
+}
+
+func (s *Server) AddArtifact(artifact Artifact) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if artifact.Id == "" {
+		artifact.Id = s.generateId()
+	}
+	s.artifacts[artifact.Id] = &artifact
+	return artifact.Id
+}
+
+func (s *Server) Artifacts(clusterId string) []Artifact {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.clusterArtifacts(clusterId)
+}
+
+func (s *Server) clusterArtifacts(clusterId string) []Artifact {
+	artifacts := []Artifact{}
+	for _, artifact := range s.artifacts {
+		if artifact.ClusterId == clusterId {
+			artifacts = append(artifacts, *artifact)
+		}
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Id < artifacts[j].Id })
+	return artifacts
+}
+
+func (s *Server) registerArtifactHandlers() {
+	s.handlers["customClasses"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if _, err := s.findCluster(args.String("clusterId")); err != nil {
+			return nil, err
+		}
+		for _, artifact := range s.artifacts {
+			if artifact.ClusterId == args.String("clusterId") {
+				s.advanceArtifact(artifact)
+			}
+		}
+		return s.clusterArtifacts(args.String("clusterId")), nil
+	}
+
+	s.handlers["uploadCustomClasses"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if _, err := s.findCluster(args.String("clusterId")); err != nil {
+			return nil, err
+		}
+		file, ok := args["file"].(*uploadedFile)
+		if !ok {
+			return nil, fmt.Errorf("file is missing")
+		}
+		name := file.Name
+		if args.String("fileName") != "" {
+			name = args.String("fileName")
+		}
+		artifact := &Artifact{
+			Id:        s.generateId(),
+			ClusterId: args.String("clusterId"),
+			Name:      name,
+			Status:    "PENDING",
+			Content:   file.Content,
+		}
+		s.artifacts[artifact.Id] = artifact
+		return artifact, nil
+	}
+
+	s.handlers["deleteCustomClasses"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		artifact, err := s.findArtifact(args)
+		if err != nil {
+			return nil, err
+		}
+		delete(s.artifacts, artifact.Id)
+		return artifact, nil
+	}
+
+	s.handlers["downloadCustomClasses"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		artifact, err := s.findArtifact(args)
+		if err != nil {
+			return nil, err
+		}
+		return ArtifactLink{
+			Id:   artifact.Id,
+			Name: artifact.Name,
+			Url:  fmt.Sprintf("%s/artifacts/%s", s.URL, artifact.Id),
+		}, nil
+	}
+}
+
+// advanceArtifact moves an uploaded artifact from PENDING to PROCESSING on the first read, and to READY, or FAILED
+// for the FailingArtifactNames, after TransitionSteps reads.
+func (s *Server) advanceArtifact(artifact *Artifact) {
+	if artifact.Status != "PENDING" && artifact.Status != "PROCESSING" {
+		return
+	}
+	s.artifactReads[artifact.Id]++
+	artifact.Status = "PROCESSING"
+	if s.artifactReads[artifact.Id] >= s.TransitionSteps {
+		artifact.Status = "READY"
+		if s.FailingArtifactNames[artifact.Name] {
+			artifact.Status = "FAILED"
+		}
+		delete(s.artifactReads, artifact.Id)
+	}
+}
+
+func (s *Server) findArtifact(args Args) (*Artifact, error) {
+	id := args.String("customClassesId")
+	artifact, ok := s.artifacts[id]
+	if !ok || artifact.ClusterId != args.String("clusterId") {
+		return nil, fmt.Errorf("artifact %s not found", id)
+	}
+	return artifact, nil
+}
+
+func (s *Server) serveArtifactContent(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	artifact, ok := s.artifacts[strings.TrimPrefix(r.URL.Path, "/artifacts/")]
+	s.mutex.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/java-archive")
+	http.ServeContent(w, r, artifact.Name, time.Time{}, bytes.NewReader(artifact.Content))
+}
+
+func readMultipartRequest(r *http.Request, request interface{}) (*uploadedFile, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return nil, err
+	}
+	if err := json.NewDecoder(strings.NewReader(r.FormValue("operations"))).Decode(request); err != nil {
+		return nil, err
+	}
+	for _, headers := range r.MultipartForm.File {
+		if len(headers) == 0 {
+			continue
+		}
+		file, err := headers[0].Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+		return &uploadedFile{Name: headers[0].Filename, Content: content}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,51 @@
+package fakeapi
+
+import "fmt"
+
+func (s *Server) registerLoginHandlers() {
+	s.handlers["login"] = func(args Args) (interface{}, error) {
+		if args.String("apiKey") != ApiKey || args.String("apiSecret") != ApiSecret {
+			return nil, fmt.Errorf("invalid api key or api secret")
+		}
+		return map[string]string{"token": Token}, nil
+	}
+}
+
+func (s *Server) registerCatalogHandlers() {
+	s.handlers["cloudProviders"] = func(args Args) (interface{}, error) {
+		return []CatalogItem{
+			{Name: "aws", IsEnabledForStarter: true, IsEnabledForEnterprise: true},
+			{Name: "gcp", IsEnabledForStarter: true, IsEnabledForEnterprise: true},
+			{Name: "azure", IsEnabledForStarter: false, IsEnabledForEnterprise: true},
+		}, nil
+	}
+	s.handlers["regions"] = func(args Args) (interface{}, error) {
+		regions := map[string][]CatalogItem{
+			"aws": {
+				{Name: "us-east-1", IsEnabledForStarter: true, IsEnabledForEnterprise: true},
+				{Name: "eu-west-2", IsEnabledForStarter: false, IsEnabledForEnterprise: true},
+			},
+			"gcp": {
+				{Name: "us-central1", IsEnabledForStarter: true, IsEnabledForEnterprise: true},
+			},
+			"azure": {
+				{Name: "westeurope", IsEnabledForStarter: false, IsEnabledForEnterprise: true},
+			},
+		}
+		return regions[args.String("cloudProvider")], nil
+	}
+	s.handlers["instanceTypes"] = func(args Args) (interface{}, error) {
+		instanceTypes := map[string][]InstanceType{
+			"aws":   {{Name: "m5.large", TotalMemory: 8}, {Name: "m5.xlarge", TotalMemory: 16}},
+			"gcp":   {{Name: "n1-standard-2", TotalMemory: 7.5}},
+			"azure": {{Name: "Standard_D2s_v3", TotalMemory: 8}},
+		}
+		return instanceTypes[args.String("cloudProvider")], nil
+	}
+	s.handlers["hazelcastVersions"] = func(args Args) (interface{}, error) {
+		return []HazelcastVersion{
+			{Version: "4.2", UpgradeableVersions: []string{"5.0"}},
+			{Version: "5.0", UpgradeableVersions: []string{}},
+		}, nil
+	}
+}
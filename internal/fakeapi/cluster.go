@@ -0,0 +1,206 @@
+package fakeapi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StatePending  = "PENDING"
+	StateRunning  = "RUNNING"
+	StateStopped  = "STOPPED"
+	StateFailed   = "FAILED"
+	StateDeleting = "DELETING"
+)
+
+// AddCluster stores a cluster as is and returns its id, so commands can be run against a known state.
+func (s *Server) AddCluster(cluster Cluster) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if cluster.Id == "" {
+		cluster.Id = s.generateId()
+	}
+	s.clusters[cluster.Id] = &cluster
+	return cluster.Id
+}
+
+func (s *Server) Cluster(clusterId string) (Cluster, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	cluster, ok := s.clusters[clusterId]
+	if !ok {
+		return Cluster{}, false
+	}
+	return *cluster, true
+}
+
+func (s *Server) registerClusterHandlers() {
+	s.handlers["createStarterCluster"] = func(args Args) (interface{}, error) {
+		cluster := s.newCluster(args, "Starter")
+		cluster.ClusterType.Name = strings.ToUpper(args.String("input.clusterType"))
+		cluster.ProductType.IsFree = cluster.ClusterType.Name == "FREE"
+		cluster.Specs.TotalMemory = args.Float("input.totalMemory")
+		cluster.IsIpWhitelistEnabled = args.Bool("input.isIPWhitelistEnabled")
+		return s.storeCluster(cluster), nil
+	}
+	s.handlers["createEnterpriseCluster"] = func(args Args) (interface{}, error) {
+		cluster := s.newCluster(args, "Enterprise")
+		cluster.Specs.InstanceType = args.String("input.instanceType")
+		cluster.Specs.InstancePerZone = args.Int("input.instancePerZone")
+		cluster.Specs.NativeMemory = args.Int("input.nativeMemory")
+		cluster.Specs.TotalMemory = float64(cluster.Specs.NativeMemory)
+		cluster.Networking.CidrBlock = args.String("input.cidrBlock")
+		cluster.Networking.Type = "PEERING"
+		if args.Bool("input.isPublicAccessEnabled") {
+			cluster.Networking.Type = "PUBLIC"
+		}
+		cluster.CloudProvider.AvailabilityZones = []string{cluster.CloudProvider.Region + "a"}
+		if args.String("input.zoneType") == "MULTI" {
+			cluster.CloudProvider.AvailabilityZones = append(cluster.CloudProvider.AvailabilityZones,
+				cluster.CloudProvider.Region+"b", cluster.CloudProvider.Region+"c")
+		}
+		return s.storeCluster(cluster), nil
+	}
+	s.handlers["createServerlessCluster"] = func(args Args) (interface{}, error) {
+		cluster := s.newCluster(args, "Serverless")
+		cluster.CloudProvider.Name = "aws"
+		cluster.ClusterType.Name = strings.ToUpper(args.String("input.clusterType"))
+		if cluster.ClusterType.Name == "" {
+			cluster.ClusterType.Name = "SERVERLESS"
+		}
+		return s.storeCluster(cluster), nil
+	}
+	s.handlers["cluster"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		cluster, err := s.findCluster(args.String("clusterId"))
+		if err != nil {
+			return nil, err
+		}
+		s.advanceCluster(cluster)
+		return cluster, nil
+	}
+	s.handlers["clusters"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		productType := args.String("productType")
+		clusters := []Cluster{}
+		for _, cluster := range s.clusters {
+			if productType != "" && !strings.EqualFold(productType, cluster.ProductType.Name) {
+				continue
+			}
+			s.advanceCluster(cluster)
+			clusters = append(clusters, *cluster)
+		}
+		sort.Slice(clusters, func(i, j int) bool {
+			left, _ := strconv.Atoi(clusters[i].Id)
+			right, _ := strconv.Atoi(clusters[j].Id)
+			return left < right
+		})
+		return clusters, nil
+	}
+	s.handlers["deleteCluster"] = s.clusterAction(func(cluster *Cluster) error {
+		delete(s.clusters, cluster.Id)
+		delete(s.clusterReads, cluster.Id)
+		return nil
+	})
+	s.handlers["stopCluster"] = s.clusterAction(func(cluster *Cluster) error {
+		if cluster.State != StateRunning {
+			return fmt.Errorf("cluster %s is %s, only running clusters can be stopped", cluster.Id, cluster.State)
+		}
+		cluster.State = StateStopped
+		cluster.StoppedAt = now()
+		return nil
+	})
+	s.handlers["resumeCluster"] = s.clusterAction(func(cluster *Cluster) error {
+		if cluster.State != StateStopped {
+			return fmt.Errorf("cluster %s is %s, only stopped clusters can be resumed", cluster.Id, cluster.State)
+		}
+		cluster.State = StatePending
+		cluster.Progress = Progress{Status: "Resuming", TotalItemCount: s.TransitionSteps}
+		s.clusterReads[cluster.Id] = 0
+		return nil
+	})
+}
+
+// newCluster builds a pending cluster from the input argument of the create mutations.
+func (s *Server) newCluster(args Args, productType string) *Cluster {
+	cluster := &Cluster{
+		CustomerId:           1,
+		Name:                 args.String("input.name"),
+		Password:             "fake-password",
+		Port:                 31000,
+		HazelcastVersion:     args.String("input.hazelcastVersion"),
+		IsAutoScalingEnabled: args.Bool("input.isAutoScalingEnabled"),
+		IsHotBackupEnabled:   args.Bool("input.isHotBackupEnabled"),
+		IsHotRestartEnabled:  args.Bool("input.isHotRestartEnabled"),
+		IsTlsEnabled:         args.Bool("input.isTLSEnabled"),
+		ProductType:          ProductType{Name: productType},
+		State:                StatePending,
+		CreatedAt:            now(),
+		Progress:             Progress{Status: "Creating", TotalItemCount: s.TransitionSteps},
+		CloudProvider: CloudProvider{
+			Name:   args.String("input.cloudProvider"),
+			Region: args.String("input.region"),
+		},
+	}
+	if cluster.HazelcastVersion == "" {
+		cluster.HazelcastVersion = "5.0"
+	}
+	return cluster
+}
+
+func (s *Server) storeCluster(cluster *Cluster) Cluster {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	cluster.Id = s.generateId()
+	cluster.ReleaseName = "pr-" + cluster.Id
+	cluster.DiscoveryTokens = []DiscoveryToken{{Source: "default", Token: "fake-token-" + cluster.Id}}
+	s.clusters[cluster.Id] = cluster
+	s.clusterReads[cluster.Id] = 0
+	return *cluster
+}
+
+func (s *Server) findCluster(clusterId string) (*Cluster, error) {
+	cluster, ok := s.clusters[clusterId]
+	if !ok {
+		return nil, fmt.Errorf("cluster %s not found", clusterId)
+	}
+	return cluster, nil
+}
+
+func (s *Server) clusterAction(action func(cluster *Cluster) error) HandlerFunc {
+	return func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		cluster, err := s.findCluster(args.String("clusterId"))
+		if err != nil {
+			return nil, err
+		}
+		if err = action(cluster); err != nil {
+			return nil, err
+		}
+		id, _ := strconv.Atoi(cluster.Id)
+		return ClusterId{ClusterId: id}, nil
+	}
+}
+
+func (s *Server) advanceCluster(cluster *Cluster) {
+	if cluster.State != StatePending {
+		return
+	}
+	s.clusterReads[cluster.Id]++
+	cluster.Progress.CompletedItemCount = s.clusterReads[cluster.Id]
+	if s.clusterReads[cluster.Id] >= s.TransitionSteps {
+		cluster.State = StateRunning
+		cluster.StartedAt = now()
+		cluster.Progress.Status = "Running"
+	}
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
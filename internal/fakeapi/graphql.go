@@ -0,0 +1,297 @@
+package fakeapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type operation struct {
+	Type  string
+	Alias string
+	Field string
+	Args  Args
+}
+
+type Args map[string]interface{}
+
+// lookup returns the argument at the dotted path key, like "input.name" for the name field of the input argument.
+func (a Args) lookup(key string) (interface{}, bool) {
+	var value interface{} = map[string]interface{}(a)
+	for _, name := range strings.Split(key, ".") {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = fields[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func (a Args) String(key string) string {
+	value, ok := a.lookup(key)
+	if !ok || value == nil {
+		return ""
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func (a Args) Bool(key string) bool {
+	value, _ := a.lookup(key)
+	b, _ := value.(bool)
+	return b
+}
+
+func (a Args) Float(key string) float64 {
+	value, _ := a.lookup(key)
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+func (a Args) Int(key string) int {
+	return int(a.Float(key))
+}
+
+func (a Args) Strings(key string) []string {
+	value, _ := a.lookup(key)
+	items, _ := value.([]interface{})
+	var result []string
+	for _, item := range items {
+		result = append(result, fmt.Sprintf("%v", item))
+	}
+	return result
+}
+
+func (a Args) Maps(key string) []Args {
+	value, _ := a.lookup(key)
+	items, _ := value.([]interface{})
+	var result []Args
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+type queryParser struct {
+	input     string
+	position  int
+	variables map[string]interface{}
+}
+
+func parseOperation(query string, variables map[string]interface{}) (operation, error) {
+	p := &queryParser{input: query, variables: variables}
+	op := operation{Type: "query", Args: Args{}}
+	p.skipSpaces()
+	if p.peek() != '{' {
+		op.Type = p.readName()
+		p.skipSpaces()
+		if p.peek() != '{' && p.peek() != '(' {
+			p.readName()
+			p.skipSpaces()
+		}
+		if p.peek() == '(' {
+			if err := p.skipBlock('(', ')'); err != nil {
+				return op, err
+			}
+			p.skipSpaces()
+		}
+	}
+	if err := p.expect('{'); err != nil {
+		return op, err
+	}
+	p.skipSpaces()
+	name := p.readName()
+	p.skipSpaces()
+	if p.peek() == ':' {
+		p.position++
+		p.skipSpaces()
+		op.Alias = name
+		name = p.readName()
+		p.skipSpaces()
+	}
+	if name == "" {
+		return op, fmt.Errorf("fake api: could not find an operation in query %q", query)
+	}
+	op.Field = name
+	if op.Alias == "" {
+		op.Alias = name
+	}
+	if p.peek() == '(' {
+		p.position++
+		args, err := p.readFields(')')
+		if err != nil {
+			return op, err
+		}
+		op.Args = args
+	}
+	return op, nil
+}
+
+func (p *queryParser) peek() byte {
+	if p.position >= len(p.input) {
+		return 0
+	}
+	return p.input[p.position]
+}
+
+func (p *queryParser) skipSpaces() {
+	for p.position < len(p.input) {
+		c := rune(p.input[p.position])
+		if unicode.IsSpace(c) || c == ',' {
+			p.position++
+		} else if c == '#' {
+			for p.position < len(p.input) && p.input[p.position] != '\n' {
+				p.position++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+func (p *queryParser) expect(c byte) error {
+	if p.peek() != c {
+		return fmt.Errorf("fake api: expected %q at position %d of %q", c, p.position, p.input)
+	}
+	p.position++
+	return nil
+}
+
+func (p *queryParser) readName() string {
+	start := p.position
+	for p.position < len(p.input) {
+		c := rune(p.input[p.position])
+		if c == '_' || c == '$' || unicode.IsLetter(c) || unicode.IsDigit(c) {
+			p.position++
+		} else {
+			break
+		}
+	}
+	return p.input[start:p.position]
+}
+
+func (p *queryParser) skipBlock(open byte, close byte) error {
+	depth := 0
+	for p.position < len(p.input) {
+		switch p.input[p.position] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				p.position++
+				return nil
+			}
+		}
+		p.position++
+	}
+	return fmt.Errorf("fake api: unterminated %q in %q", open, p.input)
+}
+
+func (p *queryParser) readFields(close byte) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	for {
+		p.skipSpaces()
+		if p.peek() == close {
+			p.position++
+			return fields, nil
+		}
+		name := p.readName()
+		if name == "" {
+			return nil, fmt.Errorf("fake api: expected argument name at position %d of %q", p.position, p.input)
+		}
+		p.skipSpaces()
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+}
+
+func (p *queryParser) readValue() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		return p.readString()
+	case c == '{':
+		p.position++
+		return p.readFields('}')
+	case c == '[':
+		p.position++
+		var items []interface{}
+		for {
+			p.skipSpaces()
+			if p.peek() == ']' {
+				p.position++
+				return items, nil
+			}
+			item, err := p.readValue()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.position
+		p.position++
+		for p.position < len(p.input) && strings.ContainsRune("0123456789.eE+-", rune(p.input[p.position])) {
+			p.position++
+		}
+		return strconv.ParseFloat(p.input[start:p.position], 64)
+	default:
+		name := p.readName()
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("fake api: unexpected %q at position %d of %q", c, p.position, p.input)
+		case name == "true":
+			return true, nil
+		case name == "false":
+			return false, nil
+		case name == "null":
+			return nil, nil
+		case strings.HasPrefix(name, "$"):
+			return p.variables[strings.TrimPrefix(name, "$")], nil
+		default:
+			return name, nil
+		}
+	}
+}
+
+func (p *queryParser) readString() (string, error) {
+	start := p.position
+	p.position++
+	for p.position < len(p.input) {
+		switch p.input[p.position] {
+		case '\\':
+			p.position += 2
+		case '"':
+			p.position++
+			return strconv.Unquote(p.input[start:p.position])
+		default:
+			p.position++
+		}
+	}
+	return "", fmt.Errorf("fake api: unterminated string in %q", p.input)
+}
@@ -0,0 +1,191 @@
+package fakeapi
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AddAwsPeering stores a peering as is and returns its id.
+func (s *Server) AddAwsPeering(peering AwsPeering) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if peering.Id == "" {
+		peering.Id = s.generateId()
+	}
+	s.awsPeerings[peering.Id] = &peering
+	return peering.Id
+}
+
+func (s *Server) AddGcpPeering(peering GcpPeering) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if peering.Id == "" {
+		peering.Id = s.generateId()
+	}
+	s.gcpPeerings[peering.Id] = &peering
+	return peering.Id
+}
+
+func (s *Server) AddAzurePeering(peering AzurePeering) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if peering.Id == "" {
+		peering.Id = s.generateId()
+	}
+	s.azurePeerings[peering.Id] = &peering
+	return peering.Id
+}
+
+func (s *Server) AwsPeerings(clusterId string) []AwsPeering {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	peerings := []AwsPeering{}
+	for _, peering := range s.awsPeerings {
+		if peering.ClusterId == clusterId {
+			peerings = append(peerings, *peering)
+		}
+	}
+	sort.Slice(peerings, func(i, j int) bool { return peerings[i].Id < peerings[j].Id })
+	return peerings
+}
+
+func (s *Server) GcpPeerings(clusterId string) []GcpPeering {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	peerings := []GcpPeering{}
+	for _, peering := range s.gcpPeerings {
+		if peering.ClusterId == clusterId {
+			peerings = append(peerings, *peering)
+		}
+	}
+	sort.Slice(peerings, func(i, j int) bool { return peerings[i].Id < peerings[j].Id })
+	return peerings
+}
+
+func (s *Server) AzurePeerings(clusterId string) []AzurePeering {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	peerings := []AzurePeering{}
+	for _, peering := range s.azurePeerings {
+		if peering.ClusterId == clusterId {
+			peerings = append(peerings, *peering)
+		}
+	}
+	sort.Slice(peerings, func(i, j int) bool { return peerings[i].Id < peerings[j].Id })
+	return peerings
+}
+
+func (s *Server) registerPeeringHandlers() {
+	s.handlers["awsPeeringProperties"] = s.peeringProperties(func(cluster *Cluster) interface{} {
+		return AwsPeeringProperties{
+			OwnerId: "111111111111",
+			VpcId:   "vpc-hazelcast-" + cluster.Id,
+			VpcCidr: cluster.Networking.CidrBlock,
+			Region:  cluster.CloudProvider.Region,
+		}
+	})
+	s.handlers["acceptAwsVpcPeering"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if _, err := s.findCluster(args.String("input.clusterId")); err != nil {
+			return nil, err
+		}
+		for _, subnet := range args.Maps("input.subnets") {
+			id := s.generateId()
+			s.awsPeerings[id] = &AwsPeering{
+				Id:                  id,
+				ClusterId:           args.String("input.clusterId"),
+				PeeringConnectionId: args.String("input.peeringConnectionId"),
+				VpcId:               args.String("input.vpcId"),
+				VpcCidr:             args.String("input.vpcCidr"),
+				SubnetId:            subnet.String("subnetId"),
+				SubnetCidr:          subnet.String("subnetCidr"),
+			}
+		}
+		return Result{IsSuccess: true}, nil
+	}
+	s.handlers["awsPeerings"] = func(args Args) (interface{}, error) {
+		return s.AwsPeerings(args.String("clusterId")), nil
+	}
+	s.handlers["deleteAwsPeering"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if _, ok := s.awsPeerings[args.String("id")]; !ok {
+			return nil, fmt.Errorf("peering %s not found", args.String("id"))
+		}
+		delete(s.awsPeerings, args.String("id"))
+		return Result{IsSuccess: true}, nil
+	}
+
+	s.handlers["gcpPeeringProperties"] = s.peeringProperties(func(cluster *Cluster) interface{} {
+		return GcpPeeringProperties{
+			ProjectId:   "hazelcast-cloud",
+			NetworkName: "hazelcast-network-" + cluster.Id,
+		}
+	})
+	s.handlers["acceptGcpVpcPeering"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if _, err := s.findCluster(args.String("input.clusterId")); err != nil {
+			return nil, err
+		}
+		id := s.generateId()
+		s.gcpPeerings[id] = &GcpPeering{
+			Id:          id,
+			ClusterId:   args.String("input.clusterId"),
+			ProjectId:   args.String("input.projectId"),
+			NetworkName: args.String("input.networkName"),
+		}
+		return Result{IsSuccess: true}, nil
+	}
+	s.handlers["gcpPeerings"] = func(args Args) (interface{}, error) {
+		return s.GcpPeerings(args.String("clusterId")), nil
+	}
+	s.handlers["deleteGcpPeering"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if _, ok := s.gcpPeerings[args.String("id")]; !ok {
+			return nil, fmt.Errorf("peering %s not found", args.String("id"))
+		}
+		delete(s.gcpPeerings, args.String("id"))
+		return Result{IsSuccess: true}, nil
+	}
+
+	s.handlers["azurePeeringProperties"] = s.peeringProperties(func(cluster *Cluster) interface{} {
+		return AzurePeeringProperties{
+			AppRegistrationId:  "00000000-0000-0000-0000-000000000001",
+			AppRegistrationKey: "fake-app-registration-key",
+			TenantId:           "00000000-0000-0000-0000-000000000002",
+			SubscriptionId:     "00000000-0000-0000-0000-000000000003",
+			ResourceGroupName:  "hazelcast-" + cluster.Id,
+			VnetName:           "hazelcast-vnet-" + cluster.Id,
+		}
+	})
+	s.handlers["azurePeerings"] = func(args Args) (interface{}, error) {
+		return s.AzurePeerings(args.String("clusterId")), nil
+	}
+	s.handlers["deleteAzurePeering"] = func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if _, ok := s.azurePeerings[args.String("id")]; !ok {
+			return nil, fmt.Errorf("peering %s not found", args.String("id"))
+		}
+		delete(s.azurePeerings, args.String("id"))
+		return Result{IsSuccess: true}, nil
+	}
+}
+
+func (s *Server) peeringProperties(properties func(cluster *Cluster) interface{}) HandlerFunc {
+	return func(args Args) (interface{}, error) {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		cluster, err := s.findCluster(args.String("clusterId"))
+		if err != nil {
+			return nil, err
+		}
+		if cluster.ProductType.Name != "Enterprise" {
+			return nil, fmt.Errorf("peering is only available for enterprise clusters")
+		}
+		return properties(cluster), nil
+	}
+}
@@ -0,0 +1,211 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+)
+
+const (
+	ApiKey    = "fake-api-key"
+	ApiSecret = "fake-api-secret"
+	Token     = "fake-token"
+)
+
+type HandlerFunc func(args Args) (interface{}, error)
+
+// Server is an in-process stand-in for the Hazelcast Cloud API. Point the CLI at it by setting
+// HZ_CLOUD_API_URL to Server.URL or by using ClientFactory.
+type Server struct {
+	*httptest.Server
+	// TransitionSteps is the number of reads after which a pending cluster becomes running, and a pending artifact
+	// becomes ready.
+	TransitionSteps int
+	// FailingArtifactNames are the names of the uploaded artifacts which fail processing instead of becoming ready.
+	FailingArtifactNames map[string]bool
+	mutex                sync.Mutex
+	handlers             map[string]HandlerFunc
+	nextId               int
+	clusters             map[string]*Cluster
+	awsPeerings          map[string]*AwsPeering
+	gcpPeerings          map[string]*GcpPeering
+	azurePeerings        map[string]*AzurePeering
+	artifacts            map[string]*Artifact
+	clusterReads         map[string]int
+	artifactReads        map[string]int
+}
+
+func NewServer() *Server {
+	s := &Server{
+		TransitionSteps:      3,
+		FailingArtifactNames: map[string]bool{},
+		handlers:             map[string]HandlerFunc{},
+		nextId:               100,
+		clusters:             map[string]*Cluster{},
+		awsPeerings:          map[string]*AwsPeering{},
+		gcpPeerings:          map[string]*GcpPeering{},
+		azurePeerings:        map[string]*AzurePeering{},
+		artifacts:            map[string]*Artifact{},
+		clusterReads:         map[string]int{},
+		artifactReads:        map[string]int{},
+	}
+	s.registerLoginHandlers()
+	s.registerCatalogHandlers()
+	s.registerClusterHandlers()
+	s.registerPeeringHandlers()
+	s.registerArtifactHandlers()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// ClientFactory returns a factory creating clients logged into this server, suitable for cmd.SetClientFactory.
+func (s *Server) ClientFactory() func() *hazelcastcloud.Client {
+	return func() *hazelcastcloud.Client {
+		client, _, err := hazelcastcloud.NewFromCredentials(ApiKey, ApiSecret, hazelcastcloud.OptionEndpoint(s.URL))
+		if err != nil {
+			panic(err)
+		}
+		return client
+	}
+}
+
+// Handle registers or replaces the handler for a GraphQL operation, e.g. to inject failures.
+func (s *Server) Handle(operationName string, handler HandlerFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[operationName] = handler
+}
+
+// Handler returns the handler of a GraphQL operation, e.g. to restore it after injecting a failure.
+func (s *Server) Handler(operationName string) HandlerFunc {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.handlers[operationName]
+}
+
+func (s *Server) generateId() string {
+	s.nextId++
+	return fmt.Sprintf("%d", s.nextId)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/artifacts/") && r.Method == http.MethodGet:
+		s.serveArtifactContent(w, r)
+	case r.URL.Path == "/peerings" && r.Method == http.MethodPost:
+		s.serveAzurePeeringNotification(w, r)
+	case r.Method == http.MethodPost:
+		s.serveGraphql(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveGraphql(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	var file *uploadedFile
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		uploaded, err := readMultipartRequest(r, &request)
+		if err != nil {
+			writeErrors(w, http.StatusBadRequest, err)
+			return
+		}
+		file = uploaded
+	} else if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeErrors(w, http.StatusBadRequest, err)
+		return
+	}
+	op, err := parseOperation(request.Query, request.Variables)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, err)
+		return
+	}
+	if op.Field != "login" && r.Header.Get("Authorization") != "Bearer "+Token {
+		writeErrors(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	if file != nil {
+		op.Args["file"] = file
+	}
+
+	s.mutex.Lock()
+	handler, ok := s.handlers[op.Field]
+	s.mutex.Unlock()
+	if !ok {
+		writeErrors(w, http.StatusOK, fmt.Errorf("fake api: unsupported operation %s", op.Field))
+		return
+	}
+	result, err := handler(op.Args)
+	if err != nil {
+		writeErrors(w, http.StatusOK, err)
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{op.Alias: result},
+	})
+}
+
+func (s *Server) serveAzurePeeringNotification(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+Token {
+		writeErrors(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+	var notification struct {
+		ClusterId           string `json:"clusterId"`
+		PeeringConnectionId string `json:"peeringConnectionId"`
+		VpcId               string `json:"vpcId"`
+		VpcCidr             string `json:"vpcCidr"`
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	if err := json.Unmarshal(body, &notification); err != nil {
+		writeErrors(w, http.StatusBadRequest, err)
+		return
+	}
+	s.mutex.Lock()
+	if _, err := s.findCluster(notification.ClusterId); err != nil {
+		s.mutex.Unlock()
+		writeJson(w, http.StatusNotFound, map[string]interface{}{
+			"message":       err.Error(),
+			"correlationId": "fake-correlation-id",
+		})
+		return
+	}
+	id := s.generateId()
+	s.azurePeerings[id] = &AzurePeering{
+		Id:                  id,
+		ClusterId:           notification.ClusterId,
+		PeeringConnectionId: notification.PeeringConnectionId,
+		VpcId:               notification.VpcId,
+		VpcCidr:             notification.VpcCidr,
+	}
+	s.mutex.Unlock()
+	writeJson(w, http.StatusOK, Result{IsSuccess: true})
+}
+
+func writeJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeErrors(w http.ResponseWriter, status int, err error) {
+	writeJson(w, status, map[string]interface{}{
+		"data": nil,
+		"errors": []map[string]interface{}{
+			{
+				"message":       err.Error(),
+				"correlationId": "fake-correlation-id",
+				"extensions":    map[string]interface{}{"correlationId": "fake-correlation-id"},
+			},
+		},
+	})
+}
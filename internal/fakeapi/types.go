@@ -0,0 +1,176 @@
+package fakeapi
+
+type Cluster struct {
+	Id                   string           `json:"id"`
+	CustomerId           int              `json:"customerId"`
+	Name                 string           `json:"name"`
+	ReleaseName          string           `json:"releaseName"`
+	Password             string           `json:"password"`
+	Port                 int              `json:"port"`
+	HazelcastVersion     string           `json:"hazelcastVersion"`
+	IsAutoScalingEnabled bool             `json:"isAutoScalingEnabled"`
+	IsHotBackupEnabled   bool             `json:"isHotBackupEnabled"`
+	IsHotRestartEnabled  bool             `json:"isHotRestartEnabled"`
+	IsIpWhitelistEnabled bool             `json:"isIpWhitelistEnabled"`
+	IsTlsEnabled         bool             `json:"isTlsEnabled"`
+	ProductType          ProductType      `json:"productType"`
+	ClusterType          ClusterType      `json:"clusterType"`
+	State                string           `json:"state"`
+	CreatedAt            string           `json:"createdAt"`
+	StartedAt            string           `json:"startedAt"`
+	StoppedAt            string           `json:"stoppedAt"`
+	Progress             Progress         `json:"progress"`
+	CloudProvider        CloudProvider    `json:"cloudProvider"`
+	DiscoveryTokens      []DiscoveryToken `json:"discoveryTokens"`
+	Specs                Specs            `json:"specs"`
+	Networking           Networking       `json:"networking"`
+	DataStructures       DataStructures   `json:"dataStructures"`
+}
+
+type ProductType struct {
+	Name   string `json:"name"`
+	IsFree bool   `json:"isFree"`
+}
+
+type ClusterType struct {
+	Name string `json:"name"`
+}
+
+type Progress struct {
+	Status             string `json:"status"`
+	TotalItemCount     int    `json:"totalItemCount"`
+	CompletedItemCount int    `json:"completedItemCount"`
+}
+
+type CloudProvider struct {
+	Name              string   `json:"name"`
+	Region            string   `json:"region"`
+	AvailabilityZones []string `json:"availabilityZones"`
+}
+
+type DiscoveryToken struct {
+	Source string `json:"source"`
+	Token  string `json:"token"`
+}
+
+type Specs struct {
+	TotalMemory     float64 `json:"totalMemory"`
+	HeapMemory      int     `json:"heapMemory"`
+	NativeMemory    int     `json:"nativeMemory"`
+	Cpu             int     `json:"cpu"`
+	InstanceType    string  `json:"instanceType"`
+	InstancePerZone int     `json:"instancePerZone"`
+}
+
+type Networking struct {
+	Type        string      `json:"type"`
+	CidrBlock   string      `json:"cidrBlock"`
+	Peering     Peering     `json:"peering"`
+	PrivateLink PrivateLink `json:"privateLink"`
+}
+
+type Peering struct {
+	IsEnabled bool `json:"isEnabled"`
+}
+
+type PrivateLink struct {
+	Url   string `json:"url"`
+	State string `json:"state"`
+}
+
+type DataStructures struct {
+	MapConfigs           []interface{} `json:"mapConfigs"`
+	ListConfigs          []interface{} `json:"listConfigs"`
+	SetConfigs           []interface{} `json:"setConfigs"`
+	QueueConfigs         []interface{} `json:"queueConfigs"`
+	JCacheConfigs        []interface{} `json:"jcacheConfigs"`
+	MultiMapConfigs      []interface{} `json:"multiMapConfigs"`
+	TopicConfigs         []interface{} `json:"topicConfigs"`
+	RingBufferConfigs    []interface{} `json:"ringBufferConfigs"`
+	ReliableTopicConfigs []interface{} `json:"reliableTopicConfigs"`
+	ReplicatedMapConfigs []interface{} `json:"replicatedMapConfigs"`
+}
+
+type ClusterId struct {
+	ClusterId int `json:"clusterId"`
+}
+
+type Result struct {
+	IsSuccess bool `json:"isSuccess"`
+}
+
+type CatalogItem struct {
+	Name                   string `json:"name"`
+	IsEnabledForStarter    bool   `json:"isEnabledForStarter"`
+	IsEnabledForEnterprise bool   `json:"isEnabledForEnterprise"`
+}
+
+type InstanceType struct {
+	Name        string  `json:"name"`
+	TotalMemory float64 `json:"totalMemory"`
+}
+
+type HazelcastVersion struct {
+	Version             string   `json:"version"`
+	UpgradeableVersions []string `json:"upgradeableVersions"`
+}
+
+type AwsPeering struct {
+	Id                  string `json:"id"`
+	ClusterId           string `json:"-"`
+	PeeringConnectionId string `json:"-"`
+	VpcId               string `json:"vpcId"`
+	VpcCidr             string `json:"vpcCidr"`
+	SubnetId            string `json:"subnetId"`
+	SubnetCidr          string `json:"subnetCidr"`
+}
+
+type AwsPeeringProperties struct {
+	OwnerId string `json:"ownerId"`
+	VpcId   string `json:"vpcId"`
+	VpcCidr string `json:"vpcCidr"`
+	Region  string `json:"region"`
+}
+
+type GcpPeering struct {
+	Id          string `json:"id"`
+	ClusterId   string `json:"-"`
+	ProjectId   string `json:"projectId"`
+	NetworkName string `json:"networkName"`
+}
+
+type GcpPeeringProperties struct {
+	ProjectId   string `json:"projectId"`
+	NetworkName string `json:"networkName"`
+}
+
+type AzurePeering struct {
+	Id                  string `json:"id"`
+	ClusterId           string `json:"-"`
+	PeeringConnectionId string `json:"-"`
+	VpcId               string `json:"vpcId"`
+	VpcCidr             string `json:"vpcCidr"`
+}
+
+type AzurePeeringProperties struct {
+	AppRegistrationId  string `json:"appRegistrationId"`
+	AppRegistrationKey string `json:"appRegistrationKey"`
+	TenantId           string `json:"tenantId"`
+	SubscriptionId     string `json:"subscriptionId"`
+	ResourceGroupName  string `json:"resourceGroupName"`
+	VnetName           string `json:"vnetName"`
+}
+
+type Artifact struct {
+	Id        string `json:"id"`
+	ClusterId string `json:"-"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Content   []byte `json:"-"`
+}
+
+type ArtifactLink struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Url  string `json:"url"`
+}
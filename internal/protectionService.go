@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+)
+
+// ProtectionService keeps the ids of the clusters which are refused to be deleted, as a comma separated list in the
+// config.
+type ProtectionService struct {
+	ConfigService ConfigService
+}
+
+func NewProtectionService() ProtectionService {
+	return ProtectionService{
+		ConfigService: NewConfigService(),
+	}
+}
+
+func (p ProtectionService) List() []string {
+	var clusterIds []string
+	for _, clusterId := range strings.Split(p.ConfigService.Get(ProtectedClusterIds), ",") {
+		if clusterId != "" {
+			clusterIds = append(clusterIds, clusterId)
+		}
+	}
+	return clusterIds
+}
+
+func (p ProtectionService) IsProtected(clusterId string) bool {
+	for _, protectedClusterId := range p.List() {
+		if protectedClusterId == clusterId {
+			return true
+		}
+	}
+	return false
+}
+
+func (p ProtectionService) Protect(clusterId string) {
+	if p.IsProtected(clusterId) {
+		return
+	}
+	clusterIds := append(p.List(), clusterId)
+	sort.Strings(clusterIds)
+	p.ConfigService.Set(ProtectedClusterIds, strings.Join(clusterIds, ","))
+}
+
+func (p ProtectionService) Unprotect(clusterId string) {
+	var clusterIds []string
+	for _, protectedClusterId := range p.List() {
+		if protectedClusterId != clusterId {
+			clusterIds = append(clusterIds, protectedClusterId)
+		}
+	}
+	p.ConfigService.Set(ProtectedClusterIds, strings.Join(clusterIds, ","))
+}
@@ -3,7 +3,11 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/hazelcast/hazelcast-cloud-cli/util"
@@ -12,18 +16,59 @@ import (
 )
 
 type AwsPeeringService struct {
-	client                     *hazelcastcloud.Client
-	customerPeeringProperties  *AwsCustomerPeeringProperties
-	hazelcastPeeringProperties *models.AwsPeeringProperties
-	ec2                        *ec2.EC2
+	client                      *hazelcastcloud.Client
+	customerPeeringProperties   *AwsCustomerPeeringProperties
+	hazelcastPeeringProperties  *models.AwsPeeringProperties
+	ec2                         Ec2Client
+	steps                       []awsPeeringStep
+	routeChanges                []AwsRouteChange
+	deletedPeeringConnectionIds []string
+}
+
+type AwsRouteChange struct {
+	RouteTableId         string
+	DestinationCidrBlock string
+	PreviousTarget       string
+	Target               string
+	Action               string
+}
+
+type awsPeeringStep struct {
+	description string
+	rollback    func() error
+}
+
+// Ec2Client is the subset of the EC2 API used by AwsPeeringService, satisfied by *ec2.EC2.
+type Ec2Client interface {
+	CreateVpcPeeringConnection(input *ec2.CreateVpcPeeringConnectionInput) (*ec2.CreateVpcPeeringConnectionOutput, error)
+	DeleteVpcPeeringConnection(input *ec2.DeleteVpcPeeringConnectionInput) (*ec2.DeleteVpcPeeringConnectionOutput, error)
+	DescribeVpcPeeringConnections(input *ec2.DescribeVpcPeeringConnectionsInput) (*ec2.DescribeVpcPeeringConnectionsOutput, error)
+	DescribeRouteTables(input *ec2.DescribeRouteTablesInput) (*ec2.DescribeRouteTablesOutput, error)
+	DeleteRoute(input *ec2.DeleteRouteInput) (*ec2.DeleteRouteOutput, error)
+	CreateRoute(input *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error)
+	ReplaceRoute(input *ec2.ReplaceRouteInput) (*ec2.ReplaceRouteOutput, error)
+	DescribeVpcs(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error)
+	DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error)
+	AuthorizeSecurityGroupIngress(input *ec2.AuthorizeSecurityGroupIngressInput) (*ec2.AuthorizeSecurityGroupIngressOutput, error)
+	AuthorizeSecurityGroupEgress(input *ec2.AuthorizeSecurityGroupEgressInput) (*ec2.AuthorizeSecurityGroupEgressOutput, error)
+	RevokeSecurityGroupIngress(input *ec2.RevokeSecurityGroupIngressInput) (*ec2.RevokeSecurityGroupIngressOutput, error)
+	RevokeSecurityGroupEgress(input *ec2.RevokeSecurityGroupEgressInput) (*ec2.RevokeSecurityGroupEgressOutput, error)
+	DescribeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error)
 }
 
 type AwsCustomerPeeringProperties struct {
-	SubAccountId string
-	ClusterId    string
-	Region       string
-	VpcId        string
-	SubnetIds    []string
+	SubAccountId  string
+	ClusterId     string
+	Region        string
+	VpcId         string
+	SubnetIds     []string
+	NoRollback    bool
+	ReplaceRoutes bool
+	// Profile and AssumeRoleArn select the credentials used for the customer account, defaults are used when empty.
+	Profile                 string
+	AssumeRoleArn           string
+	IngressSecurityGroupIds []string
+	EgressSecurityGroupIds  []string
 }
 
 func NewAwsPeeringService(client *hazelcastcloud.Client, customerProperties *AwsCustomerPeeringProperties) AwsPeeringService {
@@ -33,6 +78,15 @@ func NewAwsPeeringService(client *hazelcastcloud.Client, customerProperties *Aws
 	}
 }
 
+func NewAwsPeeringServiceWithEc2Client(client *hazelcastcloud.Client, customerProperties *AwsCustomerPeeringProperties,
+	ec2Client Ec2Client) AwsPeeringService {
+	return AwsPeeringService{
+		client:                    client,
+		customerPeeringProperties: customerProperties,
+		ec2:                       ec2Client,
+	}
+}
+
 func (s *AwsPeeringService) Create(indicator *util.LoadingIndicator) error {
 	indicator.SetStep("Peering Properties collecting...", 10)
 	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
@@ -45,25 +99,104 @@ func (s *AwsPeeringService) Create(indicator *util.LoadingIndicator) error {
 		return initClientErr
 	}
 
+	s.steps = nil
+	s.routeChanges = nil
+	createPeeringErr := s.createPeering(indicator)
+	if createPeeringErr != nil {
+		return s.rollback(createPeeringErr, indicator)
+	}
+	return nil
+}
+
+func (s *AwsPeeringService) createPeering(indicator *util.LoadingIndicator) error {
+	indicator.SetStep("Checking vpc...", 22)
+	vpcCidr, vpcCidrErr := s.getVpcCidr()
+	if vpcCidrErr != nil {
+		return vpcCidrErr
+	}
+	indicator.SetStep("Checking route tables...", 25)
+	routeTables, routeTablesErr := s.getRouteTables()
+	if routeTablesErr != nil {
+		return routeTablesErr
+	}
+	routeConflictsErr := s.checkRouteConflicts(routeTables)
+	if routeConflictsErr != nil {
+		return routeConflictsErr
+	}
 	indicator.SetStep("Creating vpc peering connection...", 30)
 	peeringConnectionId, peeringErr := s.createPeeringConnection()
 	if peeringErr != nil {
 		return peeringErr
 	}
 	indicator.SetStep("Creating routes...", 40)
-	createRouteErr := s.createRoute(peeringConnectionId)
-	if createRouteErr != nil {
-		return createRouteErr
+	createRoutesErr := s.createRoutes(peeringConnectionId, routeTables)
+	if createRoutesErr != nil {
+		return createRoutesErr
+	}
+	indicator.SetStep("Authorizing security group rules...", 45)
+	securityGroupRulesErr := s.authorizeSecurityGroupRules()
+	if securityGroupRulesErr != nil {
+		return securityGroupRulesErr
 	}
 	indicator.SetStep("Verifying vpc peering connection...", 50)
 	subnets, subnetsErr := s.getSubnets()
 	if subnetsErr != nil {
 		return subnetsErr
 	}
+	_, _, acceptErr := s.client.AwsPeering.Accept(context.Background(), &models.AcceptAwsPeeringInput{
+		ClusterId:           s.customerPeeringProperties.ClusterId,
+		VpcId:               s.customerPeeringProperties.VpcId,
+		VpcCidr:             vpcCidr,
+		PeeringConnectionId: peeringConnectionId,
+		Subnets:             subnets,
+	})
+	if acceptErr != nil {
+		return acceptErr
+	}
+	return nil
+}
+
+// Accept completes the Hazelcast side of a peering whose customer side was created outside of the CLI, e.g. from the
+// output of Emit. Your account is only read, the vpc peering connection is looked up when peeringConnectionId is
+// empty. It returns the id of the accepted vpc peering connection.
+func (s *AwsPeeringService) Accept(peeringConnectionId string, indicator *util.LoadingIndicator) (string, error) {
+	indicator.SetStep("Peering Properties collecting...", 10)
+	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
+	if initHazelcastPeeringPropertiesErr != nil {
+		return "", initHazelcastPeeringPropertiesErr
+	}
+	indicator.SetStep("Clients initializing...", 20)
+	initClientErr := s.initClients()
+	if initClientErr != nil {
+		return "", initClientErr
+	}
+	indicator.SetStep("Checking vpc...", 30)
 	vpcCidr, vpcCidrErr := s.getVpcCidr()
 	if vpcCidrErr != nil {
-		return vpcCidrErr
+		return "", vpcCidrErr
+	}
+	if peeringConnectionId == "" {
+		indicator.SetStep("Finding vpc peering connection...", 40)
+		peeringConnectionIds, peeringConnectionIdsErr := s.getPeeringConnectionIds()
+		if peeringConnectionIdsErr != nil {
+			return "", peeringConnectionIdsErr
+		}
+		if len(peeringConnectionIds) == 0 {
+			return "", fmt.Errorf("no vpc peering connection between %s and %s, apply the customer side of the peering first",
+				s.customerPeeringProperties.VpcId, s.hazelcastPeeringProperties.VpcId)
+		}
+		if len(peeringConnectionIds) > 1 {
+			return "", fmt.Errorf("vpc peering connections %s are between %s and %s, please set one with --peering-connection-id",
+				strings.Join(peeringConnectionIds, ", "), s.customerPeeringProperties.VpcId, s.hazelcastPeeringProperties.VpcId)
+		}
+		peeringConnectionId = peeringConnectionIds[0]
+	}
+	indicator.SetStep("Checking subnets...", 50)
+	subnets, subnetsErr := s.getSubnets()
+	if subnetsErr != nil {
+		return "", subnetsErr
 	}
+	indicator.SetStep("Peering accepting...", 70)
 	_, _, acceptErr := s.client.AwsPeering.Accept(context.Background(), &models.AcceptAwsPeeringInput{
 		ClusterId:           s.customerPeeringProperties.ClusterId,
 		VpcId:               s.customerPeeringProperties.VpcId,
@@ -72,11 +205,228 @@ func (s *AwsPeeringService) Create(indicator *util.LoadingIndicator) error {
 		Subnets:             subnets,
 	})
 	if acceptErr != nil {
-		return acceptErr
+		return "", acceptErr
+	}
+	return peeringConnectionId, nil
+}
+
+// Delete removes the routes to the vpc peering connection of the peering and the connection itself from your account,
+// then deletes the peering from the Hazelcast cluster. The connection is shared by the peerings of the subnets of a
+// vpc: while other peerings of the vpc remain, only the routes of the route tables of the subnet of the peering which
+// no remaining subnet uses are removed.
+func (s *AwsPeeringService) Delete(peeringId string, indicator *util.LoadingIndicator) error {
+	indicator.SetStep("Peering Properties collecting...", 10)
+	peerings, peeringsErr := s.getPeerings()
+	if peeringsErr != nil {
+		return peeringsErr
+	}
+	var peering *models.AwsPeering
+	var remainingSubnetIds []string
+	remainingPeerings := false
+	for k := range peerings {
+		if peerings[k].Id == peeringId {
+			peering = &peerings[k]
+		}
+	}
+	if peering == nil {
+		return fmt.Errorf("peering %s not found on cluster %s", peeringId, s.customerPeeringProperties.ClusterId)
+	}
+	for _, otherPeering := range peerings {
+		if otherPeering.Id != peeringId && otherPeering.VpcId == peering.VpcId {
+			remainingPeerings = true
+			if otherPeering.SubnetId != "" {
+				remainingSubnetIds = append(remainingSubnetIds, otherPeering.SubnetId)
+			}
+		}
+	}
+	s.customerPeeringProperties.VpcId = peering.VpcId
+	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
+	if initHazelcastPeeringPropertiesErr != nil {
+		return initHazelcastPeeringPropertiesErr
+	}
+	indicator.SetStep("Clients initializing...", 20)
+	initClientErr := s.initClients()
+	if initClientErr != nil {
+		return initClientErr
+	}
+
+	s.routeChanges = nil
+	s.deletedPeeringConnectionIds = nil
+	indicator.SetStep("Finding vpc peering connection...", 30)
+	peeringConnectionIds, peeringConnectionIdsErr := s.getPeeringConnectionIds()
+	if peeringConnectionIdsErr != nil {
+		return peeringConnectionIdsErr
+	}
+	routeTableIds, routeTableIdsErr := s.getDeletedRouteTableIds(peering.SubnetId, remainingPeerings, remainingSubnetIds)
+	if routeTableIdsErr != nil {
+		return routeTableIdsErr
+	}
+	for _, peeringConnectionId := range peeringConnectionIds {
+		indicator.SetStep("Deleting routes...", 50)
+		deleteRoutesErr := s.deleteRoutes(peeringConnectionId, routeTableIds)
+		if deleteRoutesErr != nil {
+			return deleteRoutesErr
+		}
+		if remainingPeerings {
+			continue
+		}
+		indicator.SetStep("Deleting vpc peering connection...", 70)
+		_, deleteErr := s.ec2.DeleteVpcPeeringConnection(&ec2.DeleteVpcPeeringConnectionInput{
+			VpcPeeringConnectionId: aws.String(peeringConnectionId),
+		})
+		if deleteErr != nil && !isAwsErrorCode(deleteErr, "InvalidVpcPeeringConnectionID.NotFound") {
+			return deleteErr
+		}
+		s.deletedPeeringConnectionIds = append(s.deletedPeeringConnectionIds, peeringConnectionId)
+	}
+
+	indicator.SetStep("Deleting Hazelcast peering...", 90)
+	_, _, deletePeeringErr := s.client.AwsPeering.Delete(context.Background(), &models.DeleteAwsPeeringInput{
+		Id: peeringId,
+	})
+	if deletePeeringErr != nil {
+		return deletePeeringErr
 	}
 	return nil
 }
 
+// getDeletedRouteTableIds returns the route tables whose routes to the connection Delete removes, nil for every route
+// table of the vpc when no other peering of the vpc remains.
+func (s *AwsPeeringService) getDeletedRouteTableIds(subnetId string, remainingPeerings bool, remainingSubnetIds []string) (map[string]bool, error) {
+	if !remainingPeerings {
+		return nil, nil
+	}
+	routeTableIds := map[string]bool{}
+	if subnetId == "" {
+		return routeTableIds, nil
+	}
+	s.customerPeeringProperties.SubnetIds = []string{subnetId}
+	routeTables, routeTablesErr := s.getRouteTables()
+	if routeTablesErr != nil {
+		return nil, routeTablesErr
+	}
+	for _, table := range routeTables {
+		routeTableIds[aws.StringValue(table.RouteTableId)] = true
+	}
+	if len(remainingSubnetIds) == 0 {
+		return routeTableIds, nil
+	}
+	s.customerPeeringProperties.SubnetIds = remainingSubnetIds
+	remainingRouteTables, remainingRouteTablesErr := s.getRouteTables()
+	if remainingRouteTablesErr != nil {
+		return nil, remainingRouteTablesErr
+	}
+	for _, table := range remainingRouteTables {
+		delete(routeTableIds, aws.StringValue(table.RouteTableId))
+	}
+	return routeTableIds, nil
+}
+
+// Diagnose compares the peerings of the cluster with the vpc peering connections, route tables and, when egress
+// security groups are given, security group rules in your account.
+func (s *AwsPeeringService) Diagnose(indicator *util.LoadingIndicator) ([]PeeringCheck, error) {
+	indicator.SetStep("Peering Properties collecting...", 10)
+	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
+	if initHazelcastPeeringPropertiesErr != nil {
+		return nil, initHazelcastPeeringPropertiesErr
+	}
+	indicator.SetStep("Clients initializing...", 20)
+	initClientErr := s.initClients()
+	if initClientErr != nil {
+		return nil, initClientErr
+	}
+	cluster, clusterErr := getCluster(s.client, s.customerPeeringProperties.ClusterId)
+	if clusterErr != nil {
+		return nil, clusterErr
+	}
+	peerings, _, peeringsErr := s.client.AwsPeering.List(context.Background(), &models.ListAwsPeeringsInput{
+		ClusterId: s.customerPeeringProperties.ClusterId,
+	})
+	if peeringsErr != nil {
+		return nil, peeringsErr
+	}
+
+	checks := []PeeringCheck{newHazelcastPeeringCheck(len(*peerings), "hzcloud aws-peering create")}
+	var vpcIds []string
+	vpcCidrs := map[string]string{}
+	subnetIds := map[string][]string{}
+	for _, peering := range *peerings {
+		if _, ok := vpcCidrs[peering.VpcId]; !ok {
+			vpcIds = append(vpcIds, peering.VpcId)
+		}
+		vpcCidrs[peering.VpcId] = peering.VpcCidr
+		if peering.SubnetId != "" {
+			subnetIds[peering.VpcId] = append(subnetIds[peering.VpcId], peering.SubnetId)
+		}
+	}
+	indicator.SetStep("Checking vpc peering connections...", 40)
+	for _, vpcId := range vpcIds {
+		s.customerPeeringProperties.VpcId = vpcId
+		s.customerPeeringProperties.SubnetIds = subnetIds[vpcId]
+		checks = append(checks, newCidrOverlapCheck(fmt.Sprintf("Cidr of %s", vpcId), vpcCidrs[vpcId], cluster.Networking.CidrBlock))
+		peeringConnectionCheck, peeringConnectionId, peeringConnectionErr := s.checkPeeringConnection()
+		if peeringConnectionErr != nil {
+			return nil, peeringConnectionErr
+		}
+		checks = append(checks, peeringConnectionCheck)
+		routeChecks, routeChecksErr := s.checkRoutes(peeringConnectionId)
+		if routeChecksErr != nil {
+			return nil, routeChecksErr
+		}
+		checks = append(checks, routeChecks...)
+	}
+	indicator.SetStep("Checking security groups...", 80)
+	securityGroupChecks, securityGroupChecksErr := s.checkSecurityGroups(cluster.Port)
+	if securityGroupChecksErr != nil {
+		return nil, securityGroupChecksErr
+	}
+	return append(checks, securityGroupChecks...), nil
+}
+
+// DeletedPeeringConnectionIds returns the vpc peering connections deleted by the last successful Delete.
+func (s *AwsPeeringService) DeletedPeeringConnectionIds() []string {
+	return s.deletedPeeringConnectionIds
+}
+
+// RouteChanges returns the route table changes made by the last successful Create or Delete.
+func (s *AwsPeeringService) RouteChanges() []AwsRouteChange {
+	return s.routeChanges
+}
+
+func (s *AwsPeeringService) record(description string, rollback func() error) {
+	s.steps = append(s.steps, awsPeeringStep{
+		description: description,
+		rollback:    rollback,
+	})
+}
+
+func (s *AwsPeeringService) rollback(cause error, indicator *util.LoadingIndicator) error {
+	if len(s.steps) == 0 {
+		return cause
+	}
+	if s.customerPeeringProperties.NoRollback {
+		var descriptions []string
+		for _, step := range s.steps {
+			descriptions = append(descriptions, step.description)
+		}
+		return fmt.Errorf("%s. Rollback is disabled, following changes are left behind: %s", cause, strings.Join(descriptions, ", "))
+	}
+	indicator.SetStep("Rolling back...", 90)
+	var failedSteps []string
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		rollbackErr := s.steps[i].rollback()
+		if rollbackErr != nil {
+			failedSteps = append(failedSteps, fmt.Sprintf("%s (%s)", s.steps[i].description, rollbackErr))
+		}
+	}
+	s.steps = nil
+	s.routeChanges = nil
+	if len(failedSteps) != 0 {
+		return fmt.Errorf("%s. Rollback failed, following changes are left behind: %s", cause, strings.Join(failedSteps, ", "))
+	}
+	return fmt.Errorf("%s. All changes are rolled back", cause)
+}
+
 func (s *AwsPeeringService) initHazelcastPeeringProperties() error {
 	hazelcastPeeringProperties, _, hazelcastPeeringPropertiesErr := s.client.AwsPeering.GetProperties(context.Background(), &models.GetAwsPeeringPropertiesInput{
 		ClusterId: s.customerPeeringProperties.ClusterId,
@@ -89,13 +439,30 @@ func (s *AwsPeeringService) initHazelcastPeeringProperties() error {
 }
 
 func (s *AwsPeeringService) initClients() error {
-	sess, sessionErr := session.NewSession(&aws.Config{
-		Region: aws.String(s.customerPeeringProperties.Region)},
-	)
+	if s.ec2 != nil {
+		return nil
+	}
+	config := aws.Config{}
+	if s.customerPeeringProperties.Region != "" {
+		config.Region = aws.String(s.customerPeeringProperties.Region)
+	}
+	sess, sessionErr := session.NewSessionWithOptions(session.Options{
+		Config:            config,
+		Profile:           s.customerPeeringProperties.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
 	if sessionErr != nil {
 		return sessionErr
 	}
-	s.ec2 = ec2.New(sess)
+	if aws.StringValue(sess.Config.Region) == "" {
+		return fmt.Errorf("region of vpc %s could not be determined, please set it with --region", s.customerPeeringProperties.VpcId)
+	}
+	s.customerPeeringProperties.Region = aws.StringValue(sess.Config.Region)
+	if s.customerPeeringProperties.AssumeRoleArn != "" {
+		s.ec2 = ec2.New(sess, &aws.Config{Credentials: stscreds.NewCredentials(sess, s.customerPeeringProperties.AssumeRoleArn)})
+	} else {
+		s.ec2 = ec2.New(sess)
+	}
 	return nil
 }
 
@@ -109,12 +476,19 @@ func (s *AwsPeeringService) createPeeringConnection() (string, error) {
 	if peeringErr != nil {
 		return "", peeringErr
 	}
-	return aws.StringValue(peering.VpcPeeringConnection.VpcPeeringConnectionId), nil
+	peeringConnectionId := aws.StringValue(peering.VpcPeeringConnection.VpcPeeringConnectionId)
+	s.record(fmt.Sprintf("vpc peering connection %s created", peeringConnectionId), func() error {
+		_, deleteErr := s.ec2.DeleteVpcPeeringConnection(&ec2.DeleteVpcPeeringConnectionInput{
+			VpcPeeringConnectionId: aws.String(peeringConnectionId),
+		})
+		return deleteErr
+	})
+	return peeringConnectionId, nil
 }
 
-func (s *AwsPeeringService) createRoute(peeringConnectionId string) error {
-	var routeTableIds []string
-	var routeTables *ec2.DescribeRouteTablesOutput
+func (s *AwsPeeringService) getRouteTables() ([]*ec2.RouteTable, error) {
+	var routeTables []*ec2.RouteTable
+	routeTableIds := map[string]bool{}
 	for _, subnet := range s.customerPeeringProperties.SubnetIds {
 		tables, routeTablesErr := s.ec2.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
 			Filters: []*ec2.Filter{
@@ -124,10 +498,8 @@ func (s *AwsPeeringService) createRoute(peeringConnectionId string) error {
 				},
 			},
 		})
-		routeTables = tables
-
 		if routeTablesErr != nil {
-			return routeTablesErr
+			return nil, routeTablesErr
 		}
 
 		if len(tables.RouteTables) == 0 {
@@ -144,35 +516,401 @@ func (s *AwsPeeringService) createRoute(peeringConnectionId string) error {
 				},
 			})
 			if tablesErr != nil {
-				return tablesErr
+				return nil, tablesErr
+			}
+			tables = defaultTables
+		}
+		if len(tables.RouteTables) == 0 {
+			return nil, fmt.Errorf("no route table found for subnet %s", subnet)
+		}
+		for _, table := range tables.RouteTables {
+			if !routeTableIds[aws.StringValue(table.RouteTableId)] {
+				routeTableIds[aws.StringValue(table.RouteTableId)] = true
+				routeTables = append(routeTables, table)
 			}
-			routeTables = defaultTables
 		}
-		routeTableIds = append(routeTableIds, aws.StringValue(routeTables.RouteTables[0].RouteTableId))
 	}
+	return routeTables, nil
+}
 
-	for _, routeTableId := range routeTableIds {
-		s.ec2.DeleteRoute(&ec2.DeleteRouteInput{
-			DestinationCidrBlock: aws.String(s.hazelcastPeeringProperties.VpcCidr),
-			RouteTableId:         aws.String(routeTableId),
-		})
+func (s *AwsPeeringService) checkRouteConflicts(routeTables []*ec2.RouteTable) error {
+	if s.customerPeeringProperties.ReplaceRoutes {
+		return nil
+	}
+	var conflicts []string
+	for _, table := range routeTables {
+		route := findRoute(table, s.hazelcastPeeringProperties.VpcCidr)
+		if route != nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s routes %s to %s", aws.StringValue(table.RouteTableId),
+				s.hazelcastPeeringProperties.VpcCidr, getRouteTarget(route)))
+		}
+	}
+	if len(conflicts) != 0 {
+		return fmt.Errorf("conflicting routes found, use --replace-routes to replace them: %s", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+func (s *AwsPeeringService) createRoutes(peeringConnectionId string, routeTables []*ec2.RouteTable) error {
+	destinationCidrBlock := s.hazelcastPeeringProperties.VpcCidr
+	for _, table := range routeTables {
+		routeTableId := aws.StringValue(table.RouteTableId)
+		previousRoute := findRoute(table, destinationCidrBlock)
+		if previousRoute != nil {
+			_, replaceRouteErr := s.ec2.ReplaceRoute(&ec2.ReplaceRouteInput{
+				DestinationCidrBlock:   aws.String(destinationCidrBlock),
+				RouteTableId:           aws.String(routeTableId),
+				VpcPeeringConnectionId: aws.String(peeringConnectionId),
+			})
+			if replaceRouteErr != nil {
+				return replaceRouteErr
+			}
+			s.record(fmt.Sprintf("route to %s in %s replaced", destinationCidrBlock, routeTableId), func() error {
+				_, restoreRouteErr := s.ec2.ReplaceRoute(newReplaceRouteInput(routeTableId, previousRoute))
+				return restoreRouteErr
+			})
+			s.routeChanges = append(s.routeChanges, AwsRouteChange{
+				RouteTableId:         routeTableId,
+				DestinationCidrBlock: destinationCidrBlock,
+				PreviousTarget:       getRouteTarget(previousRoute),
+				Target:               peeringConnectionId,
+				Action:               "Replaced",
+			})
+			continue
+		}
 
 		_, createRouteErr := s.ec2.CreateRoute(&ec2.CreateRouteInput{
-			DestinationCidrBlock:   aws.String(s.hazelcastPeeringProperties.VpcCidr),
+			DestinationCidrBlock:   aws.String(destinationCidrBlock),
 			RouteTableId:           aws.String(routeTableId),
 			VpcPeeringConnectionId: aws.String(peeringConnectionId),
 		})
-
 		if createRouteErr != nil {
 			return createRouteErr
 		}
+		s.record(fmt.Sprintf("route to %s in %s created", destinationCidrBlock, routeTableId), func() error {
+			return s.deleteRoute(routeTableId, destinationCidrBlock)
+		})
+		s.routeChanges = append(s.routeChanges, AwsRouteChange{
+			RouteTableId:         routeTableId,
+			DestinationCidrBlock: destinationCidrBlock,
+			Target:               peeringConnectionId,
+			Action:               "Created",
+		})
 	}
 
 	return nil
 }
 
+func (s *AwsPeeringService) deleteRoute(routeTableId string, destinationCidrBlock string) error {
+	_, deleteRouteErr := s.ec2.DeleteRoute(&ec2.DeleteRouteInput{
+		DestinationCidrBlock: aws.String(destinationCidrBlock),
+		RouteTableId:         aws.String(routeTableId),
+	})
+	return deleteRouteErr
+}
+
+func (s *AwsPeeringService) getPeerings() ([]models.AwsPeering, error) {
+	peerings, _, peeringsErr := s.client.AwsPeering.List(context.Background(), &models.ListAwsPeeringsInput{
+		ClusterId: s.customerPeeringProperties.ClusterId,
+	})
+	if peeringsErr != nil {
+		return nil, peeringsErr
+	}
+	return *peerings, nil
+}
+
+func (s *AwsPeeringService) getPeeringConnectionIds() ([]string, error) {
+	connections, connectionsErr := s.ec2.DescribeVpcPeeringConnections(&ec2.DescribeVpcPeeringConnectionsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("requester-vpc-info.vpc-id"),
+				Values: aws.StringSlice([]string{s.customerPeeringProperties.VpcId}),
+			},
+			{
+				Name:   aws.String("accepter-vpc-info.vpc-id"),
+				Values: aws.StringSlice([]string{s.hazelcastPeeringProperties.VpcId}),
+			},
+			{
+				Name:   aws.String("status-code"),
+				Values: aws.StringSlice([]string{"active", "pending-acceptance"}),
+			},
+		},
+	})
+	if connectionsErr != nil {
+		return nil, connectionsErr
+	}
+	var peeringConnectionIds []string
+	for _, connection := range connections.VpcPeeringConnections {
+		peeringConnectionIds = append(peeringConnectionIds, aws.StringValue(connection.VpcPeeringConnectionId))
+	}
+	return peeringConnectionIds, nil
+}
+
+// deleteRoutes removes the routes to the connection from the route tables of the vpc, only from the route tables with
+// the ids when they are not nil.
+func (s *AwsPeeringService) deleteRoutes(peeringConnectionId string, routeTableIds map[string]bool) error {
+	tables, routeTablesErr := s.ec2.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: aws.StringSlice([]string{s.customerPeeringProperties.VpcId}),
+			},
+		},
+	})
+	if routeTablesErr != nil {
+		return routeTablesErr
+	}
+	for _, table := range tables.RouteTables {
+		if routeTableIds != nil && !routeTableIds[aws.StringValue(table.RouteTableId)] {
+			continue
+		}
+		for _, route := range table.Routes {
+			if aws.StringValue(route.VpcPeeringConnectionId) != peeringConnectionId {
+				continue
+			}
+			routeTableId := aws.StringValue(table.RouteTableId)
+			destinationCidrBlock := aws.StringValue(route.DestinationCidrBlock)
+			deleteRouteErr := s.deleteRoute(routeTableId, destinationCidrBlock)
+			if deleteRouteErr != nil && !isAwsErrorCode(deleteRouteErr, "InvalidRoute.NotFound") {
+				return deleteRouteErr
+			}
+			s.routeChanges = append(s.routeChanges, AwsRouteChange{
+				RouteTableId:         routeTableId,
+				DestinationCidrBlock: destinationCidrBlock,
+				PreviousTarget:       peeringConnectionId,
+				Action:               "Deleted",
+			})
+		}
+	}
+	return nil
+}
+
+func (s *AwsPeeringService) checkPeeringConnection() (PeeringCheck, string, error) {
+	name := fmt.Sprintf("Peering connection of %s", s.customerPeeringProperties.VpcId)
+	connections, connectionsErr := s.ec2.DescribeVpcPeeringConnections(&ec2.DescribeVpcPeeringConnectionsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("requester-vpc-info.vpc-id"),
+				Values: aws.StringSlice([]string{s.customerPeeringProperties.VpcId}),
+			},
+			{
+				Name:   aws.String("accepter-vpc-info.vpc-id"),
+				Values: aws.StringSlice([]string{s.hazelcastPeeringProperties.VpcId}),
+			},
+		},
+	})
+	if connectionsErr != nil {
+		return PeeringCheck{}, "", connectionsErr
+	}
+	if len(connections.VpcPeeringConnections) == 0 {
+		return failedCheck(name, fmt.Sprintf("no vpc peering connection between %s and %s", s.customerPeeringProperties.VpcId,
+			s.hazelcastPeeringProperties.VpcId), "Delete the peering and create it again with `hzcloud aws-peering create`."), "", nil
+	}
+	connection := connections.VpcPeeringConnections[0]
+	for _, c := range connections.VpcPeeringConnections {
+		if aws.StringValue(c.Status.Code) == "active" {
+			connection = c
+		}
+	}
+	peeringConnectionId := aws.StringValue(connection.VpcPeeringConnectionId)
+	status := aws.StringValue(connection.Status.Code)
+	switch status {
+	case "active":
+		return passedCheck(name, fmt.Sprintf("%s is active", peeringConnectionId)), peeringConnectionId, nil
+	case "pending-acceptance", "provisioning":
+		return failedCheck(name, fmt.Sprintf("%s is %s", peeringConnectionId, status),
+			"The connection is not accepted by Hazelcast yet, wait a few minutes and diagnose again."), peeringConnectionId, nil
+	default:
+		return failedCheck(name, fmt.Sprintf("%s is %s", peeringConnectionId, status),
+			"Delete the peering and create it again with `hzcloud aws-peering create`."), peeringConnectionId, nil
+	}
+}
+
+func (s *AwsPeeringService) checkRoutes(peeringConnectionId string) ([]PeeringCheck, error) {
+	if len(s.customerPeeringProperties.SubnetIds) == 0 {
+		return nil, nil
+	}
+	routeTables, routeTablesErr := s.getRouteTables()
+	if routeTablesErr != nil {
+		return []PeeringCheck{failedCheck(fmt.Sprintf("Route tables of %s", s.customerPeeringProperties.VpcId),
+			routeTablesErr.Error(), "Check that the peered subnets still exist.")}, nil
+	}
+	destinationCidrBlock := s.hazelcastPeeringProperties.VpcCidr
+	remediation := fmt.Sprintf("Add a route to %s via the vpc peering connection, or create the peering again with --replace-routes.",
+		destinationCidrBlock)
+	var checks []PeeringCheck
+	for _, table := range routeTables {
+		name := fmt.Sprintf("Route in %s", aws.StringValue(table.RouteTableId))
+		route := findRoute(table, destinationCidrBlock)
+		switch {
+		case route == nil:
+			checks = append(checks, failedCheck(name, fmt.Sprintf("no route to %s", destinationCidrBlock), remediation))
+		case peeringConnectionId == "" || aws.StringValue(route.VpcPeeringConnectionId) != peeringConnectionId:
+			checks = append(checks, failedCheck(name, fmt.Sprintf("%s is routed to %s", destinationCidrBlock, getRouteTarget(route)), remediation))
+		case aws.StringValue(route.State) == "blackhole":
+			checks = append(checks, failedCheck(name, fmt.Sprintf("route to %s is a blackhole", destinationCidrBlock), remediation))
+		default:
+			checks = append(checks, passedCheck(name, fmt.Sprintf("%s is routed to %s", destinationCidrBlock, peeringConnectionId)))
+		}
+	}
+	return checks, nil
+}
+
+// checkSecurityGroups checks that the egress security groups allow the traffic to the Hazelcast vpc on the port of the
+// cluster, as the rules authorized by Create do.
+func (s *AwsPeeringService) checkSecurityGroups(port int) ([]PeeringCheck, error) {
+	if len(s.customerPeeringProperties.EgressSecurityGroupIds) == 0 {
+		return nil, nil
+	}
+	groups, groupsErr := s.ec2.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+		GroupIds: aws.StringSlice(s.customerPeeringProperties.EgressSecurityGroupIds),
+	})
+	if groupsErr != nil {
+		return nil, groupsErr
+	}
+	hazelcastVpcCidr := s.hazelcastPeeringProperties.VpcCidr
+	var checks []PeeringCheck
+	for _, group := range groups.SecurityGroups {
+		name := fmt.Sprintf("Security group %s", aws.StringValue(group.GroupId))
+		if allowsTraffic(group.IpPermissionsEgress, hazelcastVpcCidr, port) {
+			checks = append(checks, passedCheck(name, fmt.Sprintf("outbound traffic to %s on port %d is allowed",
+				hazelcastVpcCidr, port)))
+		} else {
+			checks = append(checks, failedCheck(name, fmt.Sprintf("outbound traffic to %s on port %d is not allowed",
+				hazelcastVpcCidr, port),
+				"Allow it with --egress-security-group-ids of `hzcloud aws-peering create` or add an outbound rule."))
+		}
+	}
+	return checks, nil
+}
+
+func allowsTraffic(permissions []*ec2.IpPermission, cidr string, port int) bool {
+	for _, permission := range permissions {
+		protocol := aws.StringValue(permission.IpProtocol)
+		if protocol != "-1" && (protocol != "tcp" || aws.Int64Value(permission.FromPort) > int64(port) ||
+			aws.Int64Value(permission.ToPort) < int64(port)) {
+			continue
+		}
+		for _, ipRange := range permission.IpRanges {
+			if cidrContains(aws.StringValue(ipRange.CidrIp), cidr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *AwsPeeringService) authorizeSecurityGroupRules() error {
+	if len(s.customerPeeringProperties.IngressSecurityGroupIds) == 0 && len(s.customerPeeringProperties.EgressSecurityGroupIds) == 0 {
+		return nil
+	}
+	cluster, _, clusterErr := s.client.EnterpriseCluster.Get(context.Background(), &models.GetEnterpriseClusterInput{
+		ClusterId: s.customerPeeringProperties.ClusterId,
+	})
+	if clusterErr != nil {
+		return clusterErr
+	}
+	permissions := []*ec2.IpPermission{
+		{
+			IpProtocol: aws.String("tcp"),
+			FromPort:   aws.Int64(int64(cluster.Port)),
+			ToPort:     aws.Int64(int64(cluster.Port)),
+			IpRanges: []*ec2.IpRange{
+				{
+					CidrIp:      aws.String(s.hazelcastPeeringProperties.VpcCidr),
+					Description: aws.String(fmt.Sprintf("Hazelcast Cloud cluster %s", s.customerPeeringProperties.ClusterId)),
+				},
+			},
+		},
+	}
+
+	for _, groupId := range s.customerPeeringProperties.IngressSecurityGroupIds {
+		securityGroupId := groupId
+		_, authorizeErr := s.ec2.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(securityGroupId),
+			IpPermissions: permissions,
+		})
+		if isAwsErrorCode(authorizeErr, "InvalidPermission.Duplicate") {
+			continue
+		}
+		if authorizeErr != nil {
+			return authorizeErr
+		}
+		s.record(fmt.Sprintf("ingress rule for port %d in %s authorized", cluster.Port, securityGroupId), func() error {
+			_, revokeErr := s.ec2.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{
+				GroupId:       aws.String(securityGroupId),
+				IpPermissions: permissions,
+			})
+			return revokeErr
+		})
+	}
+
+	for _, groupId := range s.customerPeeringProperties.EgressSecurityGroupIds {
+		securityGroupId := groupId
+		_, authorizeErr := s.ec2.AuthorizeSecurityGroupEgress(&ec2.AuthorizeSecurityGroupEgressInput{
+			GroupId:       aws.String(securityGroupId),
+			IpPermissions: permissions,
+		})
+		if isAwsErrorCode(authorizeErr, "InvalidPermission.Duplicate") {
+			continue
+		}
+		if authorizeErr != nil {
+			return authorizeErr
+		}
+		s.record(fmt.Sprintf("egress rule for port %d in %s authorized", cluster.Port, securityGroupId), func() error {
+			_, revokeErr := s.ec2.RevokeSecurityGroupEgress(&ec2.RevokeSecurityGroupEgressInput{
+				GroupId:       aws.String(securityGroupId),
+				IpPermissions: permissions,
+			})
+			return revokeErr
+		})
+	}
+	return nil
+}
+
+func isAwsErrorCode(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
+
+func findRoute(table *ec2.RouteTable, destinationCidrBlock string) *ec2.Route {
+	for _, route := range table.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == destinationCidrBlock {
+			return route
+		}
+	}
+	return nil
+}
+
+func getRouteTarget(route *ec2.Route) string {
+	for _, target := range []*string{route.VpcPeeringConnectionId, route.GatewayId, route.NatGatewayId, route.TransitGatewayId,
+		route.NetworkInterfaceId, route.InstanceId} {
+		if aws.StringValue(target) != "" {
+			return aws.StringValue(target)
+		}
+	}
+	return "unknown"
+}
+
+func newReplaceRouteInput(routeTableId string, route *ec2.Route) *ec2.ReplaceRouteInput {
+	return &ec2.ReplaceRouteInput{
+		DestinationCidrBlock:   route.DestinationCidrBlock,
+		RouteTableId:           aws.String(routeTableId),
+		GatewayId:              route.GatewayId,
+		InstanceId:             route.InstanceId,
+		NatGatewayId:           route.NatGatewayId,
+		TransitGatewayId:       route.TransitGatewayId,
+		NetworkInterfaceId:     route.NetworkInterfaceId,
+		VpcPeeringConnectionId: route.VpcPeeringConnectionId,
+	}
+}
+
 func (s *AwsPeeringService) getVpcCidr() (string, error) {
 	vpcs, vpcsErr := s.ec2.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: aws.StringSlice([]string{s.customerPeeringProperties.VpcId})})
+	if isAwsErrorCode(vpcsErr, "InvalidVpcID.NotFound") {
+		return "", fmt.Errorf("vpc id %s not found in region %s, please set the region of your vpc with --region",
+			s.customerPeeringProperties.VpcId, s.customerPeeringProperties.Region)
+	}
 	if vpcsErr != nil {
 		return "", vpcsErr
 	}
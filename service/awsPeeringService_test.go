@@ -0,0 +1,302 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-cli/service/fakecloud"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+)
+
+const hazelcastVpcCidr = "10.80.0.0/16"
+
+// newPeeringTestClient starts a fake Hazelcast Cloud API with a running enterprise cluster and returns a client
+// logged into it together with the id of the cluster.
+func newPeeringTestClient(t *testing.T, cloudProvider string, region string) (*fakeapi.Server, *hazelcastcloud.Client, string) {
+	server := fakeapi.NewServer()
+	t.Cleanup(server.Close)
+	cluster := fakeapi.Cluster{
+		Name:          "mycluster",
+		Port:          31000,
+		ProductType:   fakeapi.ProductType{Name: "Enterprise"},
+		State:         fakeapi.StateRunning,
+		CloudProvider: fakeapi.CloudProvider{Name: cloudProvider, Region: region},
+	}
+	cluster.Networking.CidrBlock = hazelcastVpcCidr
+	clusterId := server.AddCluster(cluster)
+	return server, server.ClientFactory()(), clusterId
+}
+
+// newAwsPeeringTestVpc adds vpc-1 with subnet-1 on the main route table and subnet-2 on its own route table, it
+// returns the ids of both route tables.
+func newAwsPeeringTestVpc() (*fakecloud.Ec2, string, string) {
+	ec2Client := fakecloud.NewEc2()
+	mainRouteTableId := ec2Client.AddVpc("vpc-1", "10.0.0.0/16")
+	ec2Client.AddSubnet("subnet-1", "vpc-1", "10.0.1.0/24", "")
+	ec2Client.AddSubnet("subnet-2", "vpc-1", "10.0.2.0/24", "rtb-subnet-2")
+	ec2Client.AddSecurityGroup("sg-1", "vpc-1")
+	return ec2Client, mainRouteTableId, "rtb-subnet-2"
+}
+
+func newAwsPeeringTestService(client *hazelcastcloud.Client, clusterId string, ec2Client *fakecloud.Ec2) AwsPeeringService {
+	return NewAwsPeeringServiceWithEc2Client(client, &AwsCustomerPeeringProperties{
+		ClusterId:               clusterId,
+		VpcId:                   "vpc-1",
+		SubnetIds:               []string{"subnet-1", "subnet-2"},
+		IngressSecurityGroupIds: []string{"sg-1"},
+	}, ec2Client)
+}
+
+func activePeeringConnectionIds(ec2Client *fakecloud.Ec2) []string {
+	var peeringConnectionIds []string
+	for id, connection := range ec2Client.PeeringConnections {
+		if aws.StringValue(connection.Status.Code) != "deleted" {
+			peeringConnectionIds = append(peeringConnectionIds, id)
+		}
+	}
+	return peeringConnectionIds
+}
+
+func TestAwsPeeringServiceCreate(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	ec2Client, mainRouteTableId, subnetRouteTableId := newAwsPeeringTestVpc()
+	service := newAwsPeeringTestService(client, clusterId, ec2Client)
+
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+
+	peeringConnectionIds := activePeeringConnectionIds(ec2Client)
+	if len(peeringConnectionIds) != 1 {
+		t.Fatalf("vpc peering connections are %v", peeringConnectionIds)
+	}
+	for _, routeTableId := range []string{mainRouteTableId, subnetRouteTableId} {
+		route := ec2Client.Route(routeTableId, hazelcastVpcCidr)
+		if route == nil || aws.StringValue(route.VpcPeeringConnectionId) != peeringConnectionIds[0] {
+			t.Errorf("route to %s in %s is %v", hazelcastVpcCidr, routeTableId, route)
+		}
+	}
+	if permissions := ec2Client.SecurityGroups["sg-1"].IpPermissions; len(permissions) != 1 ||
+		aws.Int64Value(permissions[0].FromPort) != 31000 || aws.StringValue(permissions[0].IpRanges[0].CidrIp) != hazelcastVpcCidr {
+		t.Errorf("ingress rules of sg-1 are %v", permissions)
+	}
+	peerings := server.AwsPeerings(clusterId)
+	if len(peerings) != 2 || peerings[0].PeeringConnectionId != peeringConnectionIds[0] || peerings[0].VpcCidr != "10.0.0.0/16" {
+		t.Errorf("Hazelcast peerings are %+v", peerings)
+	}
+	if changes := service.RouteChanges(); len(changes) != 2 || changes[0].Action != "Created" {
+		t.Errorf("route changes are %+v", changes)
+	}
+}
+
+func TestAwsPeeringServiceCreateRollsBackOnFailure(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	ec2Client, mainRouteTableId, subnetRouteTableId := newAwsPeeringTestVpc()
+	ec2Client.AddRoute(subnetRouteTableId, &ec2.Route{
+		DestinationCidrBlock: aws.String(hazelcastVpcCidr),
+		TransitGatewayId:     aws.String("tgw-1"),
+	})
+	server.Handle("acceptAwsVpcPeering", func(args fakeapi.Args) (interface{}, error) {
+		return nil, fmt.Errorf("peering is not accepted")
+	})
+	service := newAwsPeeringTestService(client, clusterId, ec2Client)
+	service.customerPeeringProperties.ReplaceRoutes = true
+
+	err := service.Create(util.NewLoadingIndicator("", 100))
+	if err == nil || !strings.Contains(err.Error(), "peering is not accepted") ||
+		!strings.HasSuffix(err.Error(), "All changes are rolled back") {
+		t.Fatalf("Create returned %v", err)
+	}
+
+	if peeringConnectionIds := activePeeringConnectionIds(ec2Client); len(peeringConnectionIds) != 0 {
+		t.Errorf("vpc peering connections %v are left behind", peeringConnectionIds)
+	}
+	if route := ec2Client.Route(mainRouteTableId, hazelcastVpcCidr); route != nil {
+		t.Errorf("created route %v is left behind", route)
+	}
+	if route := ec2Client.Route(subnetRouteTableId, hazelcastVpcCidr); route == nil || aws.StringValue(route.TransitGatewayId) != "tgw-1" {
+		t.Errorf("replaced route is not restored, it is %v", route)
+	}
+	if permissions := ec2Client.SecurityGroups["sg-1"].IpPermissions; len(permissions) != 0 {
+		t.Errorf("ingress rules %v are left behind", permissions)
+	}
+	if changes := service.RouteChanges(); len(changes) != 0 {
+		t.Errorf("route changes are %+v", changes)
+	}
+}
+
+func TestAwsPeeringServiceCreateReportsChangesLeftBehind(t *testing.T) {
+	_, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	ec2Client, mainRouteTableId, _ := newAwsPeeringTestVpc()
+	ec2Client.Errors["AuthorizeSecurityGroupIngress"] = errors.New("UnauthorizedOperation")
+	ec2Client.Errors["DeleteVpcPeeringConnection"] = errors.New("connection is busy")
+	service := newAwsPeeringTestService(client, clusterId, ec2Client)
+
+	err := service.Create(util.NewLoadingIndicator("", 100))
+	if err == nil || !strings.Contains(err.Error(), "Rollback failed") || !strings.Contains(err.Error(), "connection is busy") {
+		t.Fatalf("Create returned %v", err)
+	}
+	peeringConnectionIds := activePeeringConnectionIds(ec2Client)
+	if len(peeringConnectionIds) != 1 || !strings.Contains(err.Error(), peeringConnectionIds[0]) {
+		t.Errorf("orphan vpc peering connections %v are not reported by %s", peeringConnectionIds, err)
+	}
+	if route := ec2Client.Route(mainRouteTableId, hazelcastVpcCidr); route != nil {
+		t.Errorf("created route %v is left behind", route)
+	}
+}
+
+func TestAwsPeeringServiceCreateWithoutRollback(t *testing.T) {
+	_, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	ec2Client, mainRouteTableId, _ := newAwsPeeringTestVpc()
+	ec2Client.Errors["AuthorizeSecurityGroupIngress"] = errors.New("UnauthorizedOperation")
+	service := newAwsPeeringTestService(client, clusterId, ec2Client)
+	service.customerPeeringProperties.NoRollback = true
+
+	err := service.Create(util.NewLoadingIndicator("", 100))
+	if err == nil || !strings.Contains(err.Error(), "Rollback is disabled") {
+		t.Fatalf("Create returned %v", err)
+	}
+	if peeringConnectionIds := activePeeringConnectionIds(ec2Client); len(peeringConnectionIds) != 1 {
+		t.Errorf("vpc peering connections are %v", peeringConnectionIds)
+	}
+	if route := ec2Client.Route(mainRouteTableId, hazelcastVpcCidr); route == nil {
+		t.Errorf("created route is removed")
+	}
+}
+
+func TestAwsPeeringServiceCreateAgain(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	ec2Client, mainRouteTableId, _ := newAwsPeeringTestVpc()
+	ec2Client.Errors["DescribeSubnets"] = errors.New("RequestLimitExceeded")
+	service := newAwsPeeringTestService(client, clusterId, ec2Client)
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err == nil {
+		t.Fatal("Create succeeded")
+	}
+
+	delete(ec2Client.Errors, "DescribeSubnets")
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed after rollback: %s", err)
+	}
+	peeringConnectionIds := activePeeringConnectionIds(ec2Client)
+	if len(peeringConnectionIds) != 1 || len(server.AwsPeerings(clusterId)) != 2 {
+		t.Fatalf("vpc peering connections are %v, Hazelcast peerings are %+v", peeringConnectionIds, server.AwsPeerings(clusterId))
+	}
+
+	createdConnections := ec2Client.CallCount("CreateVpcPeeringConnection")
+	err := service.Create(util.NewLoadingIndicator("", 100))
+	if err == nil || !strings.Contains(err.Error(), "conflicting routes found") {
+		t.Fatalf("Create of an existing peering returned %v", err)
+	}
+	if ec2Client.CallCount("CreateVpcPeeringConnection") != createdConnections {
+		t.Error("Create of an existing peering created another vpc peering connection")
+	}
+	if route := ec2Client.Route(mainRouteTableId, hazelcastVpcCidr); aws.StringValue(route.VpcPeeringConnectionId) != peeringConnectionIds[0] {
+		t.Errorf("route of the existing peering is changed to %v", route)
+	}
+	if len(server.AwsPeerings(clusterId)) != 2 {
+		t.Errorf("Hazelcast peerings are %+v", server.AwsPeerings(clusterId))
+	}
+}
+
+func TestAwsPeeringServiceDelete(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	ec2Client, mainRouteTableId, subnetRouteTableId := newAwsPeeringTestVpc()
+	service := newAwsPeeringTestService(client, clusterId, ec2Client)
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	peeringConnectionId := activePeeringConnectionIds(ec2Client)[0]
+	peeringIds := map[string]string{}
+	for _, peering := range server.AwsPeerings(clusterId) {
+		peeringIds[peering.SubnetId] = peering.Id
+	}
+
+	// The peering of subnet-1 still uses the connection, only the route of the route table of subnet-2 is removed.
+	if err := service.Delete(peeringIds["subnet-2"], util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+	if ids := service.DeletedPeeringConnectionIds(); len(ids) != 0 {
+		t.Errorf("deleted vpc peering connections are %v", ids)
+	}
+	if ids := activePeeringConnectionIds(ec2Client); len(ids) != 1 || ids[0] != peeringConnectionId {
+		t.Errorf("vpc peering connections are %v", ids)
+	}
+	if route := ec2Client.Route(subnetRouteTableId, hazelcastVpcCidr); route != nil {
+		t.Errorf("route %v in %s is left behind", route, subnetRouteTableId)
+	}
+	if route := ec2Client.Route(mainRouteTableId, hazelcastVpcCidr); route == nil {
+		t.Errorf("route of subnet-1 in %s is removed", mainRouteTableId)
+	}
+
+	// The peering of the last subnet deletes the connection and the remaining routes.
+	if err := service.Delete(peeringIds["subnet-1"], util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Delete of the last peering failed: %s", err)
+	}
+	if ids := service.DeletedPeeringConnectionIds(); len(ids) != 1 || ids[0] != peeringConnectionId {
+		t.Errorf("deleted vpc peering connections are %v", ids)
+	}
+	if route := ec2Client.Route(mainRouteTableId, hazelcastVpcCidr); route != nil {
+		t.Errorf("route %v in %s is left behind", route, mainRouteTableId)
+	}
+	if remaining := server.AwsPeerings(clusterId); len(remaining) != 0 {
+		t.Errorf("Hazelcast peerings %+v are left behind", remaining)
+	}
+}
+
+func TestAwsPeeringServiceDiagnose(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	// The peering properties cidr differs from the cidr of the cluster, which is a subnet of the Hazelcast vpc. Only the
+	// properties cidr overlaps with the cidr of vpc-1.
+	properties := server.Handler("awsPeeringProperties")
+	server.Handle("awsPeeringProperties", func(args fakeapi.Args) (interface{}, error) {
+		result, err := properties(args)
+		if err != nil {
+			return nil, err
+		}
+		awsProperties := result.(fakeapi.AwsPeeringProperties)
+		awsProperties.VpcCidr = "10.0.0.0/8"
+		return awsProperties, nil
+	})
+	ec2Client, _, _ := newAwsPeeringTestVpc()
+	service := newAwsPeeringTestService(client, clusterId, ec2Client)
+	service.customerPeeringProperties.EgressSecurityGroupIds = []string{"sg-1"}
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	ec2Client.AcceptVpcPeeringConnection(activePeeringConnectionIds(ec2Client)[0])
+
+	checks, err := service.Diagnose(util.NewLoadingIndicator("", 100))
+	if err != nil {
+		t.Fatalf("Diagnose failed: %s", err)
+	}
+	securityGroupChecked := false
+	for _, check := range checks {
+		if !check.IsPassed {
+			t.Errorf("check %s failed: %s", check.Name, check.Details)
+		}
+		if check.Name == "Security group sg-1" {
+			securityGroupChecked = true
+			if check.Details != "outbound traffic to 10.0.0.0/8 on port 31000 is allowed" {
+				t.Errorf("security group check details are %q", check.Details)
+			}
+		}
+	}
+	if !securityGroupChecked {
+		t.Errorf("security group is not checked, checks are %+v", checks)
+	}
+
+	ec2Client.SecurityGroups["sg-1"].IpPermissionsEgress = nil
+	checks, err = service.Diagnose(util.NewLoadingIndicator("", 100))
+	if err != nil {
+		t.Fatalf("Diagnose failed: %s", err)
+	}
+	if check := checks[len(checks)-1]; check.IsPassed || check.Name != "Security group sg-1" {
+		t.Errorf("security group check without egress rules is %+v", check)
+	}
+}
@@ -1,9 +1,10 @@
 package service
 
 import (
-	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
 	"github.com/Azure/azure-sdk-for-go/profiles/latest/graphrbac/graphrbac"
@@ -12,27 +13,59 @@ import (
 	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest/azure/cli"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/google/uuid"
+	"github.com/hazelcast/hazelcast-cloud-cli/internal"
 	"github.com/hazelcast/hazelcast-cloud-cli/util"
 	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 )
 
 type AzurePeeringService struct {
-	client                         *hazelcastcloud.Client
-	customerPeeringProperties      *AzureCustomerPeeringProperties
-	hazelcastPeeringProperties     *models.AzurePeeringProperties
-	hazelcastVnetPeeringClient     network.VirtualNetworkPeeringsClient
-	customerVnetPeeringClient      network.VirtualNetworkPeeringsClient
-	customerServicePrincipalClient graphrbac.ServicePrincipalsClient
-	customerRoleAssignmentClient   authorization.RoleAssignmentsClient
-	servicePrincipal               graphrbac.ServicePrincipal
-	customerVnetPeering            network.VirtualNetworkPeering
-	hazelcastVnetPeering           network.VirtualNetworkPeering
+	client                     *hazelcastcloud.Client
+	customerPeeringProperties  *AzureCustomerPeeringProperties
+	hazelcastPeeringProperties *models.AzurePeeringProperties
+	clients                    AzureClients
+	servicePrincipal           graphrbac.ServicePrincipal
+	customerVnetPeering        network.VirtualNetworkPeering
+	hazelcastVnetPeering       network.VirtualNetworkPeering
+	removedResources           []string
+	keptServicePrincipalId     string
+}
+
+// AzureClients holds the Azure APIs used by AzurePeeringService. When they are not provided, they are created
+// from the Hazelcast peering properties and your credentials selected by AzureCustomerPeeringProperties.AuthMethod.
+type AzureClients struct {
+	HazelcastVnetPeering     AzureVnetPeeringClient
+	CustomerVnetPeering      AzureVnetPeeringClient
+	CustomerServicePrincipal AzureServicePrincipalClient
+	CustomerRoleAssignment   AzureRoleAssignmentClient
+}
+
+type AzureVnetPeeringClient interface {
+	CreateOrUpdate(ctx context.Context, resourceGroupName string, vnetName string, peeringName string,
+		peering network.VirtualNetworkPeering) (network.VirtualNetworkPeering, error)
+	Get(ctx context.Context, resourceGroupName string, vnetName string, peeringName string) (network.VirtualNetworkPeering, error)
+	Delete(ctx context.Context, resourceGroupName string, vnetName string, peeringName string) error
+	List(ctx context.Context, resourceGroupName string, vnetName string) ([]network.VirtualNetworkPeering, error)
+}
+
+type AzureServicePrincipalClient interface {
+	Create(ctx context.Context, parameters graphrbac.ServicePrincipalCreateParameters) (graphrbac.ServicePrincipal, error)
+	List(ctx context.Context, filter string) ([]graphrbac.ServicePrincipal, error)
+	Delete(ctx context.Context, objectId string) error
+}
+
+type AzureRoleAssignmentClient interface {
+	Create(ctx context.Context, scope string, roleAssignmentName string,
+		parameters authorization.RoleAssignmentCreateParameters) (authorization.RoleAssignment, error)
+	List(ctx context.Context, scope string, filter string) ([]authorization.RoleAssignment, error)
+	Delete(ctx context.Context, roleAssignmentId string) error
 }
 
 type AzureCustomerPeeringProperties struct {
@@ -41,8 +74,34 @@ type AzureCustomerPeeringProperties struct {
 	SubscriptionId    string
 	TenantId          string
 	ResourceGroupName string
+	// Environment is the name of the Azure cloud, AzurePublicCloud when empty.
+	Environment string
+	// AuthMethod selects how your credentials are obtained, one of the AzureAuthMethod values. The Azure CLI login is
+	// used when empty.
+	AuthMethod string
+	// ClientId is the application id of the service principal, or of the user assigned managed identity.
+	ClientId                  string
+	ClientSecret              string
+	ClientCertificatePath     string
+	ClientCertificatePassword string
+	// Resume reuses the vnet peerings left by a failed attempt instead of deleting them.
+	Resume bool
+	// RemoveServicePrincipal makes Delete remove the role assignment on your vnet and the service principal of the
+	// Hazelcast application too.
+	RemoveServicePrincipal bool
 }
 
+const (
+	AzureAuthMethodCli               = "cli"
+	AzureAuthMethodClientSecret      = "client-secret"
+	AzureAuthMethodClientCertificate = "client-certificate"
+	AzureAuthMethodManagedIdentity   = "managed-identity"
+	AzureAuthMethodEnvironment       = "environment"
+)
+
+var AzureAuthMethods = []string{AzureAuthMethodCli, AzureAuthMethodClientSecret, AzureAuthMethodClientCertificate,
+	AzureAuthMethodManagedIdentity, AzureAuthMethodEnvironment}
+
 func NewAzurePeeringService(client *hazelcastcloud.Client, customerProperties *AzureCustomerPeeringProperties) AzurePeeringService {
 	return AzurePeeringService{
 		client:                    client,
@@ -50,6 +109,15 @@ func NewAzurePeeringService(client *hazelcastcloud.Client, customerProperties *A
 	}
 }
 
+func NewAzurePeeringServiceWithClients(client *hazelcastcloud.Client, customerProperties *AzureCustomerPeeringProperties,
+	clients AzureClients) AzurePeeringService {
+	return AzurePeeringService{
+		client:                    client,
+		customerPeeringProperties: customerProperties,
+		clients:                   clients,
+	}
+}
+
 func (s *AzurePeeringService) Create(indicator *util.LoadingIndicator) error {
 	indicator.SetStep("Peering Properties collecting...", 10)
 	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
@@ -66,26 +134,32 @@ func (s *AzurePeeringService) Create(indicator *util.LoadingIndicator) error {
 	if initServicePrincipalErr != nil {
 		return initServicePrincipalErr
 	}
-	indicator.SetStep("Role Assignment creating...", 45)
+	indicator.SetStep("Role Assignment creating...", 40)
 	initRoleAssignmentsErr := s.createRoleAssignment()
 	if initRoleAssignmentsErr != nil {
 		return initRoleAssignmentsErr
 	}
-	indicator.SetStep("Orphan Peerings deleting...", 55)
-	deleteOrphanPeeringsErr := s.deleteOrphanPeerings()
-	if deleteOrphanPeeringsErr != nil {
-		return deleteOrphanPeeringsErr
+	indicator.SetStep("Role Assignment propagating...", 50)
+	roleAssignmentPropagationErr := s.waitForRoleAssignmentPropagation()
+	if roleAssignmentPropagationErr != nil {
+		return roleAssignmentPropagationErr
 	}
 	indicator.SetStep("Customer Peering creating...", 65)
-	initCustomerPeeringErr := s.createCustomerPeering()
-	if initCustomerPeeringErr != nil {
-		return initCustomerPeeringErr
+	customerVnetPeering, customerVnetPeeringErr := s.createVnetPeering(s.clients.CustomerVnetPeering,
+		s.customerPeeringProperties.ResourceGroupName, s.customerPeeringProperties.VnetName, s.getHazelcastVnetId(),
+		s.customerPeeringProperties.Resume)
+	if customerVnetPeeringErr != nil {
+		return customerVnetPeeringErr
 	}
+	s.customerVnetPeering = customerVnetPeering
 	indicator.SetStep("Hazelcast Peering creating...", 80)
-	initHazelcastPeeringErr := s.createHazelcastPeering()
-	if initHazelcastPeeringErr != nil {
-		return initHazelcastPeeringErr
+	hazelcastVnetPeering, hazelcastVnetPeeringErr := s.createVnetPeering(s.clients.HazelcastVnetPeering,
+		s.hazelcastPeeringProperties.ResourceGroupName, s.hazelcastPeeringProperties.VnetName, s.getCustomerVnetId(),
+		s.customerPeeringProperties.Resume)
+	if hazelcastVnetPeeringErr != nil {
+		return hazelcastVnetPeeringErr
 	}
+	s.hazelcastVnetPeering = hazelcastVnetPeering
 	indicator.SetStep("Peering notifying...", 95)
 	notifyPeeringErr := s.notifyPeering()
 	if notifyPeeringErr != nil {
@@ -94,149 +168,391 @@ func (s *AzurePeeringService) Create(indicator *util.LoadingIndicator) error {
 	return nil
 }
 
-func (s *AzurePeeringService) notifyPeering() error {
-	jsonObject, _ := json.Marshal(struct {
-		ClusterId           string `json:"clusterId"`
-		PeeringConnectionId string `json:"peeringConnectionId"`
-		VnetId              string `json:"vpcId"`
-		VnetCidr            string `json:"vpcCidr"`
-	}{
-		s.customerPeeringProperties.ClusterId,
-		*s.hazelcastVnetPeering.Name,
-		s.customerPeeringProperties.VnetName,
-		(*s.hazelcastVnetPeering.RemoteAddressSpace.AddressPrefixes)[0],
+// Accept completes the Hazelcast side of a peering whose customer side was created outside of the CLI, e.g. from the
+// output of Emit: it creates the vnet peering of the Hazelcast vnet to your vnet, reusing the one of a previous attempt,
+// and registers the peering on the cluster. Only the credentials of the Hazelcast application are used, your
+// subscription is not changed.
+func (s *AzurePeeringService) Accept(indicator *util.LoadingIndicator) error {
+	indicator.SetStep("Peering Properties collecting...", 10)
+	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
+	if initHazelcastPeeringPropertiesErr != nil {
+		return initHazelcastPeeringPropertiesErr
+	}
+	indicator.SetStep("Clients initializing...", 20)
+	initClientErr := s.initHazelcastClient()
+	if initClientErr != nil {
+		return initClientErr
+	}
+	indicator.SetStep("Hazelcast Peering creating...", 50)
+	hazelcastVnetPeering, hazelcastVnetPeeringErr := s.createVnetPeering(s.clients.HazelcastVnetPeering,
+		s.hazelcastPeeringProperties.ResourceGroupName, s.hazelcastPeeringProperties.VnetName, s.getCustomerVnetId(), true)
+	if hazelcastVnetPeeringErr != nil {
+		return hazelcastVnetPeeringErr
+	}
+	s.hazelcastVnetPeering = hazelcastVnetPeering
+	indicator.SetStep("Peering notifying...", 90)
+	return s.notifyPeering()
+}
+
+// Delete deletes the vnet peerings of your vnet to the Hazelcast vnet and the peering from the Hazelcast cluster.
+// The vnet is the one of the peering when VnetName is empty.
+func (s *AzurePeeringService) Delete(peeringId string, indicator *util.LoadingIndicator) error {
+	indicator.SetStep("Peering Properties collecting...", 10)
+	peering, peeringErr := s.getPeering(peeringId)
+	if peeringErr != nil {
+		return peeringErr
+	}
+	if s.customerPeeringProperties.VnetName == "" {
+		s.customerPeeringProperties.VnetName = peering.VpcId
+	}
+	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
+	if initHazelcastPeeringPropertiesErr != nil {
+		return initHazelcastPeeringPropertiesErr
+	}
+	indicator.SetStep("Clients initializing...", 20)
+	initClientErr := s.initClients()
+	if initClientErr != nil {
+		return initClientErr
+	}
+
+	s.removedResources = nil
+	s.keptServicePrincipalId = ""
+	indicator.SetStep("Customer Peering deleting...", 30)
+	vnetPeerings, vnetPeeringsErr := s.clients.CustomerVnetPeering.List(context.Background(),
+		s.customerPeeringProperties.ResourceGroupName, s.customerPeeringProperties.VnetName)
+	if vnetPeeringsErr != nil {
+		return vnetPeeringsErr
+	}
+	for _, vnetPeering := range vnetPeerings {
+		if vnetPeering.VirtualNetworkPeeringPropertiesFormat == nil || vnetPeering.RemoteVirtualNetwork == nil ||
+			!strings.EqualFold(to.String(vnetPeering.RemoteVirtualNetwork.ID), s.getHazelcastVnetId()) {
+			continue
+		}
+		deleteErr := s.clients.CustomerVnetPeering.Delete(context.Background(), s.customerPeeringProperties.ResourceGroupName,
+			s.customerPeeringProperties.VnetName, to.String(vnetPeering.Name))
+		if deleteErr != nil && !isAzureStatusCode(deleteErr, http.StatusNotFound) {
+			return deleteErr
+		}
+		s.removedResources = append(s.removedResources, fmt.Sprintf("Vnet peering %s of vnet %s", to.String(vnetPeering.Name),
+			s.customerPeeringProperties.VnetName))
+	}
+
+	if s.customerPeeringProperties.RemoveServicePrincipal {
+		indicator.SetStep("Service Principal deleting...", 60)
+		deleteServicePrincipalErr := s.deleteServicePrincipal()
+		if deleteServicePrincipalErr != nil {
+			return deleteServicePrincipalErr
+		}
+	}
+
+	indicator.SetStep("Hazelcast Peering deleting...", 90)
+	_, _, deletePeeringErr := s.client.AzurePeering.Delete(context.Background(), &models.DeleteAzurePeeringInput{
+		Id: peeringId,
 	})
-	request, requestErr := http.NewRequest("POST", fmt.Sprintf("https://%s/peerings", s.client.BaseURL.Host), bytes.NewBuffer(jsonObject))
-	if requestErr != nil {
-		return requestErr
-	}
-	request.Header.Add("Content-Type", "application/json")
-	request.Header.Add("Accept", "application/json")
-	request.Header.Add("User-Agent", "hazelcast-cloud-sdk-go")
-	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.client.Token))
-	client := &http.Client{}
-	_, respErr := client.Do(request)
-	if respErr != nil {
-		return respErr
+	if deletePeeringErr != nil {
+		return deletePeeringErr
 	}
 	return nil
 }
 
-func (s *AzurePeeringService) createHazelcastPeering() error {
-	hazelcastPeeringName :=  s.generatePeeringName()
-	createHazelcastPeering, createHazelcastPeeringErr := s.hazelcastVnetPeeringClient.CreateOrUpdate(context.Background(),
-		s.hazelcastPeeringProperties.ResourceGroupName, s.hazelcastPeeringProperties.VnetName, hazelcastPeeringName,
-		network.VirtualNetworkPeering{VirtualNetworkPeeringPropertiesFormat: &network.VirtualNetworkPeeringPropertiesFormat{
-			AllowVirtualNetworkAccess: to.BoolPtr(true),
-			AllowForwardedTraffic:     to.BoolPtr(true),
-			RemoteVirtualNetwork: &network.SubResource{
-				ID: to.StringPtr(s.getCustomerVnetId()),
-			},
-		}})
-	if createHazelcastPeeringErr != nil {
-		return createHazelcastPeeringErr
+// deleteServicePrincipal deletes the role assignments of the service principal on your vnet, then the service
+// principal itself unless it has role assignments for other vnets.
+func (s *AzurePeeringService) deleteServicePrincipal() error {
+	servicePrincipal, servicePrincipalErr := s.findServicePrincipal()
+	if servicePrincipalErr != nil {
+		return servicePrincipalErr
+	}
+	if servicePrincipal == nil {
+		return nil
+	}
+	principalFilter := fmt.Sprintf("principalId eq '%s'", to.String(servicePrincipal.ObjectID))
+	roleAssignments, roleAssignmentsErr := s.clients.CustomerRoleAssignment.List(context.Background(), s.getCustomerVnetId(), principalFilter)
+	if roleAssignmentsErr != nil {
+		return roleAssignmentsErr
+	}
+	for _, roleAssignment := range roleAssignments {
+		if roleAssignment.Properties == nil || !strings.EqualFold(to.String(roleAssignment.Properties.Scope), s.getCustomerVnetId()) {
+			continue
+		}
+		deleteErr := s.clients.CustomerRoleAssignment.Delete(context.Background(), to.String(roleAssignment.ID))
+		if deleteErr != nil && !isAzureStatusCode(deleteErr, http.StatusNotFound) {
+			return deleteErr
+		}
+		s.removedResources = append(s.removedResources, fmt.Sprintf("Role assignment %s", to.String(roleAssignment.Name)))
+	}
+
+	otherRoleAssignments, otherRoleAssignmentsErr := s.clients.CustomerRoleAssignment.List(context.Background(),
+		fmt.Sprintf("/subscriptions/%s", s.customerPeeringProperties.SubscriptionId), principalFilter)
+	if otherRoleAssignmentsErr != nil {
+		return otherRoleAssignmentsErr
 	}
-	_ = createHazelcastPeering.WaitForCompletionRef(context.Background(), s.hazelcastVnetPeeringClient.Client)
-	hazelcastPeering, hazelcastPeeringErr := s.hazelcastVnetPeeringClient.Get(context.Background(),
-		s.hazelcastPeeringProperties.ResourceGroupName, s.hazelcastPeeringProperties.VnetName, hazelcastPeeringName)
-	if hazelcastPeeringErr != nil {
-		return hazelcastPeeringErr
+	if len(otherRoleAssignments) != 0 {
+		s.keptServicePrincipalId = to.String(servicePrincipal.ObjectID)
+		return nil
+	}
+	deleteErr := s.clients.CustomerServicePrincipal.Delete(context.Background(), to.String(servicePrincipal.ObjectID))
+	if deleteErr != nil && !isAzureStatusCode(deleteErr, http.StatusNotFound) {
+		return deleteErr
 	}
-	s.hazelcastVnetPeering = hazelcastPeering
+	s.removedResources = append(s.removedResources, fmt.Sprintf("Service principal %s", to.String(servicePrincipal.ObjectID)))
 	return nil
 }
 
-func (s *AzurePeeringService) createCustomerPeering() error {
-	customerPeeringName := s.generatePeeringName()
-	createCustomerPeering, createCustomerPeeringErr := s.customerVnetPeeringClient.CreateOrUpdate(context.Background(),
-		s.customerPeeringProperties.ResourceGroupName, s.customerPeeringProperties.VnetName, customerPeeringName,
+// RemovedResources describes the Azure resources removed by the last successful Delete.
+func (s *AzurePeeringService) RemovedResources() []string {
+	return s.removedResources
+}
+
+// KeptServicePrincipalId returns the service principal the last successful Delete did not remove since it still
+// has role assignments for other vnets.
+func (s *AzurePeeringService) KeptServicePrincipalId() string {
+	return s.keptServicePrincipalId
+}
+
+func (s *AzurePeeringService) getPeering(peeringId string) (*models.AzurePeering, error) {
+	peerings, _, peeringsErr := s.client.AzurePeering.List(context.Background(), &models.ListAzurePeeringsInput{
+		ClusterId: s.customerPeeringProperties.ClusterId,
+	})
+	if peeringsErr != nil {
+		return nil, peeringsErr
+	}
+	for _, peering := range *peerings {
+		if peering.Id == peeringId {
+			return &peering, nil
+		}
+	}
+	return nil, fmt.Errorf("peering %s not found on cluster %s", peeringId, s.customerPeeringProperties.ClusterId)
+}
+
+// Diagnose compares the peerings of the cluster with the state of the vnet peerings on both sides.
+func (s *AzurePeeringService) Diagnose(indicator *util.LoadingIndicator) ([]PeeringCheck, error) {
+	indicator.SetStep("Peering Properties collecting...", 10)
+	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
+	if initHazelcastPeeringPropertiesErr != nil {
+		return nil, initHazelcastPeeringPropertiesErr
+	}
+	indicator.SetStep("Clients initializing...", 20)
+	initClientErr := s.initClients()
+	if initClientErr != nil {
+		return nil, initClientErr
+	}
+	cluster, clusterErr := getCluster(s.client, s.customerPeeringProperties.ClusterId)
+	if clusterErr != nil {
+		return nil, clusterErr
+	}
+	peerings, _, peeringsErr := s.client.AzurePeering.List(context.Background(), &models.ListAzurePeeringsInput{
+		ClusterId: s.customerPeeringProperties.ClusterId,
+	})
+	if peeringsErr != nil {
+		return nil, peeringsErr
+	}
+
+	indicator.SetStep("Checking vnet peerings...", 50)
+	var vnetPeerings []models.AzurePeering
+	for _, peering := range *peerings {
+		if peering.VpcId == s.customerPeeringProperties.VnetName {
+			vnetPeerings = append(vnetPeerings, peering)
+		}
+	}
+	checks := []PeeringCheck{newHazelcastPeeringCheck(len(vnetPeerings), "hzcloud azure-peering create")}
+	for _, peering := range vnetPeerings {
+		checks = append(checks, newCidrOverlapCheck(fmt.Sprintf("Cidr of %s", peering.VpcId), peering.VpcCidr, cluster.Networking.CidrBlock))
+	}
+	customerPeeringCheck, customerPeeringCheckErr := s.checkVnetPeering("Customer vnet peering", s.clients.CustomerVnetPeering,
+		s.customerPeeringProperties.ResourceGroupName, s.customerPeeringProperties.VnetName, s.getHazelcastVnetId())
+	if customerPeeringCheckErr != nil {
+		return nil, customerPeeringCheckErr
+	}
+	hazelcastPeeringCheck, hazelcastPeeringCheckErr := s.checkVnetPeering("Hazelcast vnet peering", s.clients.HazelcastVnetPeering,
+		s.hazelcastPeeringProperties.ResourceGroupName, s.hazelcastPeeringProperties.VnetName, s.getCustomerVnetId())
+	if hazelcastPeeringCheckErr != nil {
+		return nil, hazelcastPeeringCheckErr
+	}
+	return append(checks, customerPeeringCheck, hazelcastPeeringCheck), nil
+}
+
+func (s *AzurePeeringService) checkVnetPeering(name string, client AzureVnetPeeringClient, resourceGroupName string, vnetName string,
+	remoteVnetId string) (PeeringCheck, error) {
+	vnetPeerings, vnetPeeringsErr := client.List(context.Background(), resourceGroupName, vnetName)
+	if vnetPeeringsErr != nil {
+		return PeeringCheck{}, vnetPeeringsErr
+	}
+	for _, vnetPeering := range vnetPeerings {
+		if vnetPeering.VirtualNetworkPeeringPropertiesFormat == nil || vnetPeering.RemoteVirtualNetwork == nil ||
+			!strings.EqualFold(to.String(vnetPeering.RemoteVirtualNetwork.ID), remoteVnetId) {
+			continue
+		}
+		if vnetPeering.PeeringState == network.VirtualNetworkPeeringStateConnected {
+			return passedCheck(name, fmt.Sprintf("%s is %s", to.String(vnetPeering.Name), vnetPeering.PeeringState)), nil
+		}
+		return failedCheck(name, fmt.Sprintf("%s is %s", to.String(vnetPeering.Name), vnetPeering.PeeringState),
+			"A vnet peering is connected only when both sides are created, create the peering again with `hzcloud azure-peering create`."), nil
+	}
+	return failedCheck(name, fmt.Sprintf("%s has no vnet peering to %s", vnetName, remoteVnetId),
+		"Create the peering again with `hzcloud azure-peering create`."), nil
+}
+
+// notifyPeering registers the vnet peering on the Hazelcast cluster unless a peering of the vnet is already
+// registered by an earlier run.
+func (s *AzurePeeringService) notifyPeering() error {
+	peerings, _, peeringsErr := s.client.AzurePeering.List(context.Background(), &models.ListAzurePeeringsInput{
+		ClusterId: s.customerPeeringProperties.ClusterId,
+	})
+	if peeringsErr != nil {
+		return peeringsErr
+	}
+	for _, peering := range *peerings {
+		if peering.VpcId == s.customerPeeringProperties.VnetName {
+			return nil
+		}
+	}
+	if s.hazelcastVnetPeering.VirtualNetworkPeeringPropertiesFormat == nil || s.hazelcastVnetPeering.RemoteAddressSpace == nil ||
+		s.hazelcastVnetPeering.RemoteAddressSpace.AddressPrefixes == nil || len(*s.hazelcastVnetPeering.RemoteAddressSpace.AddressPrefixes) == 0 {
+		return fmt.Errorf("address space of vnet %s is not known by vnet peering %s", s.customerPeeringProperties.VnetName,
+			to.String(s.hazelcastVnetPeering.Name))
+	}
+	_, _, notifyErr := internal.NewApiClient(s.client).NotifyAzurePeering(context.Background(), &internal.AzurePeeringNotification{
+		ClusterId:           s.customerPeeringProperties.ClusterId,
+		PeeringConnectionId: to.String(s.hazelcastVnetPeering.Name),
+		VnetId:              s.customerPeeringProperties.VnetName,
+		VnetCidr:            (*s.hazelcastVnetPeering.RemoteAddressSpace.AddressPrefixes)[0],
+	})
+	return notifyErr
+}
+
+// createVnetPeering creates a vnet peering to the remote vnet. Vnet peerings to the remote vnet left by a failed
+// attempt are deleted, or reused with resume.
+func (s *AzurePeeringService) createVnetPeering(client AzureVnetPeeringClient, resourceGroupName string, vnetName string,
+	remoteVnetId string, resume bool) (network.VirtualNetworkPeering, error) {
+	vnetPeerings, vnetPeeringsErr := client.List(context.Background(), resourceGroupName, vnetName)
+	if vnetPeeringsErr != nil {
+		return network.VirtualNetworkPeering{}, vnetPeeringsErr
+	}
+	peeringName := s.generatePeeringName()
+	for _, vnetPeering := range vnetPeerings {
+		if vnetPeering.VirtualNetworkPeeringPropertiesFormat == nil || vnetPeering.RemoteVirtualNetwork == nil ||
+			!strings.EqualFold(to.String(vnetPeering.RemoteVirtualNetwork.ID), remoteVnetId) {
+			continue
+		}
+		if resume && vnetPeering.PeeringState != network.VirtualNetworkPeeringStateDisconnected {
+			peeringName = to.String(vnetPeering.Name)
+			continue
+		}
+		if vnetPeering.PeeringState == network.VirtualNetworkPeeringStateConnected {
+			return network.VirtualNetworkPeering{}, fmt.Errorf("you already have one connected peering connection named %s, use --resume to complete it",
+				to.String(vnetPeering.Name))
+		}
+		deleteErr := client.Delete(context.Background(), resourceGroupName, vnetName, to.String(vnetPeering.Name))
+		if deleteErr != nil {
+			return network.VirtualNetworkPeering{}, deleteErr
+		}
+	}
+	return client.CreateOrUpdate(context.Background(), resourceGroupName, vnetName, peeringName,
 		network.VirtualNetworkPeering{VirtualNetworkPeeringPropertiesFormat: &network.VirtualNetworkPeeringPropertiesFormat{
 			AllowVirtualNetworkAccess: to.BoolPtr(true),
 			AllowForwardedTraffic:     to.BoolPtr(true),
 			RemoteVirtualNetwork: &network.SubResource{
-				ID: to.StringPtr(s.getHazelcastVnetId()),
+				ID: to.StringPtr(remoteVnetId),
 			},
 		}})
-	if createCustomerPeeringErr != nil {
-		return createCustomerPeeringErr
-	}
-	_ = createCustomerPeering.WaitForCompletionRef(context.Background(), s.customerVnetPeeringClient.Client)
-	customerPeering, customerPeeringErr := s.customerVnetPeeringClient.Get(context.Background(),
-		s.customerPeeringProperties.ResourceGroupName, s.customerPeeringProperties.VnetName, customerPeeringName)
-	if customerPeeringErr != nil {
-		return customerPeeringErr
-	}
-	s.customerVnetPeering = customerPeering
-	return nil
 }
 
-func (s *AzurePeeringService) deleteOrphanPeerings() error {
-	customerPeeringList, customerPeeringListErr := s.customerVnetPeeringClient.List(context.Background(),
-		s.customerPeeringProperties.ResourceGroupName, s.customerPeeringProperties.VnetName)
-	if customerPeeringListErr != nil {
-		return customerPeeringListErr
-	}
-	for _,customerPeer := range customerPeeringList.Values() {
-		if  *customerPeer.RemoteVirtualNetwork.ID == s.getHazelcastVnetId() {
-			if customerPeer.PeeringState != network.VirtualNetworkPeeringStateConnected {
-				deleteCustomerPeering, deleteCustomerPeeringErr := s.customerVnetPeeringClient.Delete(context.Background(),
-					s.customerPeeringProperties.ResourceGroupName, s.customerPeeringProperties.VnetName, *customerPeer.Name)
-				if deleteCustomerPeeringErr != nil {
-					return deleteCustomerPeeringErr
-				}
-				_ = deleteCustomerPeering.WaitForCompletionRef(context.Background(), s.customerVnetPeeringClient.Client)
-			} else {
-				return fmt.Errorf("you already have one connected peering connection named %s", *customerPeer.Name)
-			}
+// createRoleAssignment assigns the Network Contributor role on your vnet to the service principal unless it is
+// already assigned. It retries while the new service principal is not yet visible to the role assignment API.
+func (s *AzurePeeringService) createRoleAssignment() error {
+	networkContributorRoleId := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s",
+		s.customerPeeringProperties.SubscriptionId, azureNetworkContributorRoleId)
+	roleAssignments, roleAssignmentsErr := s.clients.CustomerRoleAssignment.List(context.Background(), s.getCustomerVnetId(),
+		fmt.Sprintf("principalId eq '%s'", to.String(s.servicePrincipal.ObjectID)))
+	if roleAssignmentsErr != nil {
+		return roleAssignmentsErr
+	}
+	for _, roleAssignment := range roleAssignments {
+		if roleAssignment.Properties != nil && strings.EqualFold(to.String(roleAssignment.Properties.RoleDefinitionID), networkContributorRoleId) {
+			return nil
 		}
 	}
-	return nil
+	return pollAzure("the service principal to be visible to role assignments", func() (bool, error) {
+		_, roleAssignmentErr := s.clients.CustomerRoleAssignment.Create(context.Background(), s.getCustomerVnetId(),
+			uuid.New().String(), authorization.RoleAssignmentCreateParameters{
+				Properties: &authorization.RoleAssignmentProperties{
+					RoleDefinitionID: &networkContributorRoleId,
+					PrincipalID:      s.servicePrincipal.ObjectID,
+				},
+			})
+		if roleAssignmentErr == nil || isAzureStatusCode(roleAssignmentErr, http.StatusConflict) {
+			return true, nil
+		}
+		if getAzureErrorCode(roleAssignmentErr) == "PrincipalNotFound" {
+			return false, nil
+		}
+		return false, roleAssignmentErr
+	})
 }
 
-func (s *AzurePeeringService) createRoleAssignment() error {
-	networkContributorRoleId := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/4d97b98b-1d4f-4787-a291-c67834d212e7",
-		s.customerPeeringProperties.SubscriptionId)
-	_, roleAssignmentErr := s.customerRoleAssignmentClient.Create(context.Background(), s.getCustomerVnetId(),
-		uuid.New().String(), authorization.RoleAssignmentCreateParameters{
-			Properties: &authorization.RoleAssignmentProperties{
-				RoleDefinitionID: &networkContributorRoleId,
-				PrincipalID:      s.servicePrincipal.ObjectID,
-			},
-		})
-	if roleAssignmentErr != nil {
-		if roleAssignmentErr.(autorest.DetailedError).StatusCode != 409 {
-			return roleAssignmentErr
-		} else {
-			return nil
+// waitForRoleAssignmentPropagation waits until the Hazelcast application is allowed to read the vnet peerings of
+// your vnet.
+func (s *AzurePeeringService) waitForRoleAssignmentPropagation() error {
+	return pollAzure("the role assignment to propagate", func() (bool, error) {
+		_, listErr := s.clients.CustomerVnetPeering.List(context.Background(), s.customerPeeringProperties.ResourceGroupName,
+			s.customerPeeringProperties.VnetName)
+		if listErr == nil {
+			return true, nil
 		}
-	}
-	time.Sleep(20 * time.Second)
-	return nil
+		var tokenRefreshErr adal.TokenRefreshError
+		if isAzureStatusCode(listErr, http.StatusUnauthorized) || isAzureStatusCode(listErr, http.StatusForbidden) ||
+			errors.As(listErr, &tokenRefreshErr) {
+			return false, nil
+		}
+		return false, listErr
+	})
 }
 
+// createServicePrincipal creates the service principal of the Hazelcast application in your tenant unless it
+// already exists.
 func (s *AzurePeeringService) createServicePrincipal() error {
-	servicePrincipal, createServicePrincipalErr := s.customerServicePrincipalClient.Create(context.Background(),
+	servicePrincipal, servicePrincipalErr := s.findServicePrincipal()
+	if servicePrincipalErr != nil {
+		return servicePrincipalErr
+	}
+	if servicePrincipal != nil {
+		s.servicePrincipal = *servicePrincipal
+		return nil
+	}
+	createdServicePrincipal, createServicePrincipalErr := s.clients.CustomerServicePrincipal.Create(context.Background(),
 		graphrbac.ServicePrincipalCreateParameters{
 			AppID:          &s.hazelcastPeeringProperties.AppRegistrationId,
 			AccountEnabled: to.BoolPtr(true),
 		})
 	if createServicePrincipalErr != nil {
-		if createServicePrincipalErr.(autorest.DetailedError).StatusCode != 409 {
+		if !isAzureStatusCode(createServicePrincipalErr, http.StatusConflict) {
 			return createServicePrincipalErr
 		}
-		servicePrincipalList, servicePrincipalListErr := s.customerServicePrincipalClient.List(
-			context.Background(), fmt.Sprintf("appId eq '%s'", s.hazelcastPeeringProperties.AppRegistrationId))
-		if servicePrincipalListErr != nil {
-			return servicePrincipalListErr
+		servicePrincipal, servicePrincipalErr = s.findServicePrincipal()
+		if servicePrincipalErr != nil {
+			return servicePrincipalErr
 		}
-		s.servicePrincipal = servicePrincipalList.Values()[0]
-		return nil
+		if servicePrincipal == nil {
+			return fmt.Errorf("service principal of app %s not found", s.hazelcastPeeringProperties.AppRegistrationId)
+		}
+		createdServicePrincipal = *servicePrincipal
 	}
-	time.Sleep(60 * time.Second)
-	s.servicePrincipal = servicePrincipal
+	s.servicePrincipal = createdServicePrincipal
 	return nil
 }
 
+func (s *AzurePeeringService) findServicePrincipal() (*graphrbac.ServicePrincipal, error) {
+	servicePrincipalList, servicePrincipalListErr := s.clients.CustomerServicePrincipal.List(
+		context.Background(), fmt.Sprintf("appId eq '%s'", s.hazelcastPeeringProperties.AppRegistrationId))
+	if servicePrincipalListErr != nil {
+		return nil, servicePrincipalListErr
+	}
+	if len(servicePrincipalList) == 0 {
+		return nil, nil
+	}
+	return &servicePrincipalList[0], nil
+}
+
 func (s *AzurePeeringService) initHazelcastPeeringProperties() error {
 	hazelcastPeeringProperties, _, hazelcastPeeringPropertiesErr := s.client.AzurePeering.GetProperties(context.Background(), &models.GetAzurePeeringPropertiesInput{
 		ClusterId: s.customerPeeringProperties.ClusterId,
@@ -249,21 +565,17 @@ func (s *AzurePeeringService) initHazelcastPeeringProperties() error {
 }
 
 func (s *AzurePeeringService) initClients() error {
-	env, envErr := azure.EnvironmentFromName("AzurePublicCloud")
+	if s.clients.CustomerVnetPeering != nil {
+		return nil
+	}
+	env, envErr := s.getEnvironment()
 	if envErr != nil {
 		return envErr
 	}
 
-	hazelcastOauthConfig, hazelcastOauthConfigErr := adal.NewMultiTenantOAuthConfig(env.ActiveDirectoryEndpoint,
-		s.hazelcastPeeringProperties.TenantId, []string{s.customerPeeringProperties.TenantId}, adal.OAuthOptions{})
-	if hazelcastOauthConfigErr != nil {
-		return hazelcastOauthConfigErr
-	}
-
-	hazelcastToken, hazelcastTokenErr := adal.NewMultiTenantServicePrincipalToken(hazelcastOauthConfig,
-		s.hazelcastPeeringProperties.AppRegistrationId, s.hazelcastPeeringProperties.AppRegistrationKey, env.ResourceManagerEndpoint)
-	if hazelcastTokenErr != nil {
-		return hazelcastOauthConfigErr
+	hazelcastVnetPeeringClient, hazelcastVnetPeeringClientErr := s.newHazelcastVnetPeeringClient(env)
+	if hazelcastVnetPeeringClientErr != nil {
+		return hazelcastVnetPeeringClientErr
 	}
 
 	customerOauthConfig, customerOauthConfigErr := adal.NewMultiTenantOAuthConfig(env.ActiveDirectoryEndpoint,
@@ -278,28 +590,321 @@ func (s *AzurePeeringService) initClients() error {
 		return customerTokenErr
 	}
 
-	customerAuthorizer, customerAuthorizerErr := auth.NewAuthorizerFromCLI()
-	if customerAuthorizerErr != nil {
-		return fmt.Errorf("you need to have Azure CLI installed and execute `az login` command successfully. For more information https://docs.cloud.hazelcast.com/docs/azure-vnet-peering . Azure Error:%s", customerAuthorizerErr)
+	customerResourceManagerToken, customerResourceManagerTokenErr := s.newCustomerToken(env, env.ResourceManagerEndpoint)
+	if customerResourceManagerTokenErr != nil {
+		return customerResourceManagerTokenErr
+	}
+	checkTenantErr := s.checkCustomerTenant(customerResourceManagerToken)
+	if checkTenantErr != nil {
+		return checkTenantErr
+	}
+
+	customerGraphToken, customerGraphTokenErr := s.newCustomerToken(env, env.GraphEndpoint)
+	if customerGraphTokenErr != nil {
+		return customerGraphTokenErr
+	}
+
+	customerVnetPeeringClient := network.NewVirtualNetworkPeeringsClientWithBaseURI(env.ResourceManagerEndpoint, s.customerPeeringProperties.SubscriptionId)
+	customerVnetPeeringClient.Authorizer = autorest.NewMultiTenantBearerAuthorizer(customerToken)
+	customerServicePrincipalClient := graphrbac.NewServicePrincipalsClientWithBaseURI(env.GraphEndpoint, s.customerPeeringProperties.TenantId)
+	customerServicePrincipalClient.Authorizer = autorest.NewBearerAuthorizer(customerGraphToken)
+	customerRoleAssignmentClient := authorization.NewRoleAssignmentsClientWithBaseURI(env.ResourceManagerEndpoint, s.customerPeeringProperties.SubscriptionId)
+	customerRoleAssignmentClient.Authorizer = autorest.NewBearerAuthorizer(customerResourceManagerToken)
+
+	s.clients = AzureClients{
+		HazelcastVnetPeering:     hazelcastVnetPeeringClient,
+		CustomerVnetPeering:      azureVnetPeeringClient{client: customerVnetPeeringClient},
+		CustomerServicePrincipal: azureServicePrincipalClient{client: customerServicePrincipalClient},
+		CustomerRoleAssignment:   azureRoleAssignmentClient{client: customerRoleAssignmentClient},
 	}
+	return nil
+}
 
-	customerGraphAuthorizer, customerGraphAuthorizerErr := auth.NewAuthorizerFromCLIWithResource(env.GraphEndpoint)
-	if customerGraphAuthorizerErr != nil {
-		return fmt.Errorf("you need to have Azure CLI installed and execute `az login` command successfully. For more information https://docs.cloud.hazelcast.com/docs/azure-vnet-peering . Azure Error:%s", customerGraphAuthorizerErr)
+// initHazelcastClient creates the client of the Hazelcast vnet only, it needs none of your credentials.
+func (s *AzurePeeringService) initHazelcastClient() error {
+	if s.clients.HazelcastVnetPeering != nil {
+		return nil
 	}
+	env, envErr := s.getEnvironment()
+	if envErr != nil {
+		return envErr
+	}
+	hazelcastVnetPeeringClient, hazelcastVnetPeeringClientErr := s.newHazelcastVnetPeeringClient(env)
+	if hazelcastVnetPeeringClientErr != nil {
+		return hazelcastVnetPeeringClientErr
+	}
+	s.clients.HazelcastVnetPeering = hazelcastVnetPeeringClient
+	return nil
+}
+
+func (s *AzurePeeringService) getEnvironment() (azure.Environment, error) {
+	environmentName := s.customerPeeringProperties.Environment
+	if environmentName == "" {
+		environmentName = azure.PublicCloud.Name
+	}
+	return azure.EnvironmentFromName(environmentName)
+}
 
-	s.hazelcastVnetPeeringClient = network.NewVirtualNetworkPeeringsClient(s.hazelcastPeeringProperties.SubscriptionId)
-	s.hazelcastVnetPeeringClient.Authorizer = autorest.NewMultiTenantBearerAuthorizer(hazelcastToken)
-	s.customerVnetPeeringClient = network.NewVirtualNetworkPeeringsClient(s.customerPeeringProperties.SubscriptionId)
-	s.customerVnetPeeringClient.Authorizer = autorest.NewMultiTenantBearerAuthorizer(customerToken)
-	s.customerServicePrincipalClient = graphrbac.NewServicePrincipalsClient(s.customerPeeringProperties.TenantId)
-	s.customerServicePrincipalClient.Authorizer = customerGraphAuthorizer
-	s.customerRoleAssignmentClient = authorization.NewRoleAssignmentsClient(s.customerPeeringProperties.SubscriptionId)
-	s.customerRoleAssignmentClient.Authorizer = customerAuthorizer
+// newHazelcastVnetPeeringClient creates the client of the Hazelcast vnet with the credentials of the Hazelcast
+// application, which is allowed to read your vnet once its service principal has the role assignment on it.
+func (s *AzurePeeringService) newHazelcastVnetPeeringClient(env azure.Environment) (AzureVnetPeeringClient, error) {
+	hazelcastOauthConfig, hazelcastOauthConfigErr := adal.NewMultiTenantOAuthConfig(env.ActiveDirectoryEndpoint,
+		s.hazelcastPeeringProperties.TenantId, []string{s.customerPeeringProperties.TenantId}, adal.OAuthOptions{})
+	if hazelcastOauthConfigErr != nil {
+		return nil, hazelcastOauthConfigErr
+	}
+
+	hazelcastToken, hazelcastTokenErr := adal.NewMultiTenantServicePrincipalToken(hazelcastOauthConfig,
+		s.hazelcastPeeringProperties.AppRegistrationId, s.hazelcastPeeringProperties.AppRegistrationKey, env.ResourceManagerEndpoint)
+	if hazelcastTokenErr != nil {
+		return nil, hazelcastTokenErr
+	}
+
+	hazelcastVnetPeeringClient := network.NewVirtualNetworkPeeringsClientWithBaseURI(env.ResourceManagerEndpoint, s.hazelcastPeeringProperties.SubscriptionId)
+	hazelcastVnetPeeringClient.Authorizer = autorest.NewMultiTenantBearerAuthorizer(hazelcastToken)
+	return azureVnetPeeringClient{client: hazelcastVnetPeeringClient}, nil
+}
+
+// newCustomerToken obtains a token of your account for the resource with the selected auth method.
+func (s *AzurePeeringService) newCustomerToken(env azure.Environment, resource string) (adal.OAuthTokenProvider, error) {
+	properties := s.customerPeeringProperties
+	switch properties.AuthMethod {
+	case "", AzureAuthMethodCli:
+		cliToken, cliTokenErr := cli.GetTokenFromCLI(resource)
+		if cliTokenErr != nil {
+			return nil, fmt.Errorf("you need to have Azure CLI installed and execute `az login` command successfully or use --auth-method. For more information https://docs.cloud.hazelcast.com/docs/azure-vnet-peering . Azure Error:%s", cliTokenErr)
+		}
+		token, tokenErr := cliToken.ToADALToken()
+		if tokenErr != nil {
+			return nil, tokenErr
+		}
+		return &token, nil
+	case AzureAuthMethodClientSecret:
+		clientSecret := properties.ClientSecret
+		if clientSecret == "" {
+			clientSecret = os.Getenv(auth.ClientSecret)
+		}
+		if properties.ClientId == "" || clientSecret == "" {
+			return nil, fmt.Errorf("you need to set --client-id and --client-secret or %s environment variable in order to use %s auth method",
+				auth.ClientSecret, AzureAuthMethodClientSecret)
+		}
+		config := auth.NewClientCredentialsConfig(properties.ClientId, clientSecret, properties.TenantId)
+		config.AADEndpoint = env.ActiveDirectoryEndpoint
+		config.Resource = resource
+		return config.ServicePrincipalToken()
+	case AzureAuthMethodClientCertificate:
+		if properties.ClientId == "" || properties.ClientCertificatePath == "" {
+			return nil, fmt.Errorf("you need to set --client-id and --client-certificate in order to use %s auth method",
+				AzureAuthMethodClientCertificate)
+		}
+		config := auth.NewClientCertificateConfig(properties.ClientCertificatePath, properties.ClientCertificatePassword,
+			properties.ClientId, properties.TenantId)
+		config.AADEndpoint = env.ActiveDirectoryEndpoint
+		config.Resource = resource
+		return config.ServicePrincipalToken()
+	case AzureAuthMethodManagedIdentity:
+		config := auth.NewMSIConfig()
+		config.ClientID = properties.ClientId
+		config.Resource = resource
+		return config.ServicePrincipalToken()
+	case AzureAuthMethodEnvironment:
+		settings, settingsErr := auth.GetSettingsFromEnvironment()
+		if settingsErr != nil {
+			return nil, settingsErr
+		}
+		settings.Environment = env
+		settings.Values[auth.Resource] = resource
+		if config, configErr := settings.GetClientCredentials(); configErr == nil {
+			return config.ServicePrincipalToken()
+		}
+		if config, configErr := settings.GetClientCertificate(); configErr == nil {
+			return config.ServicePrincipalToken()
+		}
+		return settings.GetMSI().ServicePrincipalToken()
+	}
+	return nil, fmt.Errorf("unknown auth method %s, it must be one of %s", properties.AuthMethod, strings.Join(AzureAuthMethods, ", "))
+}
 
+// checkCustomerTenant compares the tenant the token is issued by with the tenant of the peering.
+func (s *AzurePeeringService) checkCustomerTenant(token adal.OAuthTokenProvider) error {
+	if refresher, ok := token.(adal.Refresher); ok {
+		refreshErr := refresher.EnsureFresh()
+		if refreshErr != nil {
+			return fmt.Errorf("could not obtain an Azure token with %s auth method. Azure Error:%s", s.getAuthMethod(), refreshErr)
+		}
+	}
+	tokenParts := strings.Split(token.OAuthToken(), ".")
+	if len(tokenParts) != 3 {
+		return nil
+	}
+	claims, claimsErr := base64.RawURLEncoding.DecodeString(strings.TrimRight(tokenParts[1], "="))
+	if claimsErr != nil {
+		return nil
+	}
+	var tenant struct {
+		TenantId string `json:"tid"`
+	}
+	if json.Unmarshal(claims, &tenant) != nil || tenant.TenantId == "" {
+		return nil
+	}
+	if !strings.EqualFold(tenant.TenantId, s.customerPeeringProperties.TenantId) {
+		if s.getAuthMethod() == AzureAuthMethodCli {
+			return fmt.Errorf("you are logged in to tenant %s with Azure CLI but --tenant-id is %s. Execute `az login --tenant %s` and try again",
+				tenant.TenantId, s.customerPeeringProperties.TenantId, s.customerPeeringProperties.TenantId)
+		}
+		return fmt.Errorf("credentials of %s auth method belong to tenant %s but --tenant-id is %s",
+			s.getAuthMethod(), tenant.TenantId, s.customerPeeringProperties.TenantId)
+	}
 	return nil
 }
 
+func (s *AzurePeeringService) getAuthMethod() string {
+	if s.customerPeeringProperties.AuthMethod == "" {
+		return AzureAuthMethodCli
+	}
+	return s.customerPeeringProperties.AuthMethod
+}
+
+type azureVnetPeeringClient struct {
+	client network.VirtualNetworkPeeringsClient
+}
+
+func (c azureVnetPeeringClient) CreateOrUpdate(ctx context.Context, resourceGroupName string, vnetName string,
+	peeringName string, peering network.VirtualNetworkPeering) (network.VirtualNetworkPeering, error) {
+	future, err := c.client.CreateOrUpdate(ctx, resourceGroupName, vnetName, peeringName, peering)
+	if err != nil {
+		return network.VirtualNetworkPeering{}, err
+	}
+	if err = future.WaitForCompletionRef(ctx, c.client.Client); err != nil {
+		return network.VirtualNetworkPeering{}, err
+	}
+	if _, err = future.Result(c.client); err != nil {
+		return network.VirtualNetworkPeering{}, err
+	}
+	return c.client.Get(ctx, resourceGroupName, vnetName, peeringName)
+}
+
+func (c azureVnetPeeringClient) Get(ctx context.Context, resourceGroupName string, vnetName string,
+	peeringName string) (network.VirtualNetworkPeering, error) {
+	return c.client.Get(ctx, resourceGroupName, vnetName, peeringName)
+}
+
+func (c azureVnetPeeringClient) Delete(ctx context.Context, resourceGroupName string, vnetName string, peeringName string) error {
+	future, err := c.client.Delete(ctx, resourceGroupName, vnetName, peeringName)
+	if err != nil {
+		return err
+	}
+	if err = future.WaitForCompletionRef(ctx, c.client.Client); err != nil {
+		return err
+	}
+	_, err = future.Result(c.client)
+	return err
+}
+
+func (c azureVnetPeeringClient) List(ctx context.Context, resourceGroupName string, vnetName string) ([]network.VirtualNetworkPeering, error) {
+	var peerings []network.VirtualNetworkPeering
+	page, err := c.client.List(ctx, resourceGroupName, vnetName)
+	if err != nil {
+		return nil, err
+	}
+	for page.NotDone() {
+		peerings = append(peerings, page.Values()...)
+		if err = page.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return peerings, nil
+}
+
+type azureServicePrincipalClient struct {
+	client graphrbac.ServicePrincipalsClient
+}
+
+func (c azureServicePrincipalClient) Create(ctx context.Context,
+	parameters graphrbac.ServicePrincipalCreateParameters) (graphrbac.ServicePrincipal, error) {
+	return c.client.Create(ctx, parameters)
+}
+
+func (c azureServicePrincipalClient) Delete(ctx context.Context, objectId string) error {
+	_, err := c.client.Delete(ctx, objectId)
+	return err
+}
+
+func (c azureServicePrincipalClient) List(ctx context.Context, filter string) ([]graphrbac.ServicePrincipal, error) {
+	page, err := c.client.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return page.Values(), nil
+}
+
+type azureRoleAssignmentClient struct {
+	client authorization.RoleAssignmentsClient
+}
+
+func (c azureRoleAssignmentClient) Create(ctx context.Context, scope string, roleAssignmentName string,
+	parameters authorization.RoleAssignmentCreateParameters) (authorization.RoleAssignment, error) {
+	return c.client.Create(ctx, scope, roleAssignmentName, parameters)
+}
+
+func (c azureRoleAssignmentClient) Delete(ctx context.Context, roleAssignmentId string) error {
+	_, err := c.client.DeleteByID(ctx, roleAssignmentId)
+	return err
+}
+
+func (c azureRoleAssignmentClient) List(ctx context.Context, scope string, filter string) ([]authorization.RoleAssignment, error) {
+	var roleAssignments []authorization.RoleAssignment
+	page, err := c.client.ListForScope(ctx, scope, filter)
+	if err != nil {
+		return nil, err
+	}
+	for page.NotDone() {
+		roleAssignments = append(roleAssignments, page.Values()...)
+		if err = page.NextWithContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return roleAssignments, nil
+}
+
+// azurePollInterval and azurePropagationTimeout bound the polling of pollAzure.
+var azurePollInterval = 5 * time.Second
+var azurePropagationTimeout = 5 * time.Minute
+
+// pollAzure calls condition until it is done or fails, it gives up after azurePropagationTimeout.
+func pollAzure(description string, condition func() (bool, error)) error {
+	deadline := time.Now().Add(azurePropagationTimeout)
+	for {
+		done, err := condition()
+		if err != nil || done {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %s", description)
+		}
+		time.Sleep(azurePollInterval)
+	}
+}
+
+// isAzureStatusCode reports whether err is an Azure API error with the http status code.
+func isAzureStatusCode(err error, statusCode int) bool {
+	var requestErr *azure.RequestError
+	if errors.As(err, &requestErr) && requestErr.StatusCode == statusCode {
+		return true
+	}
+	var detailedErr autorest.DetailedError
+	return errors.As(err, &detailedErr) && detailedErr.StatusCode == statusCode
+}
+
+// getAzureErrorCode returns the error code of an Azure API error, it is empty for other errors.
+func getAzureErrorCode(err error) string {
+	var requestErr *azure.RequestError
+	if errors.As(err, &requestErr) && requestErr.ServiceError != nil {
+		return requestErr.ServiceError.Code
+	}
+	return ""
+}
+
 func (s *AzurePeeringService) getCustomerVnetId() string {
 	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/%s",
 		s.customerPeeringProperties.SubscriptionId, s.customerPeeringProperties.ResourceGroupName, s.customerPeeringProperties.VnetName)
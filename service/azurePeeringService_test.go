@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-06-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/hazelcast/hazelcast-cloud-cli/service/fakecloud"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+)
+
+const customerVnetId = "/subscriptions/my-subscription/resourceGroups/my-resource-group/providers/Microsoft.Network/virtualNetworks/my-vnet"
+
+type azurePeeringTestClients struct {
+	vnetPeerings      *fakecloud.AzureVnetPeerings
+	servicePrincipals *fakecloud.AzureServicePrincipals
+	roleAssignments   *fakecloud.AzureRoleAssignments
+}
+
+// failingVnetPeerings fails the creation of vnet peerings and delegates the other calls.
+type failingVnetPeerings struct {
+	AzureVnetPeeringClient
+	err error
+}
+
+func (c failingVnetPeerings) CreateOrUpdate(ctx context.Context, resourceGroupName string, vnetName string, peeringName string,
+	peering network.VirtualNetworkPeering) (network.VirtualNetworkPeering, error) {
+	return network.VirtualNetworkPeering{}, c.err
+}
+
+func newAzurePeeringTestClients() azurePeeringTestClients {
+	clients := azurePeeringTestClients{
+		vnetPeerings:      fakecloud.NewAzureVnetPeerings(),
+		servicePrincipals: fakecloud.NewAzureServicePrincipals(),
+		roleAssignments:   fakecloud.NewAzureRoleAssignments(),
+	}
+	clients.vnetPeerings.AddressPrefixes[customerVnetId] = []string{"10.1.0.0/16"}
+	return clients
+}
+
+func (c azurePeeringTestClients) azureClients() AzureClients {
+	return AzureClients{
+		HazelcastVnetPeering:     c.vnetPeerings,
+		CustomerVnetPeering:      c.vnetPeerings,
+		CustomerServicePrincipal: c.servicePrincipals,
+		CustomerRoleAssignment:   c.roleAssignments,
+	}
+}
+
+func newAzurePeeringTestService(client *hazelcastcloud.Client, clusterId string, clients AzureClients) AzurePeeringService {
+	return NewAzurePeeringServiceWithClients(client, &AzureCustomerPeeringProperties{
+		ClusterId:         clusterId,
+		VnetName:          "my-vnet",
+		SubscriptionId:    "my-subscription",
+		TenantId:          "my-tenant",
+		ResourceGroupName: "my-resource-group",
+	}, clients)
+}
+
+func vnetPeerings(vnetPeeringClient AzureVnetPeeringClient, resourceGroupName string, vnetName string) []network.VirtualNetworkPeering {
+	peerings, _ := vnetPeeringClient.List(context.Background(), resourceGroupName, vnetName)
+	return peerings
+}
+
+func TestAzurePeeringServiceCreate(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "azure", "westeurope")
+	clients := newAzurePeeringTestClients()
+	service := newAzurePeeringTestService(client, clusterId, clients.azureClients())
+
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+
+	customerPeerings := vnetPeerings(clients.vnetPeerings, "my-resource-group", "my-vnet")
+	if len(customerPeerings) != 1 || customerPeerings[0].PeeringState != network.VirtualNetworkPeeringStateConnected {
+		t.Errorf("vnet peerings of my-vnet are %+v", customerPeerings)
+	}
+	hazelcastPeerings := vnetPeerings(clients.vnetPeerings, "hazelcast-"+clusterId, "hazelcast-vnet-"+clusterId)
+	if len(hazelcastPeerings) != 1 || hazelcastPeerings[0].PeeringState != network.VirtualNetworkPeeringStateConnected {
+		t.Fatalf("vnet peerings of the Hazelcast vnet are %+v", hazelcastPeerings)
+	}
+	if len(clients.servicePrincipals.ServicePrincipals) != 1 || len(clients.roleAssignments.RoleAssignments) != 1 ||
+		to.String(clients.roleAssignments.RoleAssignments[0].Properties.Scope) != customerVnetId {
+		t.Errorf("service principals are %+v, role assignments are %+v", clients.servicePrincipals.ServicePrincipals,
+			clients.roleAssignments.RoleAssignments)
+	}
+	peerings := server.AzurePeerings(clusterId)
+	if len(peerings) != 1 || peerings[0].VpcId != "my-vnet" || peerings[0].VpcCidr != "10.1.0.0/16" ||
+		peerings[0].PeeringConnectionId != to.String(hazelcastPeerings[0].Name) {
+		t.Errorf("Hazelcast peerings are %+v", peerings)
+	}
+}
+
+func TestAzurePeeringServiceCreateAgainRemovesOrphanPeering(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "azure", "westeurope")
+	clients := newAzurePeeringTestClients()
+	azureClients := clients.azureClients()
+	azureClients.HazelcastVnetPeering = failingVnetPeerings{clients.vnetPeerings, errors.New("quota exceeded")}
+	service := newAzurePeeringTestService(client, clusterId, azureClients)
+
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err == nil || err.Error() != "quota exceeded" {
+		t.Fatalf("Create returned %v", err)
+	}
+	orphanPeerings := vnetPeerings(clients.vnetPeerings, "my-resource-group", "my-vnet")
+	if len(orphanPeerings) != 1 || orphanPeerings[0].PeeringState != network.VirtualNetworkPeeringStateInitiated {
+		t.Fatalf("vnet peerings of my-vnet are %+v", orphanPeerings)
+	}
+
+	service = newAzurePeeringTestService(client, clusterId, clients.azureClients())
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	customerPeerings := vnetPeerings(clients.vnetPeerings, "my-resource-group", "my-vnet")
+	if len(customerPeerings) != 1 || to.String(customerPeerings[0].Name) == to.String(orphanPeerings[0].Name) ||
+		customerPeerings[0].PeeringState != network.VirtualNetworkPeeringStateConnected {
+		t.Errorf("vnet peerings of my-vnet are %+v, orphan %s is not replaced", customerPeerings, to.String(orphanPeerings[0].Name))
+	}
+	if len(clients.servicePrincipals.ServicePrincipals) != 1 || len(clients.roleAssignments.RoleAssignments) != 1 {
+		t.Errorf("service principals are %+v, role assignments are %+v", clients.servicePrincipals.ServicePrincipals,
+			clients.roleAssignments.RoleAssignments)
+	}
+	if peerings := server.AzurePeerings(clusterId); len(peerings) != 1 {
+		t.Errorf("Hazelcast peerings are %+v", peerings)
+	}
+}
+
+func TestAzurePeeringServiceCreateResume(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "azure", "westeurope")
+	clients := newAzurePeeringTestClients()
+	service := newAzurePeeringTestService(client, clusterId, clients.azureClients())
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	hazelcastPeeringName := to.String(vnetPeerings(clients.vnetPeerings, "hazelcast-"+clusterId, "hazelcast-vnet-"+clusterId)[0].Name)
+
+	service = newAzurePeeringTestService(client, clusterId, clients.azureClients())
+	err := service.Create(util.NewLoadingIndicator("", 100))
+	if err == nil || !strings.Contains(err.Error(), "use --resume to complete it") {
+		t.Fatalf("Create of a connected peering returned %v", err)
+	}
+
+	service = newAzurePeeringTestService(client, clusterId, clients.azureClients())
+	service.customerPeeringProperties.Resume = true
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create with resume failed: %s", err)
+	}
+	hazelcastPeerings := vnetPeerings(clients.vnetPeerings, "hazelcast-"+clusterId, "hazelcast-vnet-"+clusterId)
+	if len(hazelcastPeerings) != 1 || to.String(hazelcastPeerings[0].Name) != hazelcastPeeringName {
+		t.Errorf("vnet peerings of the Hazelcast vnet are %+v, %s is not reused", hazelcastPeerings, hazelcastPeeringName)
+	}
+	if peerings := server.AzurePeerings(clusterId); len(peerings) != 1 || peerings[0].PeeringConnectionId != hazelcastPeeringName {
+		t.Errorf("Hazelcast peerings are %+v", peerings)
+	}
+}
+
+func TestAzurePeeringServiceAccept(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "azure", "westeurope")
+	clients := newAzurePeeringTestClients()
+	hazelcastVnetId := "/subscriptions/00000000-0000-0000-0000-000000000003/resourceGroups/hazelcast-" + clusterId +
+		"/providers/Microsoft.Network/virtualNetworks/hazelcast-vnet-" + clusterId
+	clients.vnetPeerings.AddressPrefixes[hazelcastVnetId] = []string{"10.80.0.0/16"}
+	if _, err := clients.vnetPeerings.CreateOrUpdate(context.Background(), "my-resource-group", "my-vnet", "hazelcast",
+		network.VirtualNetworkPeering{VirtualNetworkPeeringPropertiesFormat: &network.VirtualNetworkPeeringPropertiesFormat{
+			RemoteVirtualNetwork: &network.SubResource{ID: to.StringPtr(hazelcastVnetId)},
+		}}); err != nil {
+		t.Fatal(err)
+	}
+	service := newAzurePeeringTestService(client, clusterId, AzureClients{HazelcastVnetPeering: clients.vnetPeerings})
+
+	if err := service.Accept(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Accept failed: %s", err)
+	}
+	if len(clients.servicePrincipals.ServicePrincipals) != 0 || len(clients.roleAssignments.RoleAssignments) != 0 {
+		t.Errorf("service principals are %+v, role assignments are %+v", clients.servicePrincipals.ServicePrincipals,
+			clients.roleAssignments.RoleAssignments)
+	}
+	customerPeerings := vnetPeerings(clients.vnetPeerings, "my-resource-group", "my-vnet")
+	if len(customerPeerings) != 1 || customerPeerings[0].PeeringState != network.VirtualNetworkPeeringStateConnected {
+		t.Errorf("vnet peerings of my-vnet are %+v", customerPeerings)
+	}
+	hazelcastPeerings := vnetPeerings(clients.vnetPeerings, "hazelcast-"+clusterId, "hazelcast-vnet-"+clusterId)
+	if len(hazelcastPeerings) != 1 || hazelcastPeerings[0].PeeringState != network.VirtualNetworkPeeringStateConnected {
+		t.Fatalf("vnet peerings of the Hazelcast vnet are %+v", hazelcastPeerings)
+	}
+	peerings := server.AzurePeerings(clusterId)
+	if len(peerings) != 1 || peerings[0].VpcId != "my-vnet" || peerings[0].VpcCidr != "10.1.0.0/16" {
+		t.Errorf("Hazelcast peerings are %+v", peerings)
+	}
+
+	service = newAzurePeeringTestService(client, clusterId, AzureClients{HazelcastVnetPeering: clients.vnetPeerings})
+	if err := service.Accept(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Accept again failed: %s", err)
+	}
+	if again := vnetPeerings(clients.vnetPeerings, "hazelcast-"+clusterId, "hazelcast-vnet-"+clusterId); len(again) != 1 ||
+		to.String(again[0].Name) != to.String(hazelcastPeerings[0].Name) {
+		t.Errorf("vnet peerings of the Hazelcast vnet are %+v, %s is not reused", again, to.String(hazelcastPeerings[0].Name))
+	}
+	if peerings := server.AzurePeerings(clusterId); len(peerings) != 1 {
+		t.Errorf("Hazelcast peerings are %+v", peerings)
+	}
+}
+
+func TestAzurePeeringServiceDelete(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "azure", "westeurope")
+	clients := newAzurePeeringTestClients()
+	service := newAzurePeeringTestService(client, clusterId, clients.azureClients())
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	peeringId := server.AzurePeerings(clusterId)[0].Id
+
+	service = newAzurePeeringTestService(client, clusterId, clients.azureClients())
+	service.customerPeeringProperties.VnetName = ""
+	service.customerPeeringProperties.RemoveServicePrincipal = true
+	if err := service.Delete(peeringId, util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+	if peerings := vnetPeerings(clients.vnetPeerings, "my-resource-group", "my-vnet"); len(peerings) != 0 {
+		t.Errorf("vnet peerings %+v of my-vnet are left behind", peerings)
+	}
+	hazelcastPeerings := vnetPeerings(clients.vnetPeerings, "hazelcast-"+clusterId, "hazelcast-vnet-"+clusterId)
+	if len(hazelcastPeerings) != 1 || hazelcastPeerings[0].PeeringState != network.VirtualNetworkPeeringStateDisconnected {
+		t.Errorf("vnet peerings of the Hazelcast vnet are %+v", hazelcastPeerings)
+	}
+	if len(clients.servicePrincipals.ServicePrincipals) != 0 || len(clients.roleAssignments.RoleAssignments) != 0 {
+		t.Errorf("service principals %+v and role assignments %+v are left behind", clients.servicePrincipals.ServicePrincipals,
+			clients.roleAssignments.RoleAssignments)
+	}
+	if removed := service.RemovedResources(); len(removed) != 3 {
+		t.Errorf("removed resources are %v", removed)
+	}
+	if peerings := server.AzurePeerings(clusterId); len(peerings) != 0 {
+		t.Errorf("Hazelcast peerings %+v are left behind", peerings)
+	}
+}
+
+func TestAzurePeeringServiceDeleteErrors(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "azure", "westeurope")
+	clients := newAzurePeeringTestClients()
+	service := newAzurePeeringTestService(client, clusterId, clients.azureClients())
+	if err := service.Create(util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	peeringId := server.AzurePeerings(clusterId)[0].Id
+
+	clients.vnetPeerings.Errors["Delete"] = autorest.DetailedError{StatusCode: http.StatusInternalServerError, Message: "vnet is busy"}
+	service = newAzurePeeringTestService(client, clusterId, clients.azureClients())
+	if err := service.Delete(peeringId, util.NewLoadingIndicator("", 100)); err == nil || !strings.Contains(err.Error(), "vnet is busy") {
+		t.Fatalf("Delete returned %v", err)
+	}
+	if peerings := server.AzurePeerings(clusterId); len(peerings) != 1 {
+		t.Errorf("Hazelcast peering is deleted after a failed vnet peering delete, peerings are %+v", peerings)
+	}
+
+	clients.vnetPeerings.Errors["Delete"] = autorest.DetailedError{StatusCode: http.StatusNotFound, Message: "peering not found"}
+	if err := service.Delete(peeringId, util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Delete of an already deleted vnet peering failed: %s", err)
+	}
+	if peerings := server.AzurePeerings(clusterId); len(peerings) != 0 {
+		t.Errorf("Hazelcast peerings %+v are left behind", peerings)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+	"path"
+	"strings"
+)
+
+// ClusterSelectorKeys are the keys a cluster selector can match on.
+var ClusterSelectorKeys = []string{"id", "name", "state", "version", "cloud-provider", "region"}
+
+// ClusterSelector matches the clusters all of whose terms match. An empty selector matches every cluster.
+type ClusterSelector []clusterSelectorTerm
+
+type clusterSelectorTerm struct {
+	key     string
+	pattern string
+}
+
+// ParseClusterSelector parses a comma separated list of key=pattern terms like "name=ci-*,state=RUNNING". Patterns
+// are shell patterns, states are matched ignoring case.
+func ParseClusterSelector(selector string) (ClusterSelector, error) {
+	var clusterSelector ClusterSelector
+	for _, term := range strings.Split(selector, ",") {
+		if strings.TrimSpace(term) == "" {
+			continue
+		}
+		keyValue := strings.SplitN(term, "=", 2)
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("selector term %s is not in key=pattern format", term)
+		}
+		key := strings.TrimSpace(keyValue[0])
+		if !isClusterSelectorKey(key) {
+			return nil, fmt.Errorf("selector key %s is not one of %s", key, strings.Join(ClusterSelectorKeys, ", "))
+		}
+		pattern := strings.TrimSpace(keyValue[1])
+		if _, matchErr := path.Match(pattern, ""); matchErr != nil {
+			return nil, fmt.Errorf("selector pattern %s is not valid. %s", pattern, matchErr)
+		}
+		clusterSelector = append(clusterSelector, clusterSelectorTerm{key: key, pattern: pattern})
+	}
+	if len(clusterSelector) == 0 {
+		return nil, fmt.Errorf("selector is empty")
+	}
+	return clusterSelector, nil
+}
+
+func (s ClusterSelector) Matches(cluster models.Cluster) bool {
+	for _, term := range s {
+		var value string
+		pattern := term.pattern
+		switch term.key {
+		case "id":
+			value = cluster.Id
+		case "name":
+			value = cluster.Name
+		case "state":
+			value = strings.ToUpper(string(cluster.State))
+			pattern = strings.ToUpper(pattern)
+		case "version":
+			value = cluster.HazelcastVersion
+		case "cloud-provider":
+			value = cluster.CloudProvider.Name
+		case "region":
+			value = cluster.CloudProvider.Region
+		}
+		if matched, _ := path.Match(pattern, value); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func isClusterSelectorKey(key string) bool {
+	for _, selectorKey := range ClusterSelectorKeys {
+		if selectorKey == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+func TestParseClusterSelectorErrors(t *testing.T) {
+	tests := []struct {
+		selector string
+		err      string
+	}{
+		{"owner=me", "selector key owner is not one of id, name, state, version, cloud-provider, region"},
+		{"name=ci-[", "selector pattern ci-[ is not valid"},
+		{"name", "selector term name is not in key=pattern format"},
+		{"name=ci-*,state", "selector term state is not in key=pattern format"},
+		{"", "selector is empty"},
+		{" , ", "selector is empty"},
+	}
+	for _, test := range tests {
+		selector, err := ParseClusterSelector(test.selector)
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("ParseClusterSelector(%q) returned %v, %v", test.selector, selector, err)
+		}
+	}
+}
+
+func TestClusterSelectorMatches(t *testing.T) {
+	cluster := models.Cluster{Id: "42", Name: "ci-build", State: models.Running, HazelcastVersion: "5.4.0"}
+	cluster.CloudProvider.Name = "aws"
+	cluster.CloudProvider.Region = "eu-west-2"
+	tests := []struct {
+		selector string
+		matches  bool
+	}{
+		{"name=ci-*", true},
+		{"name=ci-*,state=running", true},
+		{"state=Run*", true},
+		{"state=STOPPED", false},
+		{"name=ci-*,region=us-*", false},
+		{"id=42, version=5.*, cloud-provider=aws", true},
+	}
+	for _, test := range tests {
+		selector, err := ParseClusterSelector(test.selector)
+		if err != nil {
+			t.Fatalf("ParseClusterSelector(%q) failed: %s", test.selector, err)
+		}
+		if matches := selector.Matches(cluster); matches != test.matches {
+			t.Errorf("%q matches %v, expected %v", test.selector, matches, test.matches)
+		}
+	}
+}
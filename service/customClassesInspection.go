@@ -0,0 +1,219 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	InspectionSeverityError   = "ERROR"
+	InspectionSeverityWarning = "WARNING"
+)
+
+// customClassesSizeWarning is the size above which uploading and loading an artifact gets slow.
+const customClassesSizeWarning = 100 * 1024 * 1024
+
+type CustomClassesInspection struct {
+	Name               string                 `json:"name"`
+	Size               int64                  `json:"size"`
+	Packages           []CustomClassesPackage `json:"packages"`
+	Classes            []CustomClassesClass   `json:"classes"`
+	MaxJavaVersion     int                    `json:"maxJavaVersion"`
+	ClusterJavaVersion int                    `json:"clusterJavaVersion,omitempty"`
+	Problems           []CustomClassesProblem `json:"problems"`
+}
+
+type CustomClassesPackage struct {
+	Name           string `json:"name"`
+	ClassCount     int    `json:"classCount"`
+	MaxJavaVersion int    `json:"maxJavaVersion"`
+}
+
+type CustomClassesClass struct {
+	Name        string `json:"name"`
+	JavaVersion int    `json:"javaVersion"`
+}
+
+type CustomClassesProblem struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// javaVersionsOfHazelcast are the Java versions assumed for the clusters of a Hazelcast version, by the first
+// Hazelcast version of the runtime. The API does not tell the Java version of a cluster: the table follows the Java
+// runtime of the official hazelcast/hazelcast Docker images of these versions, so a cluster may run a newer Java and
+// classes compiled for a newer Java are only reported as a warning.
+var javaVersionsOfHazelcast = []struct {
+	hazelcastVersion []int
+	javaVersion      int
+}{
+	{[]int{5, 4}, 17},
+	{[]int{4, 0}, 11},
+	{[]int{0}, 8},
+}
+
+func (i CustomClassesInspection) HasErrors() bool {
+	for _, problem := range i.Problems {
+		if problem.Severity == InspectionSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// InspectCustomClassesFile lists the packages and classes of a jar and reports the classes the cluster can not load:
+// classes in com.hazelcast packages as errors and classes compiled for a newer Java than the runtime assumed for
+// hazelcastVersion as warnings. The Java version is not checked when hazelcastVersion is empty.
+func InspectCustomClassesFile(file CustomClassesFile, hazelcastVersion string) (*CustomClassesInspection, error) {
+	inspection := &CustomClassesInspection{Name: file.Name, Size: file.Size}
+	if file.Size > customClassesSizeWarning {
+		inspection.Problems = append(inspection.Problems, CustomClassesProblem{InspectionSeverityWarning,
+			fmt.Sprintf("%s is %d MiB, artifacts larger than %d MiB slow down uploads and cluster restarts", file.Name,
+				file.Size/1024/1024, customClassesSizeWarning/1024/1024)})
+	}
+	if hazelcastVersion != "" {
+		javaVersion, javaVersionErr := getJavaVersionOfHazelcast(hazelcastVersion)
+		if javaVersionErr != nil {
+			inspection.Problems = append(inspection.Problems, CustomClassesProblem{InspectionSeverityWarning, javaVersionErr.Error()})
+		}
+		inspection.ClusterJavaVersion = javaVersion
+	}
+
+	reader, closer, readerErr := openZip(file)
+	if readerErr != nil {
+		inspection.Problems = append(inspection.Problems, CustomClassesProblem{InspectionSeverityError,
+			fmt.Sprintf("%s is not a jar. %s", file.Name, readerErr)})
+		return inspection, nil
+	}
+	defer closer.Close()
+	packages := map[string]*CustomClassesPackage{}
+	shadowedPackages := map[string]bool{}
+	for _, entry := range reader.File {
+		// classes of multi-release jars for newer Java versions and module descriptors are not loaded as classes
+		if !strings.HasSuffix(entry.Name, ".class") || strings.HasPrefix(entry.Name, "META-INF/") ||
+			path.Base(entry.Name) == "module-info.class" {
+			continue
+		}
+		javaVersion, javaVersionErr := readClassJavaVersion(entry)
+		if javaVersionErr != nil {
+			inspection.Problems = append(inspection.Problems, CustomClassesProblem{InspectionSeverityError,
+				fmt.Sprintf("%s is not a valid class file. %s", entry.Name, javaVersionErr)})
+			continue
+		}
+		className := strings.Replace(strings.TrimSuffix(entry.Name, ".class"), "/", ".", -1)
+		packageName := strings.Replace(path.Dir(entry.Name), "/", ".", -1)
+		if packageName == "." {
+			packageName = ""
+		}
+		inspection.Classes = append(inspection.Classes, CustomClassesClass{Name: className, JavaVersion: javaVersion})
+		if _, ok := packages[packageName]; !ok {
+			packages[packageName] = &CustomClassesPackage{Name: packageName}
+		}
+		packages[packageName].ClassCount++
+		if javaVersion > packages[packageName].MaxJavaVersion {
+			packages[packageName].MaxJavaVersion = javaVersion
+		}
+		if javaVersion > inspection.MaxJavaVersion {
+			inspection.MaxJavaVersion = javaVersion
+		}
+		if packageName == "com.hazelcast" || strings.HasPrefix(packageName, "com.hazelcast.") {
+			shadowedPackages[packageName] = true
+		}
+	}
+	for _, classPackage := range packages {
+		inspection.Packages = append(inspection.Packages, *classPackage)
+	}
+	sort.Slice(inspection.Packages, func(i, j int) bool { return inspection.Packages[i].Name < inspection.Packages[j].Name })
+	sort.Slice(inspection.Classes, func(i, j int) bool { return inspection.Classes[i].Name < inspection.Classes[j].Name })
+
+	if len(inspection.Classes) == 0 {
+		inspection.Problems = append(inspection.Problems, CustomClassesProblem{InspectionSeverityWarning,
+			fmt.Sprintf("%s has no classes", file.Name)})
+	}
+	for _, classPackage := range inspection.Packages {
+		if shadowedPackages[classPackage.Name] {
+			inspection.Problems = append(inspection.Problems, CustomClassesProblem{InspectionSeverityError,
+				fmt.Sprintf("%d classes of package %s shadow the classes of Hazelcast", classPackage.ClassCount, classPackage.Name)})
+		}
+		if inspection.ClusterJavaVersion != 0 && classPackage.MaxJavaVersion > inspection.ClusterJavaVersion {
+			inspection.Problems = append(inspection.Problems, CustomClassesProblem{InspectionSeverityWarning,
+				fmt.Sprintf("classes of package %s are compiled for Java %d, the cluster runs Hazelcast %s which is assumed to run on Java %d",
+					classPackage.Name, classPackage.MaxJavaVersion, hazelcastVersion, inspection.ClusterJavaVersion)})
+		}
+	}
+	return inspection, nil
+}
+
+func openZip(file CustomClassesFile) (*zip.Reader, io.Closer, error) {
+	if file.Path == "" {
+		reader, readerErr := zip.NewReader(bytes.NewReader(file.Content), int64(len(file.Content)))
+		return reader, ioutil.NopCloser(nil), readerErr
+	}
+	reader, readerErr := zip.OpenReader(file.Path)
+	if readerErr != nil {
+		return nil, nil, readerErr
+	}
+	return &reader.Reader, reader, nil
+}
+
+// readClassJavaVersion reads the major version of a class file, which is the Java version plus 44.
+func readClassJavaVersion(entry *zip.File) (int, error) {
+	content, openErr := entry.Open()
+	if openErr != nil {
+		return 0, openErr
+	}
+	defer content.Close()
+	header := make([]byte, 8)
+	if _, readErr := io.ReadFull(content, header); readErr != nil {
+		return 0, readErr
+	}
+	if binary.BigEndian.Uint32(header[0:4]) != 0xCAFEBABE {
+		return 0, fmt.Errorf("magic number is missing")
+	}
+	_, _ = io.Copy(ioutil.Discard, content)
+	return int(binary.BigEndian.Uint16(header[6:8])) - 44, nil
+}
+
+func getJavaVersionOfHazelcast(hazelcastVersion string) (int, error) {
+	var version []int
+	for _, part := range strings.Split(strings.SplitN(hazelcastVersion, "-", 2)[0], ".") {
+		number, numberErr := strconv.Atoi(part)
+		if numberErr != nil {
+			return 0, fmt.Errorf("Java version of Hazelcast %s is not known, class versions are not checked", hazelcastVersion)
+		}
+		version = append(version, number)
+	}
+	for _, javaVersion := range javaVersionsOfHazelcast {
+		if compareVersions(version, javaVersion.hazelcastVersion) >= 0 {
+			return javaVersion.javaVersion, nil
+		}
+	}
+	return 0, fmt.Errorf("Java version of Hazelcast %s is not known, class versions are not checked", hazelcastVersion)
+}
+
+func compareVersions(version []int, other []int) int {
+	for i := 0; i < len(version) || i < len(other); i++ {
+		var part, otherPart int
+		if i < len(version) {
+			part = version[i]
+		}
+		if i < len(other) {
+			otherPart = other[i]
+		}
+		if part != otherPart {
+			if part < otherPart {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,531 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+// ArtifactClient is the custom classes part of the EnterpriseCluster and ServerlessCluster services of the SDK.
+type ArtifactClient interface {
+	ListUploadedArtifacts(ctx context.Context, request *models.ListUploadedArtifactsInput) (*[]models.UploadedArtifact, *hazelcastcloud.Response, error)
+	UploadArtifact(ctx context.Context, request *models.UploadArtifactInput) (*models.UploadedArtifact, *hazelcastcloud.Response, error)
+	DeleteArtifact(ctx context.Context, request *models.DeleteArtifactInput) (*models.UploadedArtifact, *hazelcastcloud.Response, error)
+	DownloadArtifact(ctx context.Context, request *models.DownloadArtifactInput) (*models.UploadedArtifactLink, *hazelcastcloud.Response, error)
+}
+
+type CustomClassesService struct {
+	Artifacts  ArtifactClient
+	ClusterId  string
+	HttpClient *http.Client
+}
+
+// CustomClassesFile is a file to upload as an artifact. Path is empty for the jars packaged from the loose class
+// files of a directory, which are kept in Content.
+type CustomClassesFile struct {
+	Name    string
+	Path    string
+	Size    int64
+	Sha256  string
+	Content []byte
+}
+
+const (
+	ArtifactStatusReady  = "READY"
+	ArtifactStatusFailed = "FAILED"
+)
+
+const (
+	SyncActionUpload    = "UPLOAD"
+	SyncActionReplace   = "REPLACE"
+	SyncActionUnchanged = "UNCHANGED"
+	SyncActionDelete    = "DELETE"
+	SyncActionKeep      = "KEEP"
+)
+
+// CustomClassesSyncAction is what sync does for a file name. File is the local file to upload, ArtifactIds are the
+// uploaded artifacts with the name, which are deleted by the REPLACE and DELETE actions.
+type CustomClassesSyncAction struct {
+	Action      string
+	Name        string
+	File        *CustomClassesFile
+	ArtifactIds []string
+}
+
+// artifactPollInterval is the interval of the polling of WaitForArtifacts.
+var artifactPollInterval = 5 * time.Second
+
+// packagedJarModTime is the modification time of the entries of packaged jars, so that packaging the same class files
+// again gives the same hash.
+var packagedJarModTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func NewCustomClassesService(artifacts ArtifactClient, clusterId string) CustomClassesService {
+	return CustomClassesService{
+		Artifacts:  artifacts,
+		ClusterId:  clusterId,
+		HttpClient: http.DefaultClient,
+	}
+}
+
+func (f CustomClassesFile) Open() (io.ReadCloser, error) {
+	if f.Path == "" {
+		return ioutil.NopCloser(bytes.NewReader(f.Content)), nil
+	}
+	return os.Open(f.Path)
+}
+
+// CollectCustomClassesFiles returns the files to upload for the given files and directories. The jars directly in a
+// directory are uploaded as they are, its class files are packaged into a jar named after the directory.
+func CollectCustomClassesFiles(fileNames []string, dirs []string) ([]CustomClassesFile, error) {
+	var files []CustomClassesFile
+	for _, fileName := range fileNames {
+		file, fileErr := newCustomClassesFile(fileName)
+		if fileErr != nil {
+			return nil, fileErr
+		}
+		files = append(files, file)
+	}
+	for _, dir := range dirs {
+		jars, jarsErr := filepath.Glob(filepath.Join(dir, "*.jar"))
+		if jarsErr != nil {
+			return nil, jarsErr
+		}
+		for _, jar := range jars {
+			file, fileErr := newCustomClassesFile(jar)
+			if fileErr != nil {
+				return nil, fileErr
+			}
+			files = append(files, file)
+		}
+		packagedJar, packageErr := packageClassFiles(dir)
+		if packageErr != nil {
+			return nil, packageErr
+		}
+		if packagedJar != nil {
+			files = append(files, *packagedJar)
+		}
+		if len(jars) == 0 && packagedJar == nil {
+			return nil, fmt.Errorf("directory %s has no jar or class files", dir)
+		}
+	}
+	var uniqueFiles []CustomClassesFile
+	seenNames := map[string]string{}
+	for _, file := range files {
+		source := file.Path
+		if source == "" {
+			source = "packaged class files"
+		}
+		if seenSource, ok := seenNames[file.Name]; ok {
+			if seenSource == source {
+				continue
+			}
+			return nil, fmt.Errorf("%s and %s would both be uploaded as %s", seenSource, source, file.Name)
+		}
+		seenNames[file.Name] = source
+		uniqueFiles = append(uniqueFiles, file)
+	}
+	return uniqueFiles, nil
+}
+
+// FindBuildArtifacts returns the jars built by Maven into target or by Gradle into build/libs of the project
+// directory, without the sources, javadoc and test jars.
+func FindBuildArtifacts(projectDir string, outputDir string) ([]string, error) {
+	jars, jarsErr := filepath.Glob(filepath.Join(projectDir, outputDir, "*.jar"))
+	if jarsErr != nil {
+		return nil, jarsErr
+	}
+	var artifacts []string
+	for _, jar := range jars {
+		name := filepath.Base(jar)
+		if strings.HasSuffix(name, "-sources.jar") || strings.HasSuffix(name, "-javadoc.jar") ||
+			strings.HasSuffix(name, "-tests.jar") || strings.HasPrefix(name, "original-") {
+			continue
+		}
+		artifacts = append(artifacts, jar)
+	}
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("no jar found in %s, please build the project first", filepath.Join(projectDir, outputDir))
+	}
+	return artifacts, nil
+}
+
+// FindUnchanged returns the uploaded artifact with the name and the content of the file, nil when there is none.
+// The size of the uploaded artifacts with the same name is read first, only the ones with the size of the file are
+// downloaded to compare their content.
+func (s CustomClassesService) FindUnchanged(file CustomClassesFile, uploadedArtifacts []models.UploadedArtifact) (*models.UploadedArtifact, error) {
+	for _, uploadedArtifact := range uploadedArtifacts {
+		if uploadedArtifact.Name != file.Name {
+			continue
+		}
+		link, linkErr := s.Link(uploadedArtifact.Id)
+		if linkErr != nil {
+			return nil, linkErr
+		}
+		uploadedSize, sizeErr := s.sizeOfLink(link)
+		if sizeErr != nil {
+			return nil, sizeErr
+		}
+		if uploadedSize >= 0 && uploadedSize != file.Size {
+			continue
+		}
+		uploadedHash, hashErr := s.hashLink(link)
+		if hashErr != nil {
+			return nil, hashErr
+		}
+		if uploadedHash == file.Sha256 {
+			artifact := uploadedArtifact
+			return &artifact, nil
+		}
+	}
+	return nil, nil
+}
+
+// HashUploaded returns the hex encoded SHA-256 of the content of an uploaded artifact.
+func (s CustomClassesService) HashUploaded(artifactId string) (string, error) {
+	link, linkErr := s.Link(artifactId)
+	if linkErr != nil {
+		return "", linkErr
+	}
+	return s.hashLink(link)
+}
+
+func (s CustomClassesService) hashLink(link *models.UploadedArtifactLink) (string, error) {
+	response, responseErr := s.HttpClient.Get(link.Url)
+	if responseErr != nil {
+		return "", responseErr
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("artifact %s could not be downloaded, %s", link.Name, response.Status)
+	}
+	hash := sha256.New()
+	if _, copyErr := io.Copy(hash, response.Body); copyErr != nil {
+		return "", copyErr
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// sizeOfLink returns the size of an uploaded artifact without downloading it, from the Content-Range of a request of
+// its first byte, or from the Content-Length when the range is ignored. It is -1 when neither is known.
+func (s CustomClassesService) sizeOfLink(link *models.UploadedArtifactLink) (int64, error) {
+	request, requestErr := http.NewRequest(http.MethodGet, link.Url, nil)
+	if requestErr != nil {
+		return 0, requestErr
+	}
+	request.Header.Set("Range", "bytes=0-0")
+	response, responseErr := s.HttpClient.Do(request)
+	if responseErr != nil {
+		return 0, responseErr
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable:
+		// bytes 0-0/size, or bytes */size for an empty artifact
+		contentRange := response.Header.Get("Content-Range")
+		size, parseErr := strconv.ParseInt(contentRange[strings.LastIndex(contentRange, "/")+1:], 10, 64)
+		if parseErr != nil {
+			return -1, nil
+		}
+		return size, nil
+	case http.StatusOK:
+		return response.ContentLength, nil
+	default:
+		return 0, fmt.Errorf("artifact %s could not be downloaded, %s", link.Name, response.Status)
+	}
+}
+
+func (s CustomClassesService) List() ([]models.UploadedArtifact, error) {
+	artifacts, _, artifactsErr := s.Artifacts.ListUploadedArtifacts(context.Background(), &models.ListUploadedArtifactsInput{
+		ClusterId: s.ClusterId,
+	})
+	if artifactsErr != nil {
+		return nil, artifactsErr
+	}
+	return *artifacts, nil
+}
+
+// WaitForArtifacts polls the uploaded artifacts until the artifacts with the ids reach a terminal status or the
+// timeout passes. onStatus is called with every status an artifact goes through, including the first one seen.
+func (s CustomClassesService) WaitForArtifacts(artifactIds []string, timeout time.Duration,
+	onStatus func(artifact models.UploadedArtifact)) (map[string]models.UploadedArtifact, error) {
+	deadline := time.Now().Add(timeout)
+	lastArtifacts := map[string]models.UploadedArtifact{}
+	for {
+		uploadedArtifacts, uploadedArtifactsErr := s.List()
+		if uploadedArtifactsErr != nil {
+			return lastArtifacts, uploadedArtifactsErr
+		}
+		pendingCount := 0
+		for _, artifactId := range artifactIds {
+			artifact, found := findArtifact(uploadedArtifacts, artifactId)
+			if !found {
+				return lastArtifacts, fmt.Errorf("artifact %s is not uploaded any more", artifactId)
+			}
+			if lastArtifact, seen := lastArtifacts[artifactId]; !seen || lastArtifact.Status != artifact.Status {
+				onStatus(artifact)
+			}
+			lastArtifacts[artifactId] = artifact
+			if !IsArtifactStatusTerminal(artifact.Status) {
+				pendingCount++
+			}
+		}
+		if pendingCount == 0 {
+			return lastArtifacts, nil
+		}
+		if time.Now().After(deadline) {
+			return lastArtifacts, fmt.Errorf("timed out waiting for %d artifacts to be processed", pendingCount)
+		}
+		time.Sleep(artifactPollInterval)
+	}
+}
+
+// PlanSync compares the local files to the uploaded artifacts by name and content. Uploaded artifacts without a
+// local file are deleted when prune is set, kept otherwise.
+func (s CustomClassesService) PlanSync(files []CustomClassesFile, uploadedArtifacts []models.UploadedArtifact, prune bool) ([]CustomClassesSyncAction, error) {
+	var actions []CustomClassesSyncAction
+	localNames := map[string]bool{}
+	for k := range files {
+		file := files[k]
+		localNames[file.Name] = true
+		var artifactIds []string
+		for _, uploadedArtifact := range uploadedArtifacts {
+			if uploadedArtifact.Name == file.Name {
+				artifactIds = append(artifactIds, uploadedArtifact.Id)
+			}
+		}
+		if len(artifactIds) == 0 {
+			actions = append(actions, CustomClassesSyncAction{Action: SyncActionUpload, Name: file.Name, File: &file})
+			continue
+		}
+		unchangedArtifact, unchangedErr := s.FindUnchanged(file, uploadedArtifacts)
+		if unchangedErr != nil {
+			return nil, unchangedErr
+		}
+		if unchangedArtifact != nil && len(artifactIds) == 1 {
+			actions = append(actions, CustomClassesSyncAction{Action: SyncActionUnchanged, Name: file.Name, ArtifactIds: artifactIds})
+			continue
+		}
+		actions = append(actions, CustomClassesSyncAction{Action: SyncActionReplace, Name: file.Name, File: &file, ArtifactIds: artifactIds})
+	}
+	for _, uploadedArtifact := range uploadedArtifacts {
+		if localNames[uploadedArtifact.Name] {
+			continue
+		}
+		action := SyncActionKeep
+		if prune {
+			action = SyncActionDelete
+		}
+		actions = append(actions, CustomClassesSyncAction{Action: action, Name: uploadedArtifact.Name, ArtifactIds: []string{uploadedArtifact.Id}})
+	}
+	return actions, nil
+}
+
+func IsArtifactStatusTerminal(status string) bool {
+	return status == ArtifactStatusReady || status == ArtifactStatusFailed
+}
+
+func findArtifact(artifacts []models.UploadedArtifact, artifactId string) (models.UploadedArtifact, bool) {
+	for _, artifact := range artifacts {
+		if artifact.Id == artifactId {
+			return artifact, true
+		}
+	}
+	return models.UploadedArtifact{}, false
+}
+
+func (s CustomClassesService) Upload(file CustomClassesFile, content io.Reader) (*models.UploadedArtifact, error) {
+	artifact, _, uploadErr := s.Artifacts.UploadArtifact(context.Background(), &models.UploadArtifactInput{
+		ClusterId: s.ClusterId,
+		FileName:  file.Name,
+		Content:   content,
+	})
+	return artifact, uploadErr
+}
+
+// Download downloads an uploaded artifact to path through a part file next to it. An interrupted download is resumed
+// from its part file with a range request. The download fails when expectedSha256 is set and does not match, it
+// returns the hex encoded SHA-256 of the downloaded file otherwise.
+func (s CustomClassesService) Download(link *models.UploadedArtifactLink, path string, expectedSha256 string,
+	progress func(size int64, offset int64, description string) io.Writer) (string, error) {
+	partPath := fmt.Sprintf("%s.%s.part", path, link.Id)
+	var offset int64
+	if stat, statErr := os.Stat(partPath); statErr == nil {
+		offset = stat.Size()
+	}
+	request, requestErr := http.NewRequest(http.MethodGet, link.Url, nil)
+	if requestErr != nil {
+		return "", requestErr
+	}
+	if offset != 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	response, responseErr := s.HttpClient.Do(request)
+	if responseErr != nil {
+		return "", responseErr
+	}
+	defer response.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	description := "downloading " + link.Name
+	switch {
+	case response.StatusCode == http.StatusPartialContent && offset != 0:
+		flags = os.O_WRONLY | os.O_APPEND
+		description = "resuming " + link.Name
+	case response.StatusCode == http.StatusOK:
+		offset = 0
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset != 0:
+		// the part file is already complete
+		return completeDownload(link, partPath, path, expectedSha256)
+	default:
+		return "", fmt.Errorf("artifact %s could not be downloaded, %s", link.Name, response.Status)
+	}
+	partFile, openErr := os.OpenFile(partPath, flags, 0644)
+	if openErr != nil {
+		return "", openErr
+	}
+	_, copyErr := io.Copy(io.MultiWriter(partFile, progress(offset+response.ContentLength, offset, description)), response.Body)
+	closeErr := partFile.Close()
+	if copyErr != nil {
+		return "", fmt.Errorf("download of %s interrupted, run the command again to resume it. %s", link.Name, copyErr)
+	}
+	if closeErr != nil {
+		return "", closeErr
+	}
+	return completeDownload(link, partPath, path, expectedSha256)
+}
+
+func completeDownload(link *models.UploadedArtifactLink, partPath string, path string, expectedSha256 string) (string, error) {
+	sha256Hash, hashErr := hashFile(partPath)
+	if hashErr != nil {
+		return "", hashErr
+	}
+	if expectedSha256 != "" && !strings.EqualFold(expectedSha256, sha256Hash) {
+		_ = os.Remove(partPath)
+		return sha256Hash, fmt.Errorf("SHA-256 of %s is %s, expected %s", link.Name, sha256Hash, expectedSha256)
+	}
+	return sha256Hash, os.Rename(partPath, path)
+}
+
+func (s CustomClassesService) Link(artifactId string) (*models.UploadedArtifactLink, error) {
+	link, _, linkErr := s.Artifacts.DownloadArtifact(context.Background(), &models.DownloadArtifactInput{
+		ClusterId:       s.ClusterId,
+		CustomClassesId: artifactId,
+	})
+	return link, linkErr
+}
+
+func (s CustomClassesService) Delete(artifactId string) error {
+	_, _, deleteErr := s.Artifacts.DeleteArtifact(context.Background(), &models.DeleteArtifactInput{
+		ClusterId:       s.ClusterId,
+		CustomClassesId: artifactId,
+	})
+	return deleteErr
+}
+
+func newCustomClassesFile(path string) (CustomClassesFile, error) {
+	stat, statErr := os.Stat(path)
+	if statErr != nil {
+		return CustomClassesFile{}, statErr
+	}
+	sha256Hash, hashErr := hashFile(path)
+	if hashErr != nil {
+		return CustomClassesFile{}, hashErr
+	}
+	return CustomClassesFile{
+		Name:   filepath.Base(path),
+		Path:   path,
+		Size:   stat.Size(),
+		Sha256: sha256Hash,
+	}, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, openErr := os.Open(path)
+	if openErr != nil {
+		return "", openErr
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, copyErr := io.Copy(hash, file); copyErr != nil {
+		return "", copyErr
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// packageClassFiles packages the class files under the directory into a jar, keeping their paths relative to the
+// directory as package paths. It returns nil when the directory has no class files.
+func packageClassFiles(dir string) (*CustomClassesFile, error) {
+	var classFiles []string
+	walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".class") {
+			classFiles = append(classFiles, path)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	if len(classFiles) == 0 {
+		return nil, nil
+	}
+	sort.Strings(classFiles)
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	manifest, manifestErr := writer.CreateHeader(&zip.FileHeader{Name: "META-INF/MANIFEST.MF", Method: zip.Deflate, Modified: packagedJarModTime})
+	if manifestErr != nil {
+		return nil, manifestErr
+	}
+	if _, writeErr := io.WriteString(manifest, "Manifest-Version: 1.0\r\nCreated-By: hzcloud\r\n\r\n"); writeErr != nil {
+		return nil, writeErr
+	}
+	for _, classFile := range classFiles {
+		relativePath, relativePathErr := filepath.Rel(dir, classFile)
+		if relativePathErr != nil {
+			return nil, relativePathErr
+		}
+		entry, entryErr := writer.CreateHeader(&zip.FileHeader{Name: filepath.ToSlash(relativePath), Method: zip.Deflate, Modified: packagedJarModTime})
+		if entryErr != nil {
+			return nil, entryErr
+		}
+		content, readErr := ioutil.ReadFile(classFile)
+		if readErr != nil {
+			return nil, readErr
+		}
+		if _, writeErr := entry.Write(content); writeErr != nil {
+			return nil, writeErr
+		}
+	}
+	if closeErr := writer.Close(); closeErr != nil {
+		return nil, closeErr
+	}
+	absoluteDir, absoluteDirErr := filepath.Abs(dir)
+	if absoluteDirErr != nil {
+		return nil, absoluteDirErr
+	}
+	hash := sha256.Sum256(buffer.Bytes())
+	return &CustomClassesFile{
+		Name:    filepath.Base(absoluteDir) + ".jar",
+		Size:    int64(buffer.Len()),
+		Sha256:  hex.EncodeToString(hash[:]),
+		Content: buffer.Bytes(),
+	}, nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+// recordingTransport records the Range header of the requests, "" for the ones downloading the whole content.
+type recordingTransport struct {
+	ranges []string
+}
+
+func (t *recordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.ranges = append(t.ranges, request.Header.Get("Range"))
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestCustomClassesServiceFindUnchanged(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	content := []byte("content of the jar")
+	server.AddArtifact(fakeapi.Artifact{Id: "1", ClusterId: clusterId, Name: "classes.jar", Content: []byte("longer content of the jar")})
+	server.AddArtifact(fakeapi.Artifact{Id: "2", ClusterId: clusterId, Name: "classes.jar", Content: []byte("CONTENT OF THE JAR")})
+	server.AddArtifact(fakeapi.Artifact{Id: "3", ClusterId: clusterId, Name: "empty.jar"})
+	server.AddArtifact(fakeapi.Artifact{Id: "4", ClusterId: clusterId, Name: "classes.jar", Content: content})
+	transport := &recordingTransport{}
+	service := NewCustomClassesService(client.EnterpriseCluster, clusterId)
+	service.HttpClient = &http.Client{Transport: transport}
+	uploadedArtifacts, listErr := service.List()
+	if listErr != nil {
+		t.Fatal(listErr)
+	}
+	hash := sha256.Sum256(content)
+	file := CustomClassesFile{Name: "classes.jar", Size: int64(len(content)), Sha256: hex.EncodeToString(hash[:])}
+
+	unchanged, err := service.FindUnchanged(file, uploadedArtifacts)
+	if err != nil {
+		t.Fatalf("FindUnchanged failed: %s", err)
+	}
+	if unchanged == nil || unchanged.Id != "4" {
+		t.Errorf("unchanged artifact is %+v", unchanged)
+	}
+	downloads := 0
+	for _, requestRange := range transport.ranges {
+		if requestRange == "" {
+			downloads++
+		} else if requestRange != "bytes=0-0" {
+			t.Errorf("range of a size request is %q", requestRange)
+		}
+	}
+	if len(transport.ranges) != 5 || downloads != 2 {
+		t.Errorf("requests have ranges %q, only the artifacts of the same size are expected to be downloaded", transport.ranges)
+	}
+
+	emptyHash := sha256.Sum256(nil)
+	empty, err := service.FindUnchanged(CustomClassesFile{Name: "empty.jar", Sha256: hex.EncodeToString(emptyHash[:])}, uploadedArtifacts)
+	if err != nil || empty == nil || empty.Id != "3" {
+		t.Errorf("FindUnchanged of an empty file returned %+v, %v", empty, err)
+	}
+}
+
+func TestCustomClassesServiceWaitForArtifacts(t *testing.T) {
+	pollInterval := artifactPollInterval
+	artifactPollInterval = time.Millisecond
+	defer func() { artifactPollInterval = pollInterval }()
+	server, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	server.FailingArtifactNames["broken.jar"] = true
+	readyId := server.AddArtifact(fakeapi.Artifact{ClusterId: clusterId, Name: "classes.jar", Status: "PENDING"})
+	failedId := server.AddArtifact(fakeapi.Artifact{ClusterId: clusterId, Name: "broken.jar", Status: "PENDING"})
+	service := NewCustomClassesService(client.EnterpriseCluster, clusterId)
+
+	statuses := map[string][]string{}
+	artifacts, err := service.WaitForArtifacts([]string{readyId, failedId}, time.Minute, func(artifact models.UploadedArtifact) {
+		statuses[artifact.Id] = append(statuses[artifact.Id], artifact.Status)
+	})
+	if err != nil {
+		t.Fatalf("WaitForArtifacts failed: %s", err)
+	}
+	if strings.Join(statuses[readyId], ",") != "PROCESSING,READY" || strings.Join(statuses[failedId], ",") != "PROCESSING,FAILED" {
+		t.Errorf("statuses are %v", statuses)
+	}
+	if artifacts[readyId].Status != ArtifactStatusReady || artifacts[failedId].Status != ArtifactStatusFailed {
+		t.Errorf("artifacts are %+v", artifacts)
+	}
+
+	server.TransitionSteps = 1000
+	pendingId := server.AddArtifact(fakeapi.Artifact{ClusterId: clusterId, Name: "slow.jar", Status: "PENDING"})
+	_, err = service.WaitForArtifacts([]string{readyId, pendingId}, 20*time.Millisecond, func(models.UploadedArtifact) {})
+	if err == nil || err.Error() != "timed out waiting for 1 artifacts to be processed" {
+		t.Errorf("WaitForArtifacts of a pending artifact returned %v", err)
+	}
+}
+
+func newCustomClassesTestFile(name string, content string) CustomClassesFile {
+	hash := sha256.Sum256([]byte(content))
+	return CustomClassesFile{Name: name, Size: int64(len(content)), Sha256: hex.EncodeToString(hash[:]), Content: []byte(content)}
+}
+
+func TestCustomClassesServicePlanSync(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	server.AddArtifact(fakeapi.Artifact{Id: "1", ClusterId: clusterId, Name: "same.jar", Content: []byte("same")})
+	server.AddArtifact(fakeapi.Artifact{Id: "2", ClusterId: clusterId, Name: "changed.jar", Content: []byte("old")})
+	server.AddArtifact(fakeapi.Artifact{Id: "3", ClusterId: clusterId, Name: "twice.jar", Content: []byte("twice")})
+	server.AddArtifact(fakeapi.Artifact{Id: "4", ClusterId: clusterId, Name: "twice.jar", Content: []byte("twice")})
+	server.AddArtifact(fakeapi.Artifact{Id: "5", ClusterId: clusterId, Name: "removed.jar", Content: []byte("removed")})
+	service := NewCustomClassesService(client.EnterpriseCluster, clusterId)
+	uploadedArtifacts, listErr := service.List()
+	if listErr != nil {
+		t.Fatal(listErr)
+	}
+	files := []CustomClassesFile{
+		newCustomClassesTestFile("new.jar", "new"),
+		newCustomClassesTestFile("same.jar", "same"),
+		newCustomClassesTestFile("changed.jar", "new"),
+		newCustomClassesTestFile("twice.jar", "twice"),
+	}
+
+	for _, prune := range []bool{false, true} {
+		actions, err := service.PlanSync(files, uploadedArtifacts, prune)
+		if err != nil {
+			t.Fatalf("PlanSync failed: %s", err)
+		}
+		var planned []string
+		for _, action := range actions {
+			planned = append(planned, fmt.Sprintf("%s %s %s %t", action.Action, action.Name,
+				strings.Join(action.ArtifactIds, ","), action.File != nil))
+		}
+		removedAction := "KEEP removed.jar 5 false"
+		if prune {
+			removedAction = "DELETE removed.jar 5 false"
+		}
+		expected := []string{
+			"UPLOAD new.jar  true",
+			"UNCHANGED same.jar 1 false",
+			"REPLACE changed.jar 2 true",
+			"REPLACE twice.jar 3,4 true",
+			removedAction,
+		}
+		if strings.Join(planned, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("actions with prune %t are\n%s", prune, strings.Join(planned, "\n"))
+		}
+	}
+}
+
+func TestCustomClassesServiceDownload(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	content := []byte("content of the jar")
+	hash := sha256.Sum256(content)
+	sha256Hash := hex.EncodeToString(hash[:])
+	artifactId := server.AddArtifact(fakeapi.Artifact{ClusterId: clusterId, Name: "classes.jar", Content: content})
+	transport := &recordingTransport{}
+	service := NewCustomClassesService(client.EnterpriseCluster, clusterId)
+	service.HttpClient = &http.Client{Transport: transport}
+	link, linkErr := service.Link(artifactId)
+	if linkErr != nil {
+		t.Fatal(linkErr)
+	}
+	progress := func(size int64, offset int64, description string) io.Writer {
+		if size != int64(len(content)) {
+			t.Errorf("size of %s is %d", description, size)
+		}
+		return ioutil.Discard
+	}
+	tests := []struct {
+		name           string
+		partContent    string
+		expectedSha256 string
+		requestRange   string
+		err            string
+	}{
+		{"download", "", sha256Hash, "", ""},
+		{"resume", "content ", sha256Hash, "bytes=8-", ""},
+		{"complete part file", string(content), sha256Hash, "bytes=18-", ""},
+		{"sha256 mismatch", "content ", strings.Repeat("0", 64), "bytes=8-", "SHA-256 of classes.jar is " + sha256Hash},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "classes.jar")
+			partPath := path + "." + artifactId + ".part"
+			if test.partContent != "" {
+				if err := ioutil.WriteFile(partPath, []byte(test.partContent), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			transport.ranges = nil
+
+			downloadedSha256, err := service.Download(link, path, test.expectedSha256, progress)
+			if len(transport.ranges) != 1 || transport.ranges[0] != test.requestRange {
+				t.Errorf("requests have ranges %q", transport.ranges)
+			}
+			if _, statErr := os.Stat(partPath); !os.IsNotExist(statErr) {
+				t.Errorf("part file is left behind. %v", statErr)
+			}
+			if test.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+					t.Errorf("Download returned %v", err)
+				}
+				if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+					t.Errorf("download with a SHA-256 mismatch is kept. %v", statErr)
+				}
+				return
+			}
+			if err != nil || downloadedSha256 != sha256Hash {
+				t.Fatalf("Download returned %s, %v", downloadedSha256, err)
+			}
+			if downloaded, readErr := ioutil.ReadFile(path); readErr != nil || string(downloaded) != string(content) {
+				t.Errorf("downloaded %q. %v", downloaded, readErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package fakecloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/profiles/2019-03-01/authorization/mgmt/authorization"
+	"github.com/Azure/azure-sdk-for-go/profiles/latest/graphrbac/graphrbac"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2020-06-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// AzureVnetPeerings is an in-memory implementation of service.AzureVnetPeeringClient. Peerings are keyed by
+// "<resource group>/<vnet name>/<peering name>". The same instance can hold both sides of a peering, which are
+// connected once both are created, as on Azure. AddressPrefixes holds the address space of the vnets by vnet id.
+type AzureVnetPeerings struct {
+	mutex           sync.Mutex
+	Peerings        map[string]network.VirtualNetworkPeering
+	AddressPrefixes map[string][]string
+	Errors          map[string]error
+	Calls           []string
+}
+
+func NewAzureVnetPeerings() *AzureVnetPeerings {
+	return &AzureVnetPeerings{
+		Peerings:        map[string]network.VirtualNetworkPeering{},
+		AddressPrefixes: map[string][]string{},
+		Errors:          map[string]error{},
+	}
+}
+
+func (f *AzureVnetPeerings) CreateOrUpdate(ctx context.Context, resourceGroupName string, vnetName string, peeringName string,
+	peering network.VirtualNetworkPeering) (network.VirtualNetworkPeering, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("CreateOrUpdate"); err != nil {
+		return network.VirtualNetworkPeering{}, err
+	}
+	properties := network.VirtualNetworkPeeringPropertiesFormat{}
+	if peering.VirtualNetworkPeeringPropertiesFormat != nil {
+		properties = *peering.VirtualNetworkPeeringPropertiesFormat
+	}
+	properties.PeeringState = network.VirtualNetworkPeeringStateInitiated
+	properties.ProvisioningState = "Succeeded"
+	var remoteVnetId string
+	if properties.RemoteVirtualNetwork != nil {
+		remoteVnetId = to.String(properties.RemoteVirtualNetwork.ID)
+	}
+	if addressPrefixes, ok := f.AddressPrefixes[remoteVnetId]; ok {
+		properties.RemoteAddressSpace = &network.AddressSpace{AddressPrefixes: &addressPrefixes}
+	}
+	created := network.VirtualNetworkPeering{
+		Name: to.StringPtr(peeringName),
+		ID: to.StringPtr(fmt.Sprintf("/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/%s/virtualNetworkPeerings/%s",
+			resourceGroupName, vnetName, peeringName)),
+		VirtualNetworkPeeringPropertiesFormat: &properties,
+	}
+	for _, remotePeering := range f.remotePeerings(remoteVnetId, resourceGroupName, vnetName) {
+		remotePeering.PeeringState = network.VirtualNetworkPeeringStateConnected
+		properties.PeeringState = network.VirtualNetworkPeeringStateConnected
+	}
+	f.Peerings[vnetPeeringKey(resourceGroupName, vnetName, peeringName)] = created
+	return created, nil
+}
+
+func (f *AzureVnetPeerings) Get(ctx context.Context, resourceGroupName string, vnetName string,
+	peeringName string) (network.VirtualNetworkPeering, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("Get"); err != nil {
+		return network.VirtualNetworkPeering{}, err
+	}
+	peering, ok := f.Peerings[vnetPeeringKey(resourceGroupName, vnetName, peeringName)]
+	if !ok {
+		return network.VirtualNetworkPeering{}, azureError(http.StatusNotFound, "peering %s not found", peeringName)
+	}
+	return peering, nil
+}
+
+func (f *AzureVnetPeerings) Delete(ctx context.Context, resourceGroupName string, vnetName string, peeringName string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("Delete"); err != nil {
+		return err
+	}
+	key := vnetPeeringKey(resourceGroupName, vnetName, peeringName)
+	deleted, ok := f.Peerings[key]
+	if !ok {
+		return nil
+	}
+	delete(f.Peerings, key)
+	if deleted.RemoteVirtualNetwork == nil {
+		return nil
+	}
+	for _, remotePeering := range f.remotePeerings(to.String(deleted.RemoteVirtualNetwork.ID), resourceGroupName, vnetName) {
+		remotePeering.PeeringState = network.VirtualNetworkPeeringStateDisconnected
+	}
+	return nil
+}
+
+func (f *AzureVnetPeerings) List(ctx context.Context, resourceGroupName string, vnetName string) ([]network.VirtualNetworkPeering, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("List"); err != nil {
+		return nil, err
+	}
+	var peerings []network.VirtualNetworkPeering
+	for key, peering := range f.Peerings {
+		if key == vnetPeeringKey(resourceGroupName, vnetName, *peering.Name) {
+			peerings = append(peerings, peering)
+		}
+	}
+	return peerings, nil
+}
+
+// remotePeerings returns the peerings of the remote vnet back to the vnet of the resource group.
+func (f *AzureVnetPeerings) remotePeerings(remoteVnetId string, resourceGroupName string, vnetName string) []network.VirtualNetworkPeering {
+	vnetIdSuffix := fmt.Sprintf("/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks/%s", resourceGroupName, vnetName)
+	var peerings []network.VirtualNetworkPeering
+	for key, peering := range f.Peerings {
+		if strings.HasPrefix(key, vnetKey(remoteVnetId)) && peering.RemoteVirtualNetwork != nil &&
+			strings.HasSuffix(to.String(peering.RemoteVirtualNetwork.ID), vnetIdSuffix) {
+			peerings = append(peerings, peering)
+		}
+	}
+	return peerings
+}
+
+func (f *AzureVnetPeerings) call(method string) error {
+	f.Calls = append(f.Calls, method)
+	return f.Errors[method]
+}
+
+// AzureServicePrincipals is an in-memory implementation of service.AzureServicePrincipalClient. Creating a service
+// principal of an app which already has one fails with a conflict, as on Azure.
+type AzureServicePrincipals struct {
+	mutex             sync.Mutex
+	nextId            int
+	ServicePrincipals []graphrbac.ServicePrincipal
+	Errors            map[string]error
+	Calls             []string
+}
+
+func NewAzureServicePrincipals() *AzureServicePrincipals {
+	return &AzureServicePrincipals{Errors: map[string]error{}}
+}
+
+func (f *AzureServicePrincipals) Create(ctx context.Context,
+	parameters graphrbac.ServicePrincipalCreateParameters) (graphrbac.ServicePrincipal, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("Create"); err != nil {
+		return graphrbac.ServicePrincipal{}, err
+	}
+	for _, servicePrincipal := range f.ServicePrincipals {
+		if to.String(servicePrincipal.AppID) == to.String(parameters.AppID) {
+			return graphrbac.ServicePrincipal{}, azureError(http.StatusConflict,
+				"service principal of app %s already exists", to.String(parameters.AppID))
+		}
+	}
+	f.nextId++
+	servicePrincipal := graphrbac.ServicePrincipal{
+		ObjectID: to.StringPtr(fmt.Sprintf("service-principal-%d", f.nextId)),
+		AppID:    parameters.AppID,
+	}
+	f.ServicePrincipals = append(f.ServicePrincipals, servicePrincipal)
+	return servicePrincipal, nil
+}
+
+// List supports only the "appId eq '<app id>'" filter used by service.AzurePeeringService.
+func (f *AzureServicePrincipals) List(ctx context.Context, filter string) ([]graphrbac.ServicePrincipal, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("List"); err != nil {
+		return nil, err
+	}
+	var servicePrincipals []graphrbac.ServicePrincipal
+	for _, servicePrincipal := range f.ServicePrincipals {
+		if filter == "" || filter == fmt.Sprintf("appId eq '%s'", to.String(servicePrincipal.AppID)) {
+			servicePrincipals = append(servicePrincipals, servicePrincipal)
+		}
+	}
+	return servicePrincipals, nil
+}
+
+func (f *AzureServicePrincipals) Delete(ctx context.Context, objectId string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("Delete"); err != nil {
+		return err
+	}
+	for i, servicePrincipal := range f.ServicePrincipals {
+		if to.String(servicePrincipal.ObjectID) == objectId {
+			f.ServicePrincipals = append(f.ServicePrincipals[:i], f.ServicePrincipals[i+1:]...)
+			return nil
+		}
+	}
+	return azureError(http.StatusNotFound, "service principal %s not found", objectId)
+}
+
+func (f *AzureServicePrincipals) call(method string) error {
+	f.Calls = append(f.Calls, method)
+	return f.Errors[method]
+}
+
+// AzureRoleAssignments is an in-memory implementation of service.AzureRoleAssignmentClient. Assigning the same role
+// to the same principal on the same scope twice fails with a conflict, as on Azure.
+type AzureRoleAssignments struct {
+	mutex           sync.Mutex
+	RoleAssignments []authorization.RoleAssignment
+	Errors          map[string]error
+	Calls           []string
+}
+
+func NewAzureRoleAssignments() *AzureRoleAssignments {
+	return &AzureRoleAssignments{Errors: map[string]error{}}
+}
+
+func (f *AzureRoleAssignments) Create(ctx context.Context, scope string, roleAssignmentName string,
+	parameters authorization.RoleAssignmentCreateParameters) (authorization.RoleAssignment, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "Create")
+	if err := f.Errors["Create"]; err != nil {
+		return authorization.RoleAssignment{}, err
+	}
+	for _, roleAssignment := range f.RoleAssignments {
+		if to.String(roleAssignment.Properties.Scope) == scope &&
+			to.String(roleAssignment.Properties.PrincipalID) == to.String(parameters.Properties.PrincipalID) &&
+			to.String(roleAssignment.Properties.RoleDefinitionID) == to.String(parameters.Properties.RoleDefinitionID) {
+			return authorization.RoleAssignment{}, azureError(http.StatusConflict, "the role assignment already exists")
+		}
+	}
+	roleAssignment := authorization.RoleAssignment{
+		ID:   to.StringPtr(fmt.Sprintf("%s/providers/Microsoft.Authorization/roleAssignments/%s", scope, roleAssignmentName)),
+		Name: to.StringPtr(roleAssignmentName),
+		Properties: &authorization.RoleAssignmentPropertiesWithScope{
+			Scope:            to.StringPtr(scope),
+			RoleDefinitionID: parameters.Properties.RoleDefinitionID,
+			PrincipalID:      parameters.Properties.PrincipalID,
+		},
+	}
+	f.RoleAssignments = append(f.RoleAssignments, roleAssignment)
+	return roleAssignment, nil
+}
+
+func (f *AzureRoleAssignments) Delete(ctx context.Context, roleAssignmentId string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "Delete")
+	if err := f.Errors["Delete"]; err != nil {
+		return err
+	}
+	for i, roleAssignment := range f.RoleAssignments {
+		if to.String(roleAssignment.ID) == roleAssignmentId {
+			f.RoleAssignments = append(f.RoleAssignments[:i], f.RoleAssignments[i+1:]...)
+			return nil
+		}
+	}
+	return azureError(http.StatusNotFound, "role assignment %s not found", roleAssignmentId)
+}
+
+// List supports only the "principalId eq '<principal id>'" filter used by service.AzurePeeringService. Like on
+// Azure, role assignments below the scope are listed too.
+func (f *AzureRoleAssignments) List(ctx context.Context, scope string, filter string) ([]authorization.RoleAssignment, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "List")
+	if err := f.Errors["List"]; err != nil {
+		return nil, err
+	}
+	var roleAssignments []authorization.RoleAssignment
+	for _, roleAssignment := range f.RoleAssignments {
+		if strings.HasPrefix(to.String(roleAssignment.Properties.Scope), scope) &&
+			(filter == "" || filter == fmt.Sprintf("principalId eq '%s'", to.String(roleAssignment.Properties.PrincipalID))) {
+			roleAssignments = append(roleAssignments, roleAssignment)
+		}
+	}
+	return roleAssignments, nil
+}
+
+func azureError(statusCode int, format string, args ...interface{}) error {
+	return autorest.DetailedError{
+		StatusCode: statusCode,
+		Message:    fmt.Sprintf(format, args...),
+	}
+}
+
+// vnetKey returns the key prefix of the peerings of a vnet from its id.
+func vnetKey(vnetId string) string {
+	parts := strings.Split(vnetId, "/")
+	var resourceGroupName, vnetName string
+	for i := 0; i+1 < len(parts); i++ {
+		switch parts[i] {
+		case "resourceGroups":
+			resourceGroupName = parts[i+1]
+		case "virtualNetworks":
+			vnetName = parts[i+1]
+		}
+	}
+	return resourceGroupName + "/" + vnetName + "/"
+}
+
+func vnetPeeringKey(resourceGroupName string, vnetName string, peeringName string) string {
+	return resourceGroupName + "/" + vnetName + "/" + peeringName
+}
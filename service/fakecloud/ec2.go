@@ -0,0 +1,512 @@
+package fakecloud
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Ec2 is an in-memory implementation of service.Ec2Client. Errors can be injected per method name,
+// e.g. Errors["CreateRoute"], and every call is recorded in Calls.
+type Ec2 struct {
+	mutex              sync.Mutex
+	nextId             int
+	Vpcs               map[string]*ec2.Vpc
+	Subnets            map[string]*ec2.Subnet
+	RouteTables        map[string]*ec2.RouteTable
+	PeeringConnections map[string]*ec2.VpcPeeringConnection
+	SecurityGroups     map[string]*ec2.SecurityGroup
+	Errors             map[string]error
+	Calls              []string
+}
+
+func NewEc2() *Ec2 {
+	return &Ec2{
+		Vpcs:               map[string]*ec2.Vpc{},
+		Subnets:            map[string]*ec2.Subnet{},
+		RouteTables:        map[string]*ec2.RouteTable{},
+		PeeringConnections: map[string]*ec2.VpcPeeringConnection{},
+		SecurityGroups:     map[string]*ec2.SecurityGroup{},
+		Errors:             map[string]error{},
+	}
+}
+
+// AddVpc adds a vpc together with its main route table and returns the route table id.
+func (f *Ec2) AddVpc(vpcId string, cidrBlock string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Vpcs[vpcId] = &ec2.Vpc{VpcId: aws.String(vpcId), CidrBlock: aws.String(cidrBlock)}
+	routeTableId := f.generateId("rtb")
+	f.RouteTables[routeTableId] = &ec2.RouteTable{
+		RouteTableId: aws.String(routeTableId),
+		VpcId:        aws.String(vpcId),
+		Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(true), RouteTableId: aws.String(routeTableId)}},
+	}
+	return routeTableId
+}
+
+// AddSubnet adds a subnet to a vpc. When routeTableId is empty, the subnet uses the main route table of the vpc,
+// otherwise it is explicitly associated to the given route table, which is created if needed.
+func (f *Ec2) AddSubnet(subnetId string, vpcId string, cidrBlock string, routeTableId string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Subnets[subnetId] = &ec2.Subnet{SubnetId: aws.String(subnetId), VpcId: aws.String(vpcId), CidrBlock: aws.String(cidrBlock)}
+	if routeTableId == "" {
+		return
+	}
+	table, ok := f.RouteTables[routeTableId]
+	if !ok {
+		table = &ec2.RouteTable{RouteTableId: aws.String(routeTableId), VpcId: aws.String(vpcId)}
+		f.RouteTables[routeTableId] = table
+	}
+	table.Associations = append(table.Associations, &ec2.RouteTableAssociation{
+		Main:         aws.Bool(false),
+		SubnetId:     aws.String(subnetId),
+		RouteTableId: aws.String(routeTableId),
+	})
+}
+
+// AddRoute adds an existing route to a route table, e.g. to simulate a conflicting route.
+func (f *Ec2) AddRoute(routeTableId string, route *ec2.Route) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table := f.RouteTables[routeTableId]
+	table.Routes = append(table.Routes, route)
+}
+
+func (f *Ec2) AddSecurityGroup(groupId string, vpcId string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.SecurityGroups[groupId] = &ec2.SecurityGroup{GroupId: aws.String(groupId), VpcId: aws.String(vpcId)}
+}
+
+// Route returns the route of the route table for the destination, or nil.
+func (f *Ec2) Route(routeTableId string, destinationCidrBlock string) *ec2.Route {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, ok := f.RouteTables[routeTableId]
+	if !ok {
+		return nil
+	}
+	for _, route := range table.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == destinationCidrBlock {
+			return route
+		}
+	}
+	return nil
+}
+
+// AcceptVpcPeeringConnection simulates the accepter side of a vpc peering connection.
+func (f *Ec2) AcceptVpcPeeringConnection(peeringConnectionId string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.PeeringConnections[peeringConnectionId].Status = &ec2.VpcPeeringConnectionStateReason{Code: aws.String("active")}
+}
+
+// CallCount returns how many times the method was called.
+func (f *Ec2) CallCount(method string) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	count := 0
+	for _, call := range f.Calls {
+		if call == method {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *Ec2) CreateVpcPeeringConnection(input *ec2.CreateVpcPeeringConnectionInput) (*ec2.CreateVpcPeeringConnectionOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("CreateVpcPeeringConnection"); err != nil {
+		return nil, err
+	}
+	vpc, ok := f.Vpcs[aws.StringValue(input.VpcId)]
+	if !ok {
+		return nil, awserr.New("InvalidVpcID.NotFound", fmt.Sprintf("The vpc ID '%s' does not exist", aws.StringValue(input.VpcId)), nil)
+	}
+	peeringConnectionId := f.generateId("pcx")
+	peering := &ec2.VpcPeeringConnection{
+		VpcPeeringConnectionId: aws.String(peeringConnectionId),
+		Status:                 &ec2.VpcPeeringConnectionStateReason{Code: aws.String("pending-acceptance")},
+		RequesterVpcInfo: &ec2.VpcPeeringConnectionVpcInfo{
+			VpcId:     vpc.VpcId,
+			CidrBlock: vpc.CidrBlock,
+		},
+		AccepterVpcInfo: &ec2.VpcPeeringConnectionVpcInfo{
+			VpcId:   input.PeerVpcId,
+			OwnerId: input.PeerOwnerId,
+			Region:  input.PeerRegion,
+		},
+	}
+	f.PeeringConnections[peeringConnectionId] = peering
+	return &ec2.CreateVpcPeeringConnectionOutput{VpcPeeringConnection: peering}, nil
+}
+
+func (f *Ec2) DeleteVpcPeeringConnection(input *ec2.DeleteVpcPeeringConnectionInput) (*ec2.DeleteVpcPeeringConnectionOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("DeleteVpcPeeringConnection"); err != nil {
+		return nil, err
+	}
+	peering, ok := f.PeeringConnections[aws.StringValue(input.VpcPeeringConnectionId)]
+	if !ok || aws.StringValue(peering.Status.Code) == "deleted" {
+		return nil, awserr.New("InvalidVpcPeeringConnectionID.NotFound", fmt.Sprintf("The vpcPeeringConnection ID '%s' does not exist",
+			aws.StringValue(input.VpcPeeringConnectionId)), nil)
+	}
+	peering.Status = &ec2.VpcPeeringConnectionStateReason{Code: aws.String("deleted")}
+	return &ec2.DeleteVpcPeeringConnectionOutput{Return: aws.Bool(true)}, nil
+}
+
+func (f *Ec2) DescribeVpcPeeringConnections(input *ec2.DescribeVpcPeeringConnectionsInput) (*ec2.DescribeVpcPeeringConnectionsOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("DescribeVpcPeeringConnections"); err != nil {
+		return nil, err
+	}
+	output := &ec2.DescribeVpcPeeringConnectionsOutput{}
+	for _, peering := range f.PeeringConnections {
+		if (len(input.VpcPeeringConnectionIds) == 0 ||
+			contains(aws.StringValueSlice(input.VpcPeeringConnectionIds), aws.StringValue(peering.VpcPeeringConnectionId))) &&
+			matchesPeeringConnectionFilters(peering, input.Filters) {
+			output.VpcPeeringConnections = append(output.VpcPeeringConnections, peering)
+		}
+	}
+	return output, nil
+}
+
+func (f *Ec2) DescribeRouteTables(input *ec2.DescribeRouteTablesInput) (*ec2.DescribeRouteTablesOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("DescribeRouteTables"); err != nil {
+		return nil, err
+	}
+	output := &ec2.DescribeRouteTablesOutput{}
+	for _, table := range f.RouteTables {
+		if (len(input.RouteTableIds) == 0 || contains(aws.StringValueSlice(input.RouteTableIds), aws.StringValue(table.RouteTableId))) &&
+			matchesRouteTableFilters(table, input.Filters) {
+			output.RouteTables = append(output.RouteTables, table)
+		}
+	}
+	return output, nil
+}
+
+func (f *Ec2) DeleteRoute(input *ec2.DeleteRouteInput) (*ec2.DeleteRouteOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("DeleteRoute"); err != nil {
+		return nil, err
+	}
+	table, ok := f.RouteTables[aws.StringValue(input.RouteTableId)]
+	if !ok {
+		return nil, routeTableNotFound(aws.StringValue(input.RouteTableId))
+	}
+	for i, route := range table.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == aws.StringValue(input.DestinationCidrBlock) {
+			table.Routes = append(table.Routes[:i], table.Routes[i+1:]...)
+			return &ec2.DeleteRouteOutput{}, nil
+		}
+	}
+	return nil, awserr.New("InvalidRoute.NotFound", fmt.Sprintf("no route with destination-cidr-block %s in route table %s",
+		aws.StringValue(input.DestinationCidrBlock), aws.StringValue(input.RouteTableId)), nil)
+}
+
+func (f *Ec2) CreateRoute(input *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("CreateRoute"); err != nil {
+		return nil, err
+	}
+	table, ok := f.RouteTables[aws.StringValue(input.RouteTableId)]
+	if !ok {
+		return nil, routeTableNotFound(aws.StringValue(input.RouteTableId))
+	}
+	for _, route := range table.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == aws.StringValue(input.DestinationCidrBlock) {
+			return nil, awserr.New("RouteAlreadyExists", fmt.Sprintf("The route identified by %s already exists.",
+				aws.StringValue(input.DestinationCidrBlock)), nil)
+		}
+	}
+	table.Routes = append(table.Routes, &ec2.Route{
+		DestinationCidrBlock:   input.DestinationCidrBlock,
+		VpcPeeringConnectionId: input.VpcPeeringConnectionId,
+		GatewayId:              input.GatewayId,
+		InstanceId:             input.InstanceId,
+		NatGatewayId:           input.NatGatewayId,
+		TransitGatewayId:       input.TransitGatewayId,
+		NetworkInterfaceId:     input.NetworkInterfaceId,
+		State:                  aws.String("active"),
+		Origin:                 aws.String("CreateRoute"),
+	})
+	return &ec2.CreateRouteOutput{Return: aws.Bool(true)}, nil
+}
+
+func (f *Ec2) ReplaceRoute(input *ec2.ReplaceRouteInput) (*ec2.ReplaceRouteOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("ReplaceRoute"); err != nil {
+		return nil, err
+	}
+	table, ok := f.RouteTables[aws.StringValue(input.RouteTableId)]
+	if !ok {
+		return nil, routeTableNotFound(aws.StringValue(input.RouteTableId))
+	}
+	for i, route := range table.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == aws.StringValue(input.DestinationCidrBlock) {
+			table.Routes[i] = &ec2.Route{
+				DestinationCidrBlock:   input.DestinationCidrBlock,
+				VpcPeeringConnectionId: input.VpcPeeringConnectionId,
+				GatewayId:              input.GatewayId,
+				InstanceId:             input.InstanceId,
+				NatGatewayId:           input.NatGatewayId,
+				TransitGatewayId:       input.TransitGatewayId,
+				NetworkInterfaceId:     input.NetworkInterfaceId,
+				State:                  aws.String("active"),
+				Origin:                 aws.String("CreateRoute"),
+			}
+			return &ec2.ReplaceRouteOutput{}, nil
+		}
+	}
+	return nil, awserr.New("InvalidRoute.NotFound", fmt.Sprintf("no route with destination-cidr-block %s in route table %s",
+		aws.StringValue(input.DestinationCidrBlock), aws.StringValue(input.RouteTableId)), nil)
+}
+
+func (f *Ec2) AuthorizeSecurityGroupIngress(input *ec2.AuthorizeSecurityGroupIngressInput) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("AuthorizeSecurityGroupIngress"); err != nil {
+		return nil, err
+	}
+	group, err := f.securityGroup(input.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	group.IpPermissions, err = authorize(group.IpPermissions, input.IpPermissions)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.AuthorizeSecurityGroupIngressOutput{Return: aws.Bool(true)}, nil
+}
+
+func (f *Ec2) AuthorizeSecurityGroupEgress(input *ec2.AuthorizeSecurityGroupEgressInput) (*ec2.AuthorizeSecurityGroupEgressOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("AuthorizeSecurityGroupEgress"); err != nil {
+		return nil, err
+	}
+	group, err := f.securityGroup(input.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	group.IpPermissionsEgress, err = authorize(group.IpPermissionsEgress, input.IpPermissions)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.AuthorizeSecurityGroupEgressOutput{Return: aws.Bool(true)}, nil
+}
+
+func (f *Ec2) RevokeSecurityGroupIngress(input *ec2.RevokeSecurityGroupIngressInput) (*ec2.RevokeSecurityGroupIngressOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("RevokeSecurityGroupIngress"); err != nil {
+		return nil, err
+	}
+	group, err := f.securityGroup(input.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	group.IpPermissions, err = revoke(group.IpPermissions, input.IpPermissions)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.RevokeSecurityGroupIngressOutput{Return: aws.Bool(true)}, nil
+}
+
+func (f *Ec2) RevokeSecurityGroupEgress(input *ec2.RevokeSecurityGroupEgressInput) (*ec2.RevokeSecurityGroupEgressOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("RevokeSecurityGroupEgress"); err != nil {
+		return nil, err
+	}
+	group, err := f.securityGroup(input.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	group.IpPermissionsEgress, err = revoke(group.IpPermissionsEgress, input.IpPermissions)
+	if err != nil {
+		return nil, err
+	}
+	return &ec2.RevokeSecurityGroupEgressOutput{Return: aws.Bool(true)}, nil
+}
+
+func (f *Ec2) DescribeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("DescribeSecurityGroups"); err != nil {
+		return nil, err
+	}
+	output := &ec2.DescribeSecurityGroupsOutput{}
+	for _, groupId := range input.GroupIds {
+		group, err := f.securityGroup(groupId)
+		if err != nil {
+			return nil, err
+		}
+		output.SecurityGroups = append(output.SecurityGroups, group)
+	}
+	return output, nil
+}
+
+func (f *Ec2) DescribeVpcs(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("DescribeVpcs"); err != nil {
+		return nil, err
+	}
+	output := &ec2.DescribeVpcsOutput{}
+	for _, vpcId := range aws.StringValueSlice(input.VpcIds) {
+		vpc, ok := f.Vpcs[vpcId]
+		if !ok {
+			return nil, awserr.New("InvalidVpcID.NotFound", fmt.Sprintf("The vpc ID '%s' does not exist", vpcId), nil)
+		}
+		output.Vpcs = append(output.Vpcs, vpc)
+	}
+	return output, nil
+}
+
+func (f *Ec2) DescribeSubnets(input *ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := f.call("DescribeSubnets"); err != nil {
+		return nil, err
+	}
+	output := &ec2.DescribeSubnetsOutput{}
+	for _, subnetId := range aws.StringValueSlice(input.SubnetIds) {
+		subnet, ok := f.Subnets[subnetId]
+		if !ok {
+			return nil, awserr.New("InvalidSubnetID.NotFound", fmt.Sprintf("The subnet ID '%s' does not exist", subnetId), nil)
+		}
+		output.Subnets = append(output.Subnets, subnet)
+	}
+	return output, nil
+}
+
+func (f *Ec2) call(method string) error {
+	f.Calls = append(f.Calls, method)
+	return f.Errors[method]
+}
+
+func (f *Ec2) generateId(prefix string) string {
+	f.nextId++
+	return fmt.Sprintf("%s-%08d", prefix, f.nextId)
+}
+
+func (f *Ec2) securityGroup(groupId *string) (*ec2.SecurityGroup, error) {
+	group, ok := f.SecurityGroups[aws.StringValue(groupId)]
+	if !ok {
+		return nil, awserr.New("InvalidGroup.NotFound", fmt.Sprintf("The security group '%s' does not exist", aws.StringValue(groupId)), nil)
+	}
+	return group, nil
+}
+
+func authorize(permissions []*ec2.IpPermission, added []*ec2.IpPermission) ([]*ec2.IpPermission, error) {
+	for _, permission := range added {
+		if findPermission(permissions, permission) != -1 {
+			return permissions, awserr.New("InvalidPermission.Duplicate", "the specified rule already exists", nil)
+		}
+	}
+	return append(permissions, added...), nil
+}
+
+func revoke(permissions []*ec2.IpPermission, revoked []*ec2.IpPermission) ([]*ec2.IpPermission, error) {
+	for _, permission := range revoked {
+		i := findPermission(permissions, permission)
+		if i == -1 {
+			return permissions, awserr.New("InvalidPermission.NotFound", "the specified rule does not exist in this security group", nil)
+		}
+		permissions = append(permissions[:i], permissions[i+1:]...)
+	}
+	return permissions, nil
+}
+
+func findPermission(permissions []*ec2.IpPermission, permission *ec2.IpPermission) int {
+	for i, p := range permissions {
+		if aws.StringValue(p.IpProtocol) == aws.StringValue(permission.IpProtocol) &&
+			aws.Int64Value(p.FromPort) == aws.Int64Value(permission.FromPort) &&
+			aws.Int64Value(p.ToPort) == aws.Int64Value(permission.ToPort) &&
+			ipRangeCidrs(p) == ipRangeCidrs(permission) {
+			return i
+		}
+	}
+	return -1
+}
+
+func ipRangeCidrs(permission *ec2.IpPermission) string {
+	var cidrs string
+	for _, ipRange := range permission.IpRanges {
+		cidrs += aws.StringValue(ipRange.CidrIp) + ","
+	}
+	return cidrs
+}
+
+func matchesRouteTableFilters(table *ec2.RouteTable, filters []*ec2.Filter) bool {
+	for _, filter := range filters {
+		values := aws.StringValueSlice(filter.Values)
+		matches := false
+		switch aws.StringValue(filter.Name) {
+		case "vpc-id":
+			matches = contains(values, aws.StringValue(table.VpcId))
+		case "route-table-id":
+			matches = contains(values, aws.StringValue(table.RouteTableId))
+		case "association.subnet-id":
+			for _, association := range table.Associations {
+				matches = matches || contains(values, aws.StringValue(association.SubnetId))
+			}
+		case "association.main":
+			for _, association := range table.Associations {
+				matches = matches || contains(values, fmt.Sprintf("%t", aws.BoolValue(association.Main)))
+			}
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesPeeringConnectionFilters(peering *ec2.VpcPeeringConnection, filters []*ec2.Filter) bool {
+	for _, filter := range filters {
+		values := aws.StringValueSlice(filter.Values)
+		matches := false
+		switch aws.StringValue(filter.Name) {
+		case "requester-vpc-info.vpc-id":
+			matches = contains(values, aws.StringValue(peering.RequesterVpcInfo.VpcId))
+		case "accepter-vpc-info.vpc-id":
+			matches = contains(values, aws.StringValue(peering.AccepterVpcInfo.VpcId))
+		case "status-code":
+			matches = contains(values, aws.StringValue(peering.Status.Code))
+		case "vpc-peering-connection-id":
+			matches = contains(values, aws.StringValue(peering.VpcPeeringConnectionId))
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+func routeTableNotFound(routeTableId string) error {
+	return awserr.New("InvalidRouteTableID.NotFound", fmt.Sprintf("The routeTable ID '%s' does not exist", routeTableId), nil)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+package fakecloud
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// GcpNetworks is an in-memory implementation of service.GcpNetworksClient. Networks are keyed by
+// "<project id>/<network name>". XpnHosts maps service projects to their Shared VPC host project and Permissions
+// holds the permissions of the caller per project.
+type GcpNetworks struct {
+	mutex       sync.Mutex
+	nextId      int
+	Networks    map[string]*compute.Network
+	Subnetworks map[string][]*compute.Subnetwork
+	Operations  map[string]*compute.Operation
+	XpnHosts    map[string]string
+	Permissions map[string][]string
+	Errors      map[string]error
+	Calls       []string
+}
+
+func NewGcpNetworks() *GcpNetworks {
+	return &GcpNetworks{
+		Networks:    map[string]*compute.Network{},
+		Subnetworks: map[string][]*compute.Subnetwork{},
+		Operations:  map[string]*compute.Operation{},
+		XpnHosts:    map[string]string{},
+		Permissions: map[string][]string{},
+		Errors:      map[string]error{},
+	}
+}
+
+func (f *GcpNetworks) AddNetwork(projectId string, networkName string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Networks[networkKey(projectId, networkName)] = &compute.Network{
+		Name:     networkName,
+		SelfLink: fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/global/networks/%s", projectId, networkName),
+	}
+}
+
+// AddSubnetwork adds a subnetwork of the network, the network must be added first.
+func (f *GcpNetworks) AddSubnetwork(projectId string, networkName string, subnetworkName string, cidr string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Subnetworks[projectId] = append(f.Subnetworks[projectId], &compute.Subnetwork{
+		Name:        subnetworkName,
+		Network:     f.Networks[networkKey(projectId, networkName)].SelfLink,
+		IpCidrRange: cidr,
+	})
+}
+
+// Peering returns the named peering of the network, or nil.
+func (f *GcpNetworks) Peering(projectId string, networkName string, peeringName string) *compute.NetworkPeering {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	network, ok := f.Networks[networkKey(projectId, networkName)]
+	if !ok {
+		return nil
+	}
+	for _, peering := range network.Peerings {
+		if peering.Name == peeringName {
+			return peering
+		}
+	}
+	return nil
+}
+
+func (f *GcpNetworks) AddPeering(projectId string, networkName string, request *compute.NetworksAddPeeringRequest) (*compute.Operation, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "AddPeering")
+	if err := f.Errors["AddPeering"]; err != nil {
+		return nil, err
+	}
+	network, ok := f.Networks[networkKey(projectId, networkName)]
+	if !ok {
+		return nil, networkNotFound(projectId, networkName)
+	}
+	for _, peering := range network.Peerings {
+		if peering.Name == request.Name {
+			return nil, &googleapi.Error{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("There is already a peering %s in network %s", request.Name, networkName),
+			}
+		}
+	}
+	peering := &compute.NetworkPeering{
+		Name:             request.Name,
+		Network:          request.PeerNetwork,
+		State:            "ACTIVE",
+		AutoCreateRoutes: request.AutoCreateRoutes,
+	}
+	if request.NetworkPeering != nil {
+		peering = request.NetworkPeering
+		peering.State = "ACTIVE"
+	}
+	network.Peerings = append(network.Peerings, peering)
+	return f.newOperation(), nil
+}
+
+func (f *GcpNetworks) RemovePeering(projectId string, networkName string, request *compute.NetworksRemovePeeringRequest) (*compute.Operation, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "RemovePeering")
+	if err := f.Errors["RemovePeering"]; err != nil {
+		return nil, err
+	}
+	network, ok := f.Networks[networkKey(projectId, networkName)]
+	if !ok {
+		return nil, networkNotFound(projectId, networkName)
+	}
+	for i, peering := range network.Peerings {
+		if peering.Name == request.Name {
+			network.Peerings = append(network.Peerings[:i], network.Peerings[i+1:]...)
+			return f.newOperation(), nil
+		}
+	}
+	return nil, &googleapi.Error{
+		Code:    http.StatusBadRequest,
+		Message: fmt.Sprintf("There is no peering %s in network %s", request.Name, networkName),
+	}
+}
+
+// WaitOperation completes the operation. An error set in Errors["Operation"] is reported as the error of the operation.
+func (f *GcpNetworks) WaitOperation(projectId string, operationName string) (*compute.Operation, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "WaitOperation")
+	if err := f.Errors["WaitOperation"]; err != nil {
+		return nil, err
+	}
+	operation, ok := f.Operations[operationName]
+	if !ok {
+		return nil, &googleapi.Error{Code: http.StatusNotFound, Message: fmt.Sprintf("operation %s not found", operationName)}
+	}
+	operation.Status = "DONE"
+	operation.Progress = 100
+	if err := f.Errors["Operation"]; err != nil {
+		operation.Error = &compute.OperationError{
+			Errors: []*compute.OperationErrorErrors{{Code: "OPERATION_FAILED", Message: err.Error()}},
+		}
+	}
+	return operation, nil
+}
+
+func (f *GcpNetworks) newOperation() *compute.Operation {
+	f.nextId++
+	operation := &compute.Operation{Name: fmt.Sprintf("operation-%d", f.nextId), Status: "RUNNING"}
+	f.Operations[operation.Name] = operation
+	return operation
+}
+
+func (f *GcpNetworks) Get(projectId string, networkName string) (*compute.Network, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "Get")
+	if err := f.Errors["Get"]; err != nil {
+		return nil, err
+	}
+	network, ok := f.Networks[networkKey(projectId, networkName)]
+	if !ok {
+		return nil, networkNotFound(projectId, networkName)
+	}
+	return network, nil
+}
+
+func (f *GcpNetworks) ListSubnetworks(projectId string) ([]*compute.Subnetwork, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "ListSubnetworks")
+	if err := f.Errors["ListSubnetworks"]; err != nil {
+		return nil, err
+	}
+	return f.Subnetworks[projectId], nil
+}
+
+func (f *GcpNetworks) GetXpnHost(projectId string) (*compute.Project, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "GetXpnHost")
+	if err := f.Errors["GetXpnHost"]; err != nil {
+		return nil, err
+	}
+	return &compute.Project{Name: f.XpnHosts[projectId]}, nil
+}
+
+func (f *GcpNetworks) TestPermissions(projectId string, permissions []string) ([]string, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.Calls = append(f.Calls, "TestPermissions")
+	if err := f.Errors["TestPermissions"]; err != nil {
+		return nil, err
+	}
+	var granted []string
+	for _, permission := range permissions {
+		for _, projectPermission := range f.Permissions[projectId] {
+			if permission == projectPermission {
+				granted = append(granted, permission)
+			}
+		}
+	}
+	return granted, nil
+}
+
+func networkNotFound(projectId string, networkName string) error {
+	return &googleapi.Error{
+		Code:    http.StatusNotFound,
+		Message: fmt.Sprintf("The resource 'projects/%s/global/networks/%s' was not found", projectId, networkName),
+	}
+}
+
+func networkKey(projectId string, networkName string) string {
+	return projectId + "/" + networkName
+}
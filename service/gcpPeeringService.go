@@ -3,21 +3,98 @@ package service
 import (
 	"context"
 	"fmt"
-	"github.com/hazelcast/hazelcast-cloud-cli/internal"
+	"strings"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
 	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+	"google.golang.org/api/cloudresourcemanager/v1"
 	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
 )
 
 type GcpPeeringService struct {
 	Client             *hazelcastcloud.Client
 	CustomerProperties *GcpCustomerPeeringProperties
+	Networks           GcpNetworksClient
+	// CredentialsFile and ImpersonateServiceAccount select the credentials used for your project, application default
+	// credentials are used when empty.
+	CredentialsFile           string
+	ImpersonateServiceAccount string
 }
 
 type GcpCustomerPeeringProperties struct {
-	ClusterId   string
-	ProjectId   string
-	NetworkName string
+	ClusterId string
+	ProjectId string
+	// HostProjectId is the Shared VPC host project owning the network, ProjectId must then be one of its service
+	// projects. The network is in ProjectId when empty.
+	HostProjectId      string
+	NetworkName        string
+	ExportCustomRoutes bool
+	ImportCustomRoutes bool
+}
+
+const gcpAddPeeringPermission = "compute.networks.addPeering"
+
+// GcpNetworksClient is the subset of the Compute Engine networks API used by GcpPeeringService.
+type GcpNetworksClient interface {
+	AddPeering(projectId string, networkName string, request *compute.NetworksAddPeeringRequest) (*compute.Operation, error)
+	RemovePeering(projectId string, networkName string, request *compute.NetworksRemovePeeringRequest) (*compute.Operation, error)
+	Get(projectId string, networkName string) (*compute.Network, error)
+	ListSubnetworks(projectId string) ([]*compute.Subnetwork, error)
+	// WaitOperation waits for a global operation to complete, it may return before it completes.
+	WaitOperation(projectId string, operationName string) (*compute.Operation, error)
+	// GetXpnHost returns the Shared VPC host project of a service project, its name is empty when there is none.
+	GetXpnHost(projectId string) (*compute.Project, error)
+	// TestPermissions returns the subset of permissions the caller has on the project.
+	TestPermissions(projectId string, permissions []string) ([]string, error)
+}
+
+type computeNetworksClient struct {
+	service  *compute.Service
+	projects *cloudresourcemanager.Service
+}
+
+func (c computeNetworksClient) AddPeering(projectId string, networkName string, request *compute.NetworksAddPeeringRequest) (*compute.Operation, error) {
+	return c.service.Networks.AddPeering(projectId, networkName, request).Do()
+}
+
+func (c computeNetworksClient) RemovePeering(projectId string, networkName string,
+	request *compute.NetworksRemovePeeringRequest) (*compute.Operation, error) {
+	return c.service.Networks.RemovePeering(projectId, networkName, request).Do()
+}
+
+func (c computeNetworksClient) WaitOperation(projectId string, operationName string) (*compute.Operation, error) {
+	return c.service.GlobalOperations.Wait(projectId, operationName).Do()
+}
+
+func (c computeNetworksClient) Get(projectId string, networkName string) (*compute.Network, error) {
+	return c.service.Networks.Get(projectId, networkName).Do()
+}
+
+func (c computeNetworksClient) ListSubnetworks(projectId string) ([]*compute.Subnetwork, error) {
+	var subnetworks []*compute.Subnetwork
+	err := c.service.Subnetworks.AggregatedList(projectId).Pages(context.Background(), func(list *compute.SubnetworkAggregatedList) error {
+		for _, scopedList := range list.Items {
+			subnetworks = append(subnetworks, scopedList.Subnetworks...)
+		}
+		return nil
+	})
+	return subnetworks, err
+}
+
+func (c computeNetworksClient) GetXpnHost(projectId string) (*compute.Project, error) {
+	return c.service.Projects.GetXpnHost(projectId).Do()
+}
+
+func (c computeNetworksClient) TestPermissions(projectId string, permissions []string) ([]string, error) {
+	response, err := c.projects.Projects.TestIamPermissions(projectId, &cloudresourcemanager.TestIamPermissionsRequest{
+		Permissions: permissions,
+	}).Do()
+	if err != nil {
+		return nil, err
+	}
+	return response.Permissions, nil
 }
 
 func NewGcpPeeringService(client *hazelcastcloud.Client) GcpPeeringService {
@@ -26,30 +103,324 @@ func NewGcpPeeringService(client *hazelcastcloud.Client) GcpPeeringService {
 	}
 }
 
-func (s GcpPeeringService) Create(customerProperties *GcpCustomerPeeringProperties) error {
-	hazelcastProperties := internal.Validate(s.Client.GcpPeering.GetProperties(context.Background(), &models.GetGcpPeeringPropertiesInput{
+func NewGcpPeeringServiceWithNetworksClient(client *hazelcastcloud.Client, networks GcpNetworksClient) GcpPeeringService {
+	return GcpPeeringService{
+		Client:   client,
+		Networks: networks,
+	}
+}
+
+// Create adds the network peering to the Hazelcast network to your network, reusing the one left by a previous attempt,
+// then accepts the peering on the Hazelcast cluster. A network peering it added is removed again when accepting fails.
+func (s GcpPeeringService) Create(customerProperties *GcpCustomerPeeringProperties, indicator *util.LoadingIndicator) error {
+	indicator.SetStep("Peering Properties collecting...", 10)
+	hazelcastProperties, _, hazelcastPropertiesErr := s.Client.GcpPeering.GetProperties(context.Background(), &models.GetGcpPeeringPropertiesInput{
 		ClusterId: customerProperties.ClusterId,
-	})).(*models.GcpPeeringProperties)
+	})
+	if hazelcastPropertiesErr != nil {
+		return hazelcastPropertiesErr
+	}
 
-	computeService, computeServiceErr := compute.NewService(context.Background())
-	if computeServiceErr != nil {
-		return fmt.Errorf("you need to have GOOGLE_APPLICATION_CREDENTIALS environment variable set in order to perform this action. For more information https://docs.cloud.hazelcast.com/docs/gcp-vpc-peering . GCP Error:%s", computeServiceErr)
+	indicator.SetStep("Clients initializing...", 20)
+	networks, networksErr := s.getNetworksClient()
+	if networksErr != nil {
+		return networksErr
 	}
 
-	_, addPeeringErr := computeService.Networks.AddPeering(customerProperties.ProjectId, customerProperties.NetworkName, &compute.NetworksAddPeeringRequest{
-		Name:             fmt.Sprintf("%s-%s", hazelcastProperties.ProjectId, hazelcastProperties.NetworkName),
-		PeerNetwork:      fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/global/networks/%s", hazelcastProperties.ProjectId, hazelcastProperties.NetworkName),
-		AutoCreateRoutes: true,
-	}).Do()
-	if addPeeringErr != nil {
-		return addPeeringErr
+	networkProjectId, networkProjectErr := getGcpNetworkProjectId(networks, customerProperties, indicator)
+	if networkProjectErr != nil {
+		return networkProjectErr
+	}
+
+	peeringName := getGcpPeeringName(hazelcastProperties)
+	network, networkErr := networks.Get(networkProjectId, customerProperties.NetworkName)
+	if networkErr != nil {
+		return networkErr
+	}
+	peeringAdded := false
+	if findGcpNetworkPeering(network, peeringName) == nil {
+		indicator.SetStep("Network peering creating...", 30)
+		operation, addPeeringErr := networks.AddPeering(networkProjectId, customerProperties.NetworkName, &compute.NetworksAddPeeringRequest{
+			NetworkPeering: &compute.NetworkPeering{
+				Name:                 peeringName,
+				Network:              fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/global/networks/%s", hazelcastProperties.ProjectId, hazelcastProperties.NetworkName),
+				ExchangeSubnetRoutes: true,
+				ExportCustomRoutes:   customerProperties.ExportCustomRoutes,
+				ImportCustomRoutes:   customerProperties.ImportCustomRoutes,
+			},
+		})
+		if addPeeringErr != nil {
+			return addPeeringErr
+		}
+		waitErr := waitForGcpOperation(networks, networkProjectId, operation, indicator)
+		if waitErr != nil {
+			return waitErr
+		}
+		peeringAdded = true
+	}
+
+	indicator.SetStep("Peering accepting...", 90)
+	acceptErr := s.accept(customerProperties.ClusterId, networkProjectId, customerProperties.NetworkName)
+	if acceptErr != nil && peeringAdded {
+		return removeGcpNetworkPeering(networks, networkProjectId, customerProperties.NetworkName, peeringName, acceptErr, indicator)
+	}
+	if acceptErr != nil {
+		return acceptErr
+	}
+
+	return nil
+}
+
+// Accept accepts the peering on the Hazelcast cluster once the network peering to the Hazelcast network is added to
+// your network outside of the CLI, e.g. from the output of Emit. Only the Hazelcast side is changed, your credentials
+// are not used. The network is in HostProjectId when set, in ProjectId otherwise.
+func (s GcpPeeringService) Accept(customerProperties *GcpCustomerPeeringProperties, indicator *util.LoadingIndicator) error {
+	networkProjectId := customerProperties.ProjectId
+	if customerProperties.HostProjectId != "" {
+		networkProjectId = customerProperties.HostProjectId
+	}
+	indicator.SetStep("Peering accepting...", 50)
+	return s.accept(customerProperties.ClusterId, networkProjectId, customerProperties.NetworkName)
+}
+
+func (s GcpPeeringService) accept(clusterId string, projectId string, networkName string) error {
+	_, _, acceptErr := s.Client.GcpPeering.Accept(context.Background(), &models.AcceptGcpPeeringInput{
+		ClusterId:   clusterId,
+		ProjectId:   projectId,
+		NetworkName: networkName,
+	})
+	return acceptErr
+}
+
+// findGcpNetworkPeering returns the named network peering of the network, or nil.
+func findGcpNetworkPeering(network *compute.Network, peeringName string) *compute.NetworkPeering {
+	for _, networkPeering := range network.Peerings {
+		if networkPeering.Name == peeringName {
+			return networkPeering
+		}
+	}
+	return nil
+}
+
+// removeGcpNetworkPeering removes the network peering added by a failed Create so that it is not left behind.
+func removeGcpNetworkPeering(networks GcpNetworksClient, projectId string, networkName string, peeringName string, cause error,
+	indicator *util.LoadingIndicator) error {
+	indicator.SetStep("Network peering removing...", 95)
+	operation, removePeeringErr := networks.RemovePeering(projectId, networkName, &compute.NetworksRemovePeeringRequest{
+		Name: peeringName,
+	})
+	if removePeeringErr == nil {
+		removePeeringErr = waitForGcpOperation(networks, projectId, operation, indicator)
+	}
+	if removePeeringErr != nil {
+		return fmt.Errorf("%s. Network peering %s of %s is left behind: %s", cause, peeringName, networkName, removePeeringErr)
+	}
+	return fmt.Errorf("%s. Network peering %s of %s is removed", cause, peeringName, networkName)
+}
+
+// getGcpNetworkProjectId returns the project owning the network. With a Shared VPC host project, it checks that the
+// project is one of its service projects and that the caller is allowed to add peerings to the host project.
+func getGcpNetworkProjectId(networks GcpNetworksClient, customerProperties *GcpCustomerPeeringProperties, indicator *util.LoadingIndicator) (string, error) {
+	if customerProperties.HostProjectId == "" || customerProperties.HostProjectId == customerProperties.ProjectId {
+		return customerProperties.ProjectId, nil
+	}
+	indicator.SetStep("Shared VPC validating...", 25)
+	hostProject, hostProjectErr := networks.GetXpnHost(customerProperties.ProjectId)
+	if hostProjectErr != nil {
+		return "", hostProjectErr
+	}
+	if hostProject.Name != customerProperties.HostProjectId {
+		return "", fmt.Errorf("project %s is not a service project of the Shared VPC host project %s",
+			customerProperties.ProjectId, customerProperties.HostProjectId)
+	}
+	permissions, permissionsErr := networks.TestPermissions(customerProperties.HostProjectId, []string{gcpAddPeeringPermission})
+	if permissionsErr != nil {
+		return "", permissionsErr
+	}
+	for _, permission := range permissions {
+		if permission == gcpAddPeeringPermission {
+			return customerProperties.HostProjectId, nil
+		}
+	}
+	return "", fmt.Errorf("you need to have %s permission on the Shared VPC host project %s in order to perform this action",
+		gcpAddPeeringPermission, customerProperties.HostProjectId)
+}
+
+// Delete removes the network peering to the Hazelcast network from your network, then deletes the peering from the
+// Hazelcast cluster. It returns the name of the removed network peering, which is empty when there was none.
+func (s GcpPeeringService) Delete(clusterId string, peeringId string, indicator *util.LoadingIndicator) (string, error) {
+	indicator.SetStep("Peering Properties collecting...", 10)
+	hazelcastProperties, _, hazelcastPropertiesErr := s.Client.GcpPeering.GetProperties(context.Background(), &models.GetGcpPeeringPropertiesInput{
+		ClusterId: clusterId,
+	})
+	if hazelcastPropertiesErr != nil {
+		return "", hazelcastPropertiesErr
+	}
+	peering, peeringErr := s.getPeering(clusterId, peeringId)
+	if peeringErr != nil {
+		return "", peeringErr
+	}
+
+	indicator.SetStep("Clients initializing...", 20)
+	networks, networksErr := s.getNetworksClient()
+	if networksErr != nil {
+		return "", networksErr
+	}
+	network, networkErr := networks.Get(peering.ProjectId, peering.NetworkName)
+	if networkErr != nil {
+		return "", networkErr
+	}
+
+	var removedPeeringName string
+	hazelcastNetwork := fmt.Sprintf("projects/%s/global/networks/%s", hazelcastProperties.ProjectId, hazelcastProperties.NetworkName)
+	for _, networkPeering := range network.Peerings {
+		if !strings.HasSuffix(networkPeering.Network, hazelcastNetwork) {
+			continue
+		}
+		indicator.SetStep("Network peering removing...", 30)
+		operation, removePeeringErr := networks.RemovePeering(peering.ProjectId, peering.NetworkName, &compute.NetworksRemovePeeringRequest{
+			Name: networkPeering.Name,
+		})
+		if removePeeringErr != nil {
+			return "", removePeeringErr
+		}
+		waitErr := waitForGcpOperation(networks, peering.ProjectId, operation, indicator)
+		if waitErr != nil {
+			return "", waitErr
+		}
+		removedPeeringName = networkPeering.Name
 	}
 
-	_ = internal.Validate(s.Client.GcpPeering.Accept(context.Background(), &models.AcceptGcpPeeringInput{
-		ClusterId:   customerProperties.ClusterId,
-		ProjectId:   customerProperties.ProjectId,
-		NetworkName: customerProperties.NetworkName,
-	}))
+	indicator.SetStep("Hazelcast peering deleting...", 90)
+	_, _, deleteErr := s.Client.GcpPeering.Delete(context.Background(), &models.DeleteGcpPeeringInput{
+		Id: peeringId,
+	})
+	if deleteErr != nil {
+		return "", deleteErr
+	}
+	return removedPeeringName, nil
+}
+
+func (s GcpPeeringService) getPeering(clusterId string, peeringId string) (*models.GcpPeering, error) {
+	peerings, _, peeringsErr := s.Client.GcpPeering.List(context.Background(), &models.ListGcpPeeringsInput{
+		ClusterId: clusterId,
+	})
+	if peeringsErr != nil {
+		return nil, peeringsErr
+	}
+	for _, peering := range *peerings {
+		if peering.Id == peeringId {
+			return &peering, nil
+		}
+	}
+	return nil, fmt.Errorf("peering %s not found on cluster %s", peeringId, clusterId)
+}
 
+func waitForGcpOperation(networks GcpNetworksClient, projectId string, operation *compute.Operation, indicator *util.LoadingIndicator) error {
+	for operation.Status != "DONE" {
+		indicator.SetStep(fmt.Sprintf("Operation %s waiting...", operation.Name), 30+int(operation.Progress)/2)
+		waitedOperation, waitErr := networks.WaitOperation(projectId, operation.Name)
+		if waitErr != nil {
+			return waitErr
+		}
+		operation = waitedOperation
+	}
+	if operation.Error != nil && len(operation.Error.Errors) != 0 {
+		var messages []string
+		for _, operationError := range operation.Error.Errors {
+			messages = append(messages, fmt.Sprintf("%s: %s", operationError.Code, operationError.Message))
+		}
+		return fmt.Errorf("operation %s failed. %s", operation.Name, strings.Join(messages, ", "))
+	}
 	return nil
 }
+
+func getGcpPeeringName(hazelcastProperties *models.GcpPeeringProperties) string {
+	return fmt.Sprintf("%s-%s", hazelcastProperties.ProjectId, hazelcastProperties.NetworkName)
+}
+
+// Diagnose compares the peerings of the cluster with the network peerings and subnetworks of your networks.
+func (s GcpPeeringService) Diagnose(clusterId string) ([]PeeringCheck, error) {
+	hazelcastProperties, _, hazelcastPropertiesErr := s.Client.GcpPeering.GetProperties(context.Background(), &models.GetGcpPeeringPropertiesInput{
+		ClusterId: clusterId,
+	})
+	if hazelcastPropertiesErr != nil {
+		return nil, hazelcastPropertiesErr
+	}
+	cluster, clusterErr := getCluster(s.Client, clusterId)
+	if clusterErr != nil {
+		return nil, clusterErr
+	}
+	peerings, _, peeringsErr := s.Client.GcpPeering.List(context.Background(), &models.ListGcpPeeringsInput{
+		ClusterId: clusterId,
+	})
+	if peeringsErr != nil {
+		return nil, peeringsErr
+	}
+	networks, networksErr := s.getNetworksClient()
+	if networksErr != nil {
+		return nil, networksErr
+	}
+
+	checks := []PeeringCheck{newHazelcastPeeringCheck(len(*peerings), "hzcloud gcp-peering create")}
+	hazelcastNetwork := fmt.Sprintf("projects/%s/global/networks/%s", hazelcastProperties.ProjectId, hazelcastProperties.NetworkName)
+	for _, peering := range *peerings {
+		name := fmt.Sprintf("Network peering of %s", peering.NetworkName)
+		network, networkErr := networks.Get(peering.ProjectId, peering.NetworkName)
+		if networkErr != nil {
+			checks = append(checks, failedCheck(name, networkErr.Error(),
+				"Check that the network exists and that your credentials are allowed to read it."))
+			continue
+		}
+		checks = append(checks, newGcpNetworkPeeringCheck(name, network, hazelcastNetwork))
+
+		subnetworks, subnetworksErr := networks.ListSubnetworks(peering.ProjectId)
+		if subnetworksErr != nil {
+			return nil, subnetworksErr
+		}
+		for _, subnetwork := range subnetworks {
+			if subnetwork.Network == network.SelfLink {
+				checks = append(checks, newCidrOverlapCheck(fmt.Sprintf("Cidr of %s", subnetwork.Name), subnetwork.IpCidrRange,
+					cluster.Networking.CidrBlock))
+			}
+		}
+	}
+	return checks, nil
+}
+
+func newGcpNetworkPeeringCheck(name string, network *compute.Network, hazelcastNetwork string) PeeringCheck {
+	for _, networkPeering := range network.Peerings {
+		if !strings.HasSuffix(networkPeering.Network, hazelcastNetwork) {
+			continue
+		}
+		if networkPeering.State == "ACTIVE" {
+			return passedCheck(name, fmt.Sprintf("%s is active", networkPeering.Name))
+		}
+		return failedCheck(name, fmt.Sprintf("%s is %s: %s", networkPeering.Name, networkPeering.State, networkPeering.StateDetails),
+			"The network peering becomes active when both sides are created, create the peering again with `hzcloud gcp-peering create`.")
+	}
+	return failedCheck(name, fmt.Sprintf("%s has no network peering to %s", network.Name, hazelcastNetwork),
+		"Create the peering again with `hzcloud gcp-peering create`.")
+}
+
+func (s GcpPeeringService) getNetworksClient() (GcpNetworksClient, error) {
+	if s.Networks != nil {
+		return s.Networks, nil
+	}
+	var options []option.ClientOption
+	if s.CredentialsFile != "" {
+		options = append(options, option.WithCredentialsFile(s.CredentialsFile))
+	}
+	if s.ImpersonateServiceAccount != "" {
+		options = append(options, option.ImpersonateCredentials(s.ImpersonateServiceAccount))
+	}
+	computeService, computeServiceErr := compute.NewService(context.Background(), options...)
+	if computeServiceErr != nil {
+		return nil, fmt.Errorf("you need to have GOOGLE_APPLICATION_CREDENTIALS environment variable set or use --credentials-file in order to perform this action. For more information https://docs.cloud.hazelcast.com/docs/gcp-vpc-peering . GCP Error:%s", computeServiceErr)
+	}
+	projectsService, projectsServiceErr := cloudresourcemanager.NewService(context.Background(), options...)
+	if projectsServiceErr != nil {
+		return nil, projectsServiceErr
+	}
+	return computeNetworksClient{service: computeService, projects: projectsService}, nil
+}
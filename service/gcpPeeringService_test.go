@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/internal/fakeapi"
+	"github.com/hazelcast/hazelcast-cloud-cli/service/fakecloud"
+	"github.com/hazelcast/hazelcast-cloud-cli/util"
+)
+
+func newGcpPeeringTestNetworks() *fakecloud.GcpNetworks {
+	networks := fakecloud.NewGcpNetworks()
+	networks.AddNetwork("my-project", "my-network")
+	networks.AddSubnetwork("my-project", "my-network", "my-subnetwork", "10.0.1.0/24")
+	return networks
+}
+
+func newGcpCustomerTestProperties(clusterId string) *GcpCustomerPeeringProperties {
+	return &GcpCustomerPeeringProperties{
+		ClusterId:   clusterId,
+		ProjectId:   "my-project",
+		NetworkName: "my-network",
+	}
+}
+
+func TestGcpPeeringServiceCreate(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "gcp", "europe-west1")
+	networks := newGcpPeeringTestNetworks()
+	service := NewGcpPeeringServiceWithNetworksClient(client, networks)
+
+	if err := service.Create(newGcpCustomerTestProperties(clusterId), util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+
+	networkPeering := networks.Peering("my-project", "my-network", "hazelcast-cloud-hazelcast-network-"+clusterId)
+	if networkPeering == nil || networkPeering.State != "ACTIVE" ||
+		!strings.HasSuffix(networkPeering.Network, "projects/hazelcast-cloud/global/networks/hazelcast-network-"+clusterId) {
+		t.Errorf("network peering is %+v", networkPeering)
+	}
+	peerings := server.GcpPeerings(clusterId)
+	if len(peerings) != 1 || peerings[0].ProjectId != "my-project" || peerings[0].NetworkName != "my-network" {
+		t.Errorf("Hazelcast peerings are %+v", peerings)
+	}
+}
+
+func TestGcpPeeringServiceCreateRemovesNetworkPeeringOnFailure(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "gcp", "europe-west1")
+	networks := newGcpPeeringTestNetworks()
+	server.Handle("acceptGcpVpcPeering", func(args fakeapi.Args) (interface{}, error) {
+		return nil, fmt.Errorf("peering is not accepted")
+	})
+	service := NewGcpPeeringServiceWithNetworksClient(client, networks)
+
+	err := service.Create(newGcpCustomerTestProperties(clusterId), util.NewLoadingIndicator("", 100))
+	if err == nil || !strings.Contains(err.Error(), "peering is not accepted") || !strings.HasSuffix(err.Error(), "is removed") {
+		t.Fatalf("Create returned %v", err)
+	}
+	if networkPeering := networks.Peering("my-project", "my-network", "hazelcast-cloud-hazelcast-network-"+clusterId); networkPeering != nil {
+		t.Errorf("network peering %+v is left behind", networkPeering)
+	}
+}
+
+func TestGcpPeeringServiceCreateAgain(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "gcp", "europe-west1")
+	networks := newGcpPeeringTestNetworks()
+	accept := server.Handler("acceptGcpVpcPeering")
+	server.Handle("acceptGcpVpcPeering", func(args fakeapi.Args) (interface{}, error) {
+		return nil, fmt.Errorf("peering is not accepted")
+	})
+	networks.Errors["RemovePeering"] = errors.New("permission denied")
+	service := NewGcpPeeringServiceWithNetworksClient(client, networks)
+
+	err := service.Create(newGcpCustomerTestProperties(clusterId), util.NewLoadingIndicator("", 100))
+	if err == nil || !strings.Contains(err.Error(), "is left behind: permission denied") {
+		t.Fatalf("Create returned %v", err)
+	}
+	if networks.Peering("my-project", "my-network", "hazelcast-cloud-hazelcast-network-"+clusterId) == nil {
+		t.Fatal("network peering is not left behind")
+	}
+
+	server.Handle("acceptGcpVpcPeering", accept)
+	if err := service.Create(newGcpCustomerTestProperties(clusterId), util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	addPeeringCalls := 0
+	for _, call := range networks.Calls {
+		if call == "AddPeering" {
+			addPeeringCalls++
+		}
+	}
+	if addPeeringCalls != 1 {
+		t.Errorf("AddPeering is called %d times, the network peering left behind is not reused", addPeeringCalls)
+	}
+	if peerings := server.GcpPeerings(clusterId); len(peerings) != 1 {
+		t.Errorf("Hazelcast peerings are %+v", peerings)
+	}
+}
+
+func TestGcpPeeringServiceCreateFailedOperation(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "gcp", "europe-west1")
+	networks := newGcpPeeringTestNetworks()
+	networks.Errors["Operation"] = errors.New("peering limit exceeded")
+	service := NewGcpPeeringServiceWithNetworksClient(client, networks)
+
+	err := service.Create(newGcpCustomerTestProperties(clusterId), util.NewLoadingIndicator("", 100))
+	if err == nil || !strings.Contains(err.Error(), "OPERATION_FAILED: peering limit exceeded") {
+		t.Fatalf("Create returned %v", err)
+	}
+	if peerings := server.GcpPeerings(clusterId); len(peerings) != 0 {
+		t.Errorf("Hazelcast peerings %+v are accepted", peerings)
+	}
+}
+
+func TestGcpPeeringServiceAccept(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "gcp", "europe-west1")
+	networks := newGcpPeeringTestNetworks()
+	service := NewGcpPeeringServiceWithNetworksClient(client, networks)
+	customerProperties := newGcpCustomerTestProperties(clusterId)
+	customerProperties.ProjectId = "my-service-project"
+	customerProperties.HostProjectId = "my-project"
+
+	if err := service.Accept(customerProperties, util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Accept failed: %s", err)
+	}
+	if len(networks.Calls) != 0 {
+		t.Errorf("networks are called: %v", networks.Calls)
+	}
+	peerings := server.GcpPeerings(clusterId)
+	if len(peerings) != 1 || peerings[0].ProjectId != "my-project" || peerings[0].NetworkName != "my-network" {
+		t.Errorf("Hazelcast peerings are %+v", peerings)
+	}
+}
+
+func TestGcpPeeringServiceDelete(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "gcp", "europe-west1")
+	networks := newGcpPeeringTestNetworks()
+	service := NewGcpPeeringServiceWithNetworksClient(client, networks)
+	if err := service.Create(newGcpCustomerTestProperties(clusterId), util.NewLoadingIndicator("", 100)); err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	peeringId := server.GcpPeerings(clusterId)[0].Id
+
+	removedPeeringName, err := service.Delete(clusterId, peeringId, util.NewLoadingIndicator("", 100))
+	if err != nil {
+		t.Fatalf("Delete failed: %s", err)
+	}
+	if removedPeeringName != "hazelcast-cloud-hazelcast-network-"+clusterId {
+		t.Errorf("removed network peering is %q", removedPeeringName)
+	}
+	if networks.Peering("my-project", "my-network", removedPeeringName) != nil {
+		t.Error("network peering is left behind")
+	}
+	if peerings := server.GcpPeerings(clusterId); len(peerings) != 0 {
+		t.Errorf("Hazelcast peerings %+v are left behind", peerings)
+	}
+}
+
+func TestGcpPeeringServiceDeleteWithoutNetworkPeering(t *testing.T) {
+	server, client, clusterId := newPeeringTestClient(t, "gcp", "europe-west1")
+	networks := newGcpPeeringTestNetworks()
+	peeringId := server.AddGcpPeering(fakeapi.GcpPeering{ClusterId: clusterId, ProjectId: "my-project", NetworkName: "my-network"})
+	service := NewGcpPeeringServiceWithNetworksClient(client, networks)
+
+	removedPeeringName, err := service.Delete(clusterId, peeringId, util.NewLoadingIndicator("", 100))
+	if err != nil || removedPeeringName != "" {
+		t.Fatalf("Delete returned %q, %v", removedPeeringName, err)
+	}
+	if peerings := server.GcpPeerings(clusterId); len(peerings) != 0 {
+		t.Errorf("Hazelcast peerings %+v are left behind", peerings)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	hazelcastcloud "github.com/hazelcast/hazelcast-cloud-sdk-go"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+// PeeringCheck is a single item of the checklist produced by the Diagnose methods of the peering services.
+type PeeringCheck struct {
+	Name        string
+	IsPassed    bool
+	Details     string
+	Remediation string
+}
+
+func passedCheck(name string, details string) PeeringCheck {
+	return PeeringCheck{
+		Name:     name,
+		IsPassed: true,
+		Details:  details,
+	}
+}
+
+func failedCheck(name string, details string, remediation string) PeeringCheck {
+	return PeeringCheck{
+		Name:        name,
+		Details:     details,
+		Remediation: remediation,
+	}
+}
+
+func newHazelcastPeeringCheck(peeringCount int, createCommand string) PeeringCheck {
+	if peeringCount == 0 {
+		return failedCheck("Hazelcast peering", "cluster has no peering",
+			fmt.Sprintf("Create the peering with `%s`.", createCommand))
+	}
+	return passedCheck("Hazelcast peering", fmt.Sprintf("cluster has %d peering(s)", peeringCount))
+}
+
+func newCidrOverlapCheck(name string, cidr string, clusterCidr string) PeeringCheck {
+	overlaps, overlapsErr := cidrsOverlap(cidr, clusterCidr)
+	if overlapsErr != nil {
+		return failedCheck(name, overlapsErr.Error(), "Check the cidr of your network.")
+	}
+	if overlaps {
+		return failedCheck(name, fmt.Sprintf("%s overlaps with cluster cidr %s", cidr, clusterCidr),
+			"Peer a network whose cidr does not overlap with the cluster cidr, overlapping networks cannot be routed.")
+	}
+	return passedCheck(name, fmt.Sprintf("%s does not overlap with cluster cidr %s", cidr, clusterCidr))
+}
+
+func cidrsOverlap(first string, second string) (bool, error) {
+	_, firstNetwork, firstErr := net.ParseCIDR(first)
+	if firstErr != nil {
+		return false, firstErr
+	}
+	_, secondNetwork, secondErr := net.ParseCIDR(second)
+	if secondErr != nil {
+		return false, secondErr
+	}
+	return firstNetwork.Contains(secondNetwork.IP) || secondNetwork.Contains(firstNetwork.IP), nil
+}
+
+func cidrContains(cidr string, other string) bool {
+	_, network, networkErr := net.ParseCIDR(cidr)
+	if networkErr != nil {
+		return false
+	}
+	_, otherNetwork, otherErr := net.ParseCIDR(other)
+	if otherErr != nil {
+		return false
+	}
+	networkSize, _ := network.Mask.Size()
+	otherSize, _ := otherNetwork.Mask.Size()
+	return network.Contains(otherNetwork.IP) && networkSize <= otherSize
+}
+
+func getCluster(client *hazelcastcloud.Client, clusterId string) (*models.Cluster, error) {
+	cluster, _, clusterErr := client.EnterpriseCluster.Get(context.Background(), &models.GetEnterpriseClusterInput{
+		ClusterId: clusterId,
+	})
+	if clusterErr != nil {
+		return nil, clusterErr
+	}
+	return cluster, nil
+}
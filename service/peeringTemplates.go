@@ -0,0 +1,381 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+)
+
+const (
+	EmitTerraform      = "terraform"
+	EmitCloudFormation = "cloudformation"
+	EmitBicep          = "bicep"
+	EmitGcloudScript   = "gcloud-script"
+)
+
+var EmitFormats = []string{EmitTerraform, EmitCloudFormation, EmitBicep, EmitGcloudScript}
+
+const azureNetworkContributorRoleId = "4d97b98b-1d4f-4787-a291-c67834d212e7"
+
+type awsPeeringTemplateData struct {
+	Customer                *AwsCustomerPeeringProperties
+	Hazelcast               *models.AwsPeeringProperties
+	Port                    int
+	IngressSecurityGroupIds []string
+	EgressSecurityGroupIds  []string
+}
+
+type gcpPeeringTemplateData struct {
+	Customer         *GcpCustomerPeeringProperties
+	Hazelcast        *models.GcpPeeringProperties
+	NetworkProjectId string
+	PeeringName      string
+}
+
+type azurePeeringTemplateData struct {
+	Customer        *AzureCustomerPeeringProperties
+	Hazelcast       *models.AzurePeeringProperties
+	CustomerVnetId  string
+	HazelcastVnetId string
+	RoleId          string
+}
+
+var peeringTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+var awsTerraformTemplate = `# Customer side of the AWS peering of Hazelcast Cloud cluster {{.Customer.ClusterId}}.
+# Once applied, complete the peering with:
+#   hzcloud aws-peering accept --cluster-id={{.Customer.ClusterId}} --vpc-id={{.Customer.VpcId}} --subnet-ids={{join .Customer.SubnetIds ","}}{{if .Customer.Region}} --region={{.Customer.Region}}{{end}}
+
+terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = ">= 4.0"
+    }
+  }
+}
+
+resource "aws_vpc_peering_connection" "hazelcast" {
+  vpc_id        = "{{.Customer.VpcId}}"
+  peer_owner_id = "{{.Hazelcast.OwnerId}}"
+  peer_vpc_id   = "{{.Hazelcast.VpcId}}"
+  peer_region   = "{{.Hazelcast.Region}}"
+
+  tags = {
+    Name = "hazelcast-cloud-{{.Customer.ClusterId}}"
+  }
+}
+
+data "aws_route_tables" "hazelcast_subnet" {
+  for_each = toset([{{range $i, $subnetId := .Customer.SubnetIds}}{{if $i}}, {{end}}"{{$subnetId}}"{{end}}])
+  vpc_id   = "{{.Customer.VpcId}}"
+
+  filter {
+    name   = "association.subnet-id"
+    values = [each.value]
+  }
+}
+
+# Subnets without a route table association of their own use the main route table of the vpc.
+data "aws_route_table" "hazelcast_main" {
+  vpc_id = "{{.Customer.VpcId}}"
+
+  filter {
+    name   = "association.main"
+    values = ["true"]
+  }
+}
+
+locals {
+  hazelcast_route_table_ids = toset([for tables in data.aws_route_tables.hazelcast_subnet :
+    length(tables.ids) > 0 ? tables.ids[0] : data.aws_route_table.hazelcast_main.id])
+}
+
+resource "aws_route" "hazelcast" {
+  for_each                  = local.hazelcast_route_table_ids
+  route_table_id            = each.value
+  destination_cidr_block    = "{{.Hazelcast.VpcCidr}}"
+  vpc_peering_connection_id = aws_vpc_peering_connection.hazelcast.id
+}
+{{range $i, $groupId := .IngressSecurityGroupIds}}
+resource "aws_security_group_rule" "hazelcast_ingress_{{$i}}" {
+  type              = "ingress"
+  security_group_id = "{{$groupId}}"
+  protocol          = "tcp"
+  from_port         = {{$.Port}}
+  to_port           = {{$.Port}}
+  cidr_blocks       = ["{{$.Hazelcast.VpcCidr}}"]
+  description       = "Hazelcast Cloud cluster {{$.Customer.ClusterId}}"
+}
+{{end}}{{range $i, $groupId := .EgressSecurityGroupIds}}
+resource "aws_security_group_rule" "hazelcast_egress_{{$i}}" {
+  type              = "egress"
+  security_group_id = "{{$groupId}}"
+  protocol          = "tcp"
+  from_port         = {{$.Port}}
+  to_port           = {{$.Port}}
+  cidr_blocks       = ["{{$.Hazelcast.VpcCidr}}"]
+  description       = "Hazelcast Cloud cluster {{$.Customer.ClusterId}}"
+}
+{{end}}
+output "peering_connection_id" {
+  value = aws_vpc_peering_connection.hazelcast.id
+}
+`
+
+var awsCloudFormationTemplate = `AWSTemplateFormatVersion: "2010-09-09"
+# Once deployed, complete the peering with:
+#   hzcloud aws-peering accept --cluster-id={{.Customer.ClusterId}} --vpc-id={{.Customer.VpcId}} --subnet-ids={{join .Customer.SubnetIds ","}}{{if .Customer.Region}} --region={{.Customer.Region}}{{end}}
+Description: Customer side of the AWS peering of Hazelcast Cloud cluster {{.Customer.ClusterId}}.
+Parameters:{{range $i, $subnetId := .Customer.SubnetIds}}
+  RouteTable{{$i}}:
+    Type: String
+    Default: ""
+    Description: Route table of subnet {{$subnetId}}, leave it empty when an earlier subnet has the same route table.{{end}}
+Conditions:{{range $i, $subnetId := .Customer.SubnetIds}}
+  HasRouteTable{{$i}}: !Not [!Equals [!Ref RouteTable{{$i}}, ""]]{{end}}
+Resources:
+  HazelcastPeeringConnection:
+    Type: AWS::EC2::VPCPeeringConnection
+    Properties:
+      VpcId: {{.Customer.VpcId}}
+      PeerOwnerId: "{{.Hazelcast.OwnerId}}"
+      PeerVpcId: {{.Hazelcast.VpcId}}
+      PeerRegion: {{.Hazelcast.Region}}
+      Tags:
+        - Key: Name
+          Value: hazelcast-cloud-{{.Customer.ClusterId}}{{range $i, $subnetId := .Customer.SubnetIds}}
+  HazelcastRoute{{$i}}:
+    Type: AWS::EC2::Route
+    Condition: HasRouteTable{{$i}}
+    Properties:
+      RouteTableId: !Ref RouteTable{{$i}}
+      DestinationCidrBlock: {{$.Hazelcast.VpcCidr}}
+      VpcPeeringConnectionId: !Ref HazelcastPeeringConnection{{end}}{{range $i, $groupId := .IngressSecurityGroupIds}}
+  HazelcastIngress{{$i}}:
+    Type: AWS::EC2::SecurityGroupIngress
+    Properties:
+      GroupId: {{$groupId}}
+      IpProtocol: tcp
+      FromPort: {{$.Port}}
+      ToPort: {{$.Port}}
+      CidrIp: {{$.Hazelcast.VpcCidr}}
+      Description: Hazelcast Cloud cluster {{$.Customer.ClusterId}}{{end}}{{range $i, $groupId := .EgressSecurityGroupIds}}
+  HazelcastEgress{{$i}}:
+    Type: AWS::EC2::SecurityGroupEgress
+    Properties:
+      GroupId: {{$groupId}}
+      IpProtocol: tcp
+      FromPort: {{$.Port}}
+      ToPort: {{$.Port}}
+      CidrIp: {{$.Hazelcast.VpcCidr}}
+      Description: Hazelcast Cloud cluster {{$.Customer.ClusterId}}{{end}}
+Outputs:
+  PeeringConnectionId:
+    Value: !Ref HazelcastPeeringConnection
+`
+
+var gcpTerraformTemplate = `# Customer side of the GCP peering of Hazelcast Cloud cluster {{.Customer.ClusterId}}.
+# Once applied, complete the peering with:
+#   hzcloud gcp-peering accept --cluster-id={{.Customer.ClusterId}} --project-id={{.Customer.ProjectId}}{{if .Customer.HostProjectId}} --host-project-id={{.Customer.HostProjectId}}{{end}} --network-name={{.Customer.NetworkName}}
+
+resource "google_compute_network_peering" "hazelcast" {
+  name                 = "{{.PeeringName}}"
+  network              = "projects/{{.NetworkProjectId}}/global/networks/{{.Customer.NetworkName}}"
+  peer_network         = "projects/{{.Hazelcast.ProjectId}}/global/networks/{{.Hazelcast.NetworkName}}"
+  export_custom_routes = {{.Customer.ExportCustomRoutes}}
+  import_custom_routes = {{.Customer.ImportCustomRoutes}}
+}
+`
+
+var gcpScriptTemplate = `#!/bin/sh
+# Customer side of the GCP peering of Hazelcast Cloud cluster {{.Customer.ClusterId}}.
+# Once run, complete the peering with:
+#   hzcloud gcp-peering accept --cluster-id={{.Customer.ClusterId}} --project-id={{.Customer.ProjectId}}{{if .Customer.HostProjectId}} --host-project-id={{.Customer.HostProjectId}}{{end}} --network-name={{.Customer.NetworkName}}
+set -e
+
+gcloud compute networks peerings create {{.PeeringName}} \
+  --project={{.NetworkProjectId}} \
+  --network={{.Customer.NetworkName}} \
+  --peer-project={{.Hazelcast.ProjectId}} \
+  --peer-network={{.Hazelcast.NetworkName}}{{if .Customer.ExportCustomRoutes}} \
+  --export-custom-routes{{end}}{{if .Customer.ImportCustomRoutes}} \
+  --import-custom-routes{{end}}
+`
+
+var azureTerraformTemplate = `# Customer side of the Azure peering of Hazelcast Cloud cluster {{.Customer.ClusterId}}.
+# Once applied, complete the peering with:
+#   hzcloud azure-peering accept --cluster-id={{.Customer.ClusterId}} --tenant-id={{.Customer.TenantId}} --subscription-id={{.Customer.SubscriptionId}} --resource-group={{.Customer.ResourceGroupName}} --vnet={{.Customer.VnetName}}{{if .Customer.Environment}} --azure-environment={{.Customer.Environment}}{{end}}
+
+terraform {
+  required_providers {
+    azurerm = {
+      source  = "hashicorp/azurerm"
+      version = "~> 3.0"
+    }
+    azuread = {
+      source  = "hashicorp/azuread"
+      version = "~> 2.0"
+    }
+  }
+}
+
+provider "azurerm" {
+  features {}
+  tenant_id       = "{{.Customer.TenantId}}"
+  subscription_id = "{{.Customer.SubscriptionId}}"
+}
+
+provider "azuread" {
+  tenant_id = "{{.Customer.TenantId}}"
+}
+
+resource "azuread_service_principal" "hazelcast" {
+  application_id = "{{.Hazelcast.AppRegistrationId}}"
+}
+
+resource "azurerm_role_assignment" "hazelcast" {
+  scope              = "{{.CustomerVnetId}}"
+  role_definition_id = "/subscriptions/{{.Customer.SubscriptionId}}/providers/Microsoft.Authorization/roleDefinitions/{{.RoleId}}"
+  principal_id       = azuread_service_principal.hazelcast.object_id
+}
+
+resource "azurerm_virtual_network_peering" "hazelcast" {
+  name                         = "hazelcast-{{.Customer.ClusterId}}"
+  resource_group_name          = "{{.Customer.ResourceGroupName}}"
+  virtual_network_name         = "{{.Customer.VnetName}}"
+  remote_virtual_network_id    = "{{.HazelcastVnetId}}"
+  allow_virtual_network_access = true
+  allow_forwarded_traffic      = true
+}
+`
+
+var azureBicepTemplate = `// Customer side of the Azure peering of Hazelcast Cloud cluster {{.Customer.ClusterId}}, to deploy to resource group
+// {{.Customer.ResourceGroupName}}. Create the service principal of the Hazelcast application first with:
+//   az ad sp create --id {{.Hazelcast.AppRegistrationId}}
+// and pass its object id as servicePrincipalObjectId. Once deployed, complete the peering with:
+//   hzcloud azure-peering accept --cluster-id={{.Customer.ClusterId}} --tenant-id={{.Customer.TenantId}} --subscription-id={{.Customer.SubscriptionId}} --resource-group={{.Customer.ResourceGroupName}} --vnet={{.Customer.VnetName}}{{if .Customer.Environment}} --azure-environment={{.Customer.Environment}}{{end}}
+
+param servicePrincipalObjectId string
+
+resource vnet 'Microsoft.Network/virtualNetworks@2021-05-01' existing = {
+  name: '{{.Customer.VnetName}}'
+}
+
+resource roleAssignment 'Microsoft.Authorization/roleAssignments@2022-04-01' = {
+  name: guid(vnet.id, servicePrincipalObjectId, '{{.RoleId}}')
+  scope: vnet
+  properties: {
+    roleDefinitionId: subscriptionResourceId('Microsoft.Authorization/roleDefinitions', '{{.RoleId}}')
+    principalId: servicePrincipalObjectId
+    principalType: 'ServicePrincipal'
+  }
+}
+
+resource peering 'Microsoft.Network/virtualNetworks/virtualNetworkPeerings@2021-05-01' = {
+  parent: vnet
+  name: 'hazelcast-{{.Customer.ClusterId}}'
+  properties: {
+    remoteVirtualNetwork: {
+      id: '{{.HazelcastVnetId}}'
+    }
+    allowVirtualNetworkAccess: true
+    allowForwardedTraffic: true
+  }
+}
+`
+
+// Emit returns the Terraform or CloudFormation of the customer side of the peering instead of creating it, the
+// Hazelcast side is completed with Accept once it is applied.
+func (s *AwsPeeringService) Emit(format string) (string, error) {
+	templates := map[string]string{EmitTerraform: awsTerraformTemplate, EmitCloudFormation: awsCloudFormationTemplate}
+	if _, ok := templates[format]; !ok {
+		return "", unsupportedEmitFormatError(format, "aws", EmitTerraform, EmitCloudFormation)
+	}
+	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
+	if initHazelcastPeeringPropertiesErr != nil {
+		return "", initHazelcastPeeringPropertiesErr
+	}
+	data := awsPeeringTemplateData{
+		Customer:                s.customerPeeringProperties,
+		Hazelcast:               s.hazelcastPeeringProperties,
+		IngressSecurityGroupIds: s.customerPeeringProperties.IngressSecurityGroupIds,
+		EgressSecurityGroupIds:  s.customerPeeringProperties.EgressSecurityGroupIds,
+	}
+	if len(data.IngressSecurityGroupIds) != 0 || len(data.EgressSecurityGroupIds) != 0 {
+		cluster, _, clusterErr := s.client.EnterpriseCluster.Get(context.Background(), &models.GetEnterpriseClusterInput{
+			ClusterId: s.customerPeeringProperties.ClusterId,
+		})
+		if clusterErr != nil {
+			return "", clusterErr
+		}
+		data.Port = cluster.Port
+	}
+	return renderPeeringTemplate(templates[format], data)
+}
+
+// Emit returns the Terraform or gcloud script of the customer side of the peering instead of creating it.
+func (s GcpPeeringService) Emit(customerProperties *GcpCustomerPeeringProperties, format string) (string, error) {
+	templates := map[string]string{EmitTerraform: gcpTerraformTemplate, EmitGcloudScript: gcpScriptTemplate}
+	if _, ok := templates[format]; !ok {
+		return "", unsupportedEmitFormatError(format, "gcp", EmitTerraform, EmitGcloudScript)
+	}
+	hazelcastProperties, _, hazelcastPropertiesErr := s.Client.GcpPeering.GetProperties(context.Background(), &models.GetGcpPeeringPropertiesInput{
+		ClusterId: customerProperties.ClusterId,
+	})
+	if hazelcastPropertiesErr != nil {
+		return "", hazelcastPropertiesErr
+	}
+	networkProjectId := customerProperties.ProjectId
+	if customerProperties.HostProjectId != "" {
+		networkProjectId = customerProperties.HostProjectId
+	}
+	return renderPeeringTemplate(templates[format], gcpPeeringTemplateData{
+		Customer:         customerProperties,
+		Hazelcast:        hazelcastProperties,
+		NetworkProjectId: networkProjectId,
+		PeeringName:      getGcpPeeringName(hazelcastProperties),
+	})
+}
+
+// Emit returns the Terraform or Bicep of the customer side of the peering instead of creating it.
+func (s *AzurePeeringService) Emit(format string) (string, error) {
+	templates := map[string]string{EmitTerraform: azureTerraformTemplate, EmitBicep: azureBicepTemplate}
+	if _, ok := templates[format]; !ok {
+		return "", unsupportedEmitFormatError(format, "azure", EmitTerraform, EmitBicep)
+	}
+	initHazelcastPeeringPropertiesErr := s.initHazelcastPeeringProperties()
+	if initHazelcastPeeringPropertiesErr != nil {
+		return "", initHazelcastPeeringPropertiesErr
+	}
+	return renderPeeringTemplate(templates[format], azurePeeringTemplateData{
+		Customer:        s.customerPeeringProperties,
+		Hazelcast:       s.hazelcastPeeringProperties,
+		CustomerVnetId:  s.getCustomerVnetId(),
+		HazelcastVnetId: s.getHazelcastVnetId(),
+		RoleId:          azureNetworkContributorRoleId,
+	})
+}
+
+func renderPeeringTemplate(text string, data interface{}) (string, error) {
+	parsedTemplate, parseErr := template.New("peering").Funcs(peeringTemplateFuncs).Parse(text)
+	if parseErr != nil {
+		return "", parseErr
+	}
+	var buffer bytes.Buffer
+	executeErr := parsedTemplate.Execute(&buffer, data)
+	if executeErr != nil {
+		return "", executeErr
+	}
+	return buffer.String(), nil
+}
+
+func unsupportedEmitFormatError(format string, cloudProvider string, supportedFormats ...string) error {
+	return fmt.Errorf("%s is not supported for %s peering, use one of %s", format, cloudProvider, strings.Join(supportedFormats, ", "))
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hazelcast/hazelcast-cloud-cli/service/fakecloud"
+)
+
+func assertContains(t *testing.T, name string, text string, expected ...string) {
+	t.Helper()
+	for _, part := range expected {
+		if !strings.Contains(text, part) {
+			t.Errorf("%s does not contain %q:\n%s", name, part, text)
+		}
+	}
+}
+
+func TestAwsPeeringServiceEmitTerraform(t *testing.T) {
+	_, client, clusterId := newPeeringTestClient(t, "aws", "eu-west-2")
+	service := newAwsPeeringTestService(client, clusterId, fakecloud.NewEc2())
+
+	text, err := service.Emit(EmitTerraform)
+	if err != nil {
+		t.Fatalf("Emit failed: %s", err)
+	}
+	assertContains(t, "terraform", text,
+		`data "aws_route_tables" "hazelcast_subnet" {`,
+		`name   = "association.subnet-id"`,
+		`name   = "association.main"`,
+		`length(tables.ids) > 0 ? tables.ids[0] : data.aws_route_table.hazelcast_main.id`,
+		`version = ">= 4.0"`,
+		"hzcloud aws-peering accept --cluster-id="+clusterId+" --vpc-id=vpc-1 --subnet-ids=subnet-1,subnet-2")
+	if strings.Contains(text, "subnet_id = each.value") {
+		t.Errorf("terraform looks up route tables by subnet only:\n%s", text)
+	}
+}
+
+func TestGcpPeeringServiceEmit(t *testing.T) {
+	_, client, clusterId := newPeeringTestClient(t, "gcp", "europe-west1")
+	service := NewGcpPeeringServiceWithNetworksClient(client, newGcpPeeringTestNetworks())
+	customerProperties := newGcpCustomerTestProperties(clusterId)
+	customerProperties.HostProjectId = "my-host-project"
+
+	for _, format := range []string{EmitTerraform, EmitGcloudScript} {
+		text, err := service.Emit(customerProperties, format)
+		if err != nil {
+			t.Fatalf("Emit %s failed: %s", format, err)
+		}
+		assertContains(t, format, text,
+			"hzcloud gcp-peering accept --cluster-id="+clusterId+" --project-id=my-project --host-project-id=my-host-project --network-name=my-network")
+	}
+}
+
+func TestAzurePeeringServiceEmit(t *testing.T) {
+	_, client, clusterId := newPeeringTestClient(t, "azure", "westeurope")
+	service := newAzurePeeringTestService(client, clusterId, newAzurePeeringTestClients().azureClients())
+
+	for _, format := range []string{EmitTerraform, EmitBicep} {
+		text, err := service.Emit(format)
+		if err != nil {
+			t.Fatalf("Emit %s failed: %s", format, err)
+		}
+		assertContains(t, format, text,
+			"hzcloud azure-peering accept --cluster-id="+clusterId+" --tenant-id=my-tenant --subscription-id=my-subscription --resource-group=my-resource-group --vnet=my-vnet")
+		if strings.Contains(text, "azure-peering create") {
+			t.Errorf("%s completes the peering with create:\n%s", format, text)
+		}
+	}
+}
@@ -3,12 +3,10 @@ package util
 import (
 	"errors"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
-	"github.com/fatih/color"
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
-	"github.com/jedib0t/go-pretty/v6/list"
 )
 
 func AugmentStarterClusterType(starterClusterCreateClusterType string) (models.ClusterType, error) {
@@ -48,10 +46,8 @@ func getIsClusterEnterprise(cluster models.Cluster) bool {
 	return cluster.ProductType.Name == models.Enterprise
 }
 
-func printCluster(cluster models.Cluster, printStyle PrintStyle) {
-	wr := list.NewWriter()
-	wr.SetOutputMirror(os.Stdout)
-	wr.SetStyle(list.StyleConnectedBold)
+func printCluster(out io.Writer, cluster models.Cluster, printStyle PrintStyle) {
+	wr := newItemWriter(out)
 
 	wr.AppendItem(fmt.Sprintf("Id: %s", cluster.Id))
 	wr.AppendItem(fmt.Sprintf("Display Name: %s", cluster.Name))
@@ -130,7 +126,7 @@ func printCluster(cluster models.Cluster, printStyle PrintStyle) {
 
 	wr.AppendItem("Data Structures")
 	wr.Indent()
-	wr.AppendItem("Map Configs")
+	wr.AppendItem("Map Configs:")
 	for _, mapConfig := range cluster.DataStructures.MapConfigs {
 		wr.Indent()
 		wr.AppendItem(mapConfig.Name)
@@ -151,12 +147,12 @@ func printCluster(cluster models.Cluster, printStyle PrintStyle) {
 			wr.Indent()
 			wr.AppendItem(fmt.Sprintf("Class Name: %s", mapConfig.MapStore.ClassName))
 			wr.AppendItem(fmt.Sprintf("Write Batch Size: %d", mapConfig.MapStore.WriteBatchSize))
-			wr.AppendItem(fmt.Sprintf("Write Colaescing: %t", mapConfig.MapStore.WriteCoalescing))
+			wr.AppendItem(fmt.Sprintf("Write Coalescing: %t", mapConfig.MapStore.WriteCoalescing))
 			wr.AppendItem(fmt.Sprintf("Initial Load Mode: %s", mapConfig.MapStore.InitialLoadMode))
 			wr.AppendItem(fmt.Sprintf("Write Delay Seconds: %d", mapConfig.MapStore.WriteDelaySeconds))
 			wr.UnIndent()
 		}
-		wr.AppendItem(fmt.Sprintf("Max Idle Seconods: %d", mapConfig.MaxIdleSeconds))
+		wr.AppendItem(fmt.Sprintf("Max Idle Seconds: %d", mapConfig.MaxIdleSeconds))
 		wr.AppendItem(fmt.Sprintf("Max Size: %d", mapConfig.MaxSize))
 		wr.AppendItem(fmt.Sprintf("Max Size Policy: %s", mapConfig.MaxSizePolicy))
 		wr.AppendItem(fmt.Sprintf("Ttl Seconds: %d", mapConfig.TtlSeconds))
@@ -255,7 +251,7 @@ func printCluster(cluster models.Cluster, printStyle PrintStyle) {
 		wr.AppendItem(fmt.Sprintf("Async Backup Count: %d", ringBufferConfig.AsyncBackupCount))
 		wr.AppendItem(fmt.Sprintf("Backup Count: %d", ringBufferConfig.BackupCount))
 		wr.AppendItem(fmt.Sprintf("Capacity: %d", ringBufferConfig.Capacity))
-		wr.AppendItem(fmt.Sprintf("In Memory Formay: %s", ringBufferConfig.InMemoryFormat))
+		wr.AppendItem(fmt.Sprintf("In Memory Format: %s", ringBufferConfig.InMemoryFormat))
 		wr.AppendItem(fmt.Sprintf("TTL Seconds: %d", ringBufferConfig.TtlSeconds))
 		wr.AppendItem(fmt.Sprintf("Ready: %t", ringBufferConfig.IsReady))
 		wr.UnIndent()
@@ -274,7 +270,7 @@ func printCluster(cluster models.Cluster, printStyle PrintStyle) {
 		wr.UnIndent()
 	}
 
-	wr.AppendItem("ReliableTopic Configs:")
+	wr.AppendItem("ReplicatedMap Configs:")
 	for _, replicatedMapConfig := range cluster.DataStructures.ReplicatedMapConfigs {
 		wr.Indent()
 		wr.AppendItem(replicatedMapConfig.Name)
@@ -288,14 +284,5 @@ func printCluster(cluster models.Cluster, printStyle PrintStyle) {
 
 	wr.UnIndent()
 	wr.UnIndent()
-	if printStyle == PrintStyleDefault {
-		wr.Render()
-	} else if printStyle == PrintStyleHtml {
-		wr.RenderHTML()
-	} else if printStyle == PrintStyleMarkdown {
-		wr.RenderMarkdown()
-	} else if printStyle == PrintStyleCsv {
-		color.Red("CSV not supported on this type.")
-		os.Exit(1)
-	}
+	wr.render(printStyle)
 }
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/list"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// itemWriter renders nested "Name: Value" items as a list, and additionally keeps track of them so that
+// they can be rendered as CSV, which list.Writer does not support.
+type itemWriter struct {
+	out   io.Writer
+	list  list.Writer
+	level int
+	items []writtenItem
+}
+
+type writtenItem struct {
+	level int
+	text  string
+}
+
+func newItemWriter(out io.Writer) *itemWriter {
+	wr := list.NewWriter()
+	wr.SetOutputMirror(out)
+	wr.SetStyle(list.StyleConnectedBold)
+	return &itemWriter{
+		out:  out,
+		list: wr,
+	}
+}
+
+func (w *itemWriter) AppendItem(item interface{}) {
+	w.items = append(w.items, writtenItem{level: w.level, text: fmt.Sprint(item)})
+	w.list.AppendItem(item)
+}
+
+func (w *itemWriter) Indent() {
+	if len(w.items) != 0 && w.level <= w.items[len(w.items)-1].level {
+		w.level++
+	}
+	w.list.Indent()
+}
+
+func (w *itemWriter) UnIndent() {
+	if w.level > 0 {
+		w.level--
+	}
+	w.list.UnIndent()
+}
+
+func (w *itemWriter) render(printStyle PrintStyle) {
+	if printStyle == PrintStyleDefault {
+		w.list.Render()
+	} else if printStyle == PrintStyleHtml {
+		w.list.RenderHTML()
+	} else if printStyle == PrintStyleMarkdown {
+		w.list.RenderMarkdown()
+	} else if printStyle == PrintStyleCsv {
+		w.renderCSV()
+	}
+}
+
+func (w *itemWriter) renderCSV() {
+	t := table.NewWriter()
+	t.SetOutputMirror(w.out)
+	t.AppendHeader(table.Row{"Section", "Name", "Value"})
+	var sections []string
+	for _, item := range w.items {
+		if item.level < len(sections) {
+			sections = sections[:item.level]
+		}
+		separator := strings.Index(item.text, ": ")
+		if separator == -1 {
+			for len(sections) < item.level {
+				sections = append(sections, "")
+			}
+			sections = append(sections, strings.TrimSuffix(strings.TrimSpace(item.text), ":"))
+			continue
+		}
+		t.AppendRow(table.Row{
+			strings.Join(sections, " > "),
+			strings.TrimSpace(item.text[:separator]),
+			strings.TrimSpace(item.text[separator+2:]),
+		})
+	}
+	t.RenderCSV()
+}
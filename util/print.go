@@ -6,6 +6,7 @@ import (
 	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
+	"io"
 	"os"
 	"reflect"
 )
@@ -20,33 +21,41 @@ const (
 	PrintStyleJson     PrintStyle = "json"
 )
 
+// exit ends the command after an unsupported item is printed, tests replace it.
+var exit = os.Exit
+
 type PrintRequest struct {
 	Rows       []table.Row
 	Header     table.Row
 	Data       interface{}
 	PrintStyle PrintStyle
+	Writer     io.Writer
 }
 
 func Print(request PrintRequest) {
+	out := request.Writer
+	if out == nil {
+		out = os.Stdout
+	}
 	if request.PrintStyle == PrintStyleJson {
-		printJSON(request.Data)
+		printJSON(out, request.Data)
 		return
 	}
 	if request.Header != nil && request.Rows != nil {
-		printTable(request.Rows, request.Header, request.PrintStyle)
+		printTable(out, request.Rows, request.Header, request.PrintStyle)
 	} else {
-		printItem(request.Data, request.PrintStyle)
+		printItem(out, request.Data, request.PrintStyle)
 	}
 }
 
-func printJSON(any interface{}) {
+func printJSON(out io.Writer, any interface{}) {
 	transformer := text.NewJSONTransformer("", "    ")
-	fmt.Printf("%s", transformer(any))
+	fmt.Fprintf(out, "%s", transformer(any))
 }
 
-func printTable(rows []table.Row, header table.Row, printType PrintStyle) {
+func printTable(out io.Writer, rows []table.Row, header table.Row, printType PrintStyle) {
 	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+	t.SetOutputMirror(out)
 	t.SetStyle(table.StyleLight)
 	t.Style().Format.Header = text.FormatTitle
 	t.Style().Format.Footer = text.FormatDefault
@@ -65,11 +74,11 @@ func printTable(rows []table.Row, header table.Row, printType PrintStyle) {
 	}
 }
 
-func printItem(data interface{}, printStyle PrintStyle) {
+func printItem(out io.Writer, data interface{}, printStyle PrintStyle) {
 	if reflect.TypeOf(data) == reflect.TypeOf(models.Cluster{}) {
-		printCluster(data.(models.Cluster), printStyle)
+		printCluster(out, data.(models.Cluster), printStyle)
 	} else {
-		color.Red("Not Implemented")
-		os.Exit(1)
+		_, _ = color.New(color.FgRed).Fprintln(out, "Not Implemented")
+		exit(1)
 	}
 }
@@ -0,0 +1,267 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/hazelcast/hazelcast-cloud-sdk-go/models"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+var update = flag.Bool("update", false, "regenerate golden files instead of comparing against them")
+
+var printStyles = []PrintStyle{PrintStyleDefault, PrintStyleCsv, PrintStyleHtml, PrintStyleMarkdown, PrintStyleJson}
+
+func TestPrintCluster(t *testing.T) {
+	assertAllStyles(t, "enterprise-cluster", PrintRequest{Data: enterpriseClusterFixture()})
+	assertAllStyles(t, "starter-cluster", PrintRequest{Data: starterClusterFixture()})
+}
+
+func TestPrintRegions(t *testing.T) {
+	regions := regionsFixture()
+	rows := []table.Row{}
+	for k, region := range regions {
+		rows = append(rows, table.Row{k + 1, region.Name, region.IsEnabledForStarter, region.IsEnabledForEnterprise})
+	}
+	assertAllStyles(t, "regions", PrintRequest{
+		Header: table.Row{"#", "Name", "Available in Starter", "Available in Enterprise"},
+		Rows:   rows,
+		Data:   regions,
+	})
+}
+
+func TestPrintPeerings(t *testing.T) {
+	awsPeerings := awsPeeringsFixture()
+	awsRows := []table.Row{}
+	for k, peering := range awsPeerings {
+		awsRows = append(awsRows, table.Row{k + 1, peering.Id, peering.VpcId, peering.VpcCidr, peering.SubnetId, peering.SubnetCidr})
+	}
+	assertAllStyles(t, "aws-peerings", PrintRequest{
+		Header: table.Row{"#", "Peering Id", "Vpc Id", "Vpc Cidr", "Subnet Id", "Subnet Cidr"},
+		Rows:   awsRows,
+		Data:   awsPeerings,
+	})
+
+	gcpPeerings := gcpPeeringsFixture()
+	gcpRows := []table.Row{}
+	for k, peering := range gcpPeerings {
+		gcpRows = append(gcpRows, table.Row{k + 1, peering.Id, peering.ProjectId, peering.NetworkName})
+	}
+	assertAllStyles(t, "gcp-peerings", PrintRequest{
+		Header: table.Row{"#", "Peering Id", "Project Id", "Network Name"},
+		Rows:   gcpRows,
+		Data:   gcpPeerings,
+	})
+
+	azurePeerings := azurePeeringsFixture()
+	azureRows := []table.Row{}
+	for k, peering := range azurePeerings {
+		azureRows = append(azureRows, table.Row{k + 1, peering.Id, peering.VpcId, peering.VpcCidr})
+	}
+	assertAllStyles(t, "azure-peerings", PrintRequest{
+		Header: table.Row{"#", "Peering Id", "vNet Name", "vNet Cidr"},
+		Rows:   azureRows,
+		Data:   azurePeerings,
+	})
+}
+
+func TestPrintUploadedArtifacts(t *testing.T) {
+	artifacts := uploadedArtifactsFixture()
+	rows := []table.Row{}
+	for _, artifact := range artifacts {
+		rows = append(rows, table.Row{artifact.Id, artifact.Name, artifact.Status})
+	}
+	assertAllStyles(t, "uploaded-artifacts", PrintRequest{
+		Header: table.Row{"Id", "File Name", "Status"},
+		Rows:   rows,
+		Data:   artifacts,
+	})
+}
+
+func TestPrintNotImplementedItem(t *testing.T) {
+	exitCode := -1
+	exit = func(code int) { exitCode = code }
+	defer func() { exit = os.Exit }()
+
+	out := capture(PrintRequest{Data: regionsFixture()}, PrintStyleDefault)
+
+	if string(out) != "Not Implemented\n" || exitCode != 1 {
+		t.Errorf("printed %q and exited with %d", out, exitCode)
+	}
+}
+
+// capture renders the request with the given style and returns what Print wrote. Colors are disabled so that the
+// output does not depend on the terminal.
+func capture(request PrintRequest, printStyle PrintStyle) []byte {
+	color.NoColor = true
+	text.DisableColors()
+	var out bytes.Buffer
+	request.Writer = &out
+	request.PrintStyle = printStyle
+	Print(request)
+	return out.Bytes()
+}
+
+// assertAllStyles renders the request with every print style and compares each output with
+// testdata/<name>.<style>.golden, rewriting the files when the -update flag is given.
+func assertAllStyles(t *testing.T, name string, request PrintRequest) {
+	t.Helper()
+	for _, printStyle := range printStyles {
+		printStyle := printStyle
+		t.Run(name+"/"+string(printStyle), func(t *testing.T) {
+			assertGolden(t, name+"."+string(printStyle), capture(request, printStyle))
+		})
+	}
+}
+
+func assertGolden(t *testing.T, name string, actual []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s, run the test with -update to create it", err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("output does not match %s, run the test with -update if the change is intended\n--- expected\n%s\n--- actual\n%s",
+			path, expected, actual)
+	}
+}
+
+const enterpriseClusterJson = `{
+	"id": "1201",
+	"customerId": 42,
+	"name": "fixture-enterprise",
+	"releaseName": "ent-1201",
+	"password": "secret",
+	"port": 31000,
+	"hazelcastVersion": "5.0",
+	"isAutoScalingEnabled": false,
+	"isHotBackupEnabled": true,
+	"isHotRestartEnabled": true,
+	"isIpWhitelistEnabled": false,
+	"isTlsEnabled": true,
+	"productType": {"name": "Enterprise", "isFree": false},
+	"clusterType": {"name": "DEDICATED"},
+	"state": "RUNNING",
+	"createdAt": "2021-10-01T10:00:00Z",
+	"startedAt": "2021-10-01T10:20:00Z",
+	"stoppedAt": "",
+	"cloudProvider": {"name": "aws", "region": "eu-west-2", "availabilityZones": ["eu-west-2a", "eu-west-2b"]},
+	"discoveryTokens": [{"source": "default", "token": "token-1201"}],
+	"specs": {"totalMemory": 16, "heapMemory": 4, "nativeMemory": 8, "cpu": 2, "instanceType": "m5.large", "instancePerZone": 1},
+	"networking": {
+		"type": "PEERING",
+		"cidrBlock": "10.80.0.0/16",
+		"peering": {"isEnabled": true},
+		"privateLink": {"url": "", "state": ""}
+	},
+	"dataStructures": {
+		"mapConfigs": [{
+			"name": "orders",
+			"asyncBackupCount": 0,
+			"backupCount": 1,
+			"evictionPolicy": "LRU",
+			"mapIndices": [{"name": "customerId"}],
+			"mapStore": {"className": "com.example.OrderStore", "writeBatchSize": 1, "writeCoalescing": true, "initialLoadMode": "EAGER", "writeDelaySeconds": 0},
+			"maxIdleSeconds": 0,
+			"maxSize": 1000,
+			"maxSizePolicy": "PER_NODE",
+			"ttlSeconds": 60,
+			"isReady": true
+		}],
+		"listConfigs": [{"name": "events", "asyncBackupCount": 0, "backupCount": 1, "maxSize": 10, "isReady": true}],
+		"replicatedMapConfigs": [{"name": "settings", "inMemoryFormat": "BINARY", "asyncFillUp": true, "isReady": true}]
+	}
+}`
+
+const starterClusterJson = `{
+	"id": "1202",
+	"customerId": 42,
+	"name": "fixture-starter",
+	"releaseName": "pr-1202",
+	"password": "secret",
+	"port": 31000,
+	"hazelcastVersion": "5.0",
+	"productType": {"name": "Starter", "isFree": true},
+	"clusterType": {"name": "FREE"},
+	"state": "STOPPED",
+	"createdAt": "2021-10-01T10:00:00Z",
+	"startedAt": "2021-10-01T10:05:00Z",
+	"stoppedAt": "2021-10-02T10:00:00Z",
+	"cloudProvider": {"name": "aws", "region": "us-east-1"},
+	"discoveryTokens": [{"source": "default", "token": "token-1202"}],
+	"specs": {"totalMemory": 0.2}
+}`
+
+func fromJson(content string, target interface{}) {
+	if err := json.Unmarshal([]byte(content), target); err != nil {
+		panic(err)
+	}
+}
+
+func enterpriseClusterFixture() models.Cluster {
+	var cluster models.Cluster
+	fromJson(enterpriseClusterJson, &cluster)
+	return cluster
+}
+
+func starterClusterFixture() models.Cluster {
+	var cluster models.Cluster
+	fromJson(starterClusterJson, &cluster)
+	return cluster
+}
+
+func regionsFixture() []models.Region {
+	var regions []models.Region
+	fromJson(`[
+		{"name": "us-east-1", "isEnabledForStarter": true, "isEnabledForEnterprise": true},
+		{"name": "eu-west-2", "isEnabledForStarter": false, "isEnabledForEnterprise": true}
+	]`, &regions)
+	return regions
+}
+
+func awsPeeringsFixture() []models.AwsPeering {
+	var peerings []models.AwsPeering
+	fromJson(`[
+		{"id": "1", "vpcId": "vpc-0a1b", "vpcCidr": "172.31.0.0/16", "subnetId": "subnet-1", "subnetCidr": "172.31.0.0/20"},
+		{"id": "2", "vpcId": "vpc-0a1b", "vpcCidr": "172.31.0.0/16", "subnetId": "subnet-2", "subnetCidr": "172.31.16.0/20"}
+	]`, &peerings)
+	return peerings
+}
+
+func gcpPeeringsFixture() []models.GcpPeering {
+	var peerings []models.GcpPeering
+	fromJson(`[{"id": "3", "projectId": "my-project", "networkName": "default"}]`, &peerings)
+	return peerings
+}
+
+func azurePeeringsFixture() []models.AzurePeering {
+	var peerings []models.AzurePeering
+	fromJson(`[{"id": "4", "vpcId": "my-vnet", "vpcCidr": "10.1.0.0/16"}]`, &peerings)
+	return peerings
+}
+
+func uploadedArtifactsFixture() []models.UploadedArtifact {
+	var artifacts []models.UploadedArtifact
+	fromJson(`[
+		{"id": "10", "name": "entities.jar", "status": "READY"},
+		{"id": "11", "name": "stores.jar", "status": "FAILED"}
+	]`, &artifacts)
+	return artifacts
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrSkipped is the error of the tasks RunConcurrently did not start because an earlier task failed.
+var ErrSkipped = errors.New("skipped after an earlier failure")
+
+// RunConcurrently runs task for indexes 0 to count-1 with at most parallelism tasks running at a time and returns
+// their errors by index. Unless continueOnError is set, tasks are not started any more once a task failed.
+func RunConcurrently(count int, parallelism int, continueOnError bool, task func(index int) error) []error {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	errs := make([]error, count)
+	indexes := make(chan int)
+	var mutex sync.Mutex
+	failed := false
+	var wg sync.WaitGroup
+	for i := 0; i < parallelism && i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				mutex.Lock()
+				skip := failed && !continueOnError
+				mutex.Unlock()
+				if skip {
+					errs[index] = ErrSkipped
+					continue
+				}
+				err := task(index)
+				errs[index] = err
+				if err != nil {
+					mutex.Lock()
+					failed = true
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for index := 0; index < count; index++ {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+	return errs
+}